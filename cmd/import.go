@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ranking-service/internal/importer"
+)
+
+var (
+	importFormat     string
+	importCheckpoint string
+	importRestart    bool
+	importBatchSize  int
+
+	importCmd = &cobra.Command{
+		Use:   "import <videos|interactions> <file>",
+		Short: "Bulk import videos or historical interactions from CSV or NDJSON",
+		Long: `Bulk import catalog entries with their scores, or historical interactions scored with
+the configured ranking strategy, into PostgreSQL and Redis. The stores are configured like
+the server; pause ingestion during the import.
+
+Videos have the columns video_id, user_id, title, category, score and created_at;
+interactions have video_id, type, weight, user_id and at. CSV files start with a header
+naming their columns. Invalid records are logged and skipped.
+
+Progress is checkpointed after each batch: run the same command again to resume after a
+failure or an interruption.`,
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{importer.KindVideos, importer.KindInteractions},
+		RunE:      runImport,
+	}
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importFormat, "format", "", "File format: csv or ndjson (default: from the file extension, csv for standard input)")
+	importCmd.Flags().StringVar(&importCheckpoint, "checkpoint", "", "Name of the checkpoint to resume from (default: <kind>:<file name>)")
+	importCmd.Flags().BoolVar(&importRestart, "restart", false, "Ignore the checkpoint and import the file from the start")
+	importCmd.Flags().IntVar(&importBatchSize, "batch-size", importer.DefaultBatchSize, "Number of records written per batch")
+	importCmd.SilenceUsage = true
+	importCmd.SilenceErrors = true
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	kind, file := args[0], args[1]
+	if kind != importer.KindVideos && kind != importer.KindInteractions {
+		return fmt.Errorf("unknown import kind %q, expected videos or interactions", kind)
+	}
+
+	format := importFormat
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".ndjson", ".jsonl":
+			format = importer.FormatNDJSON
+		default:
+			format = importer.FormatCSV
+		}
+	}
+	checkpoint := importCheckpoint
+	if checkpoint == "" {
+		name := filepath.Base(file)
+		if file == "-" {
+			name = "stdin"
+		}
+		checkpoint = kind + ":" + name
+	}
+
+	var in io.Reader = cmd.InOrStdin()
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	stores, err := newDirectStores()
+	if err != nil {
+		return err
+	}
+
+	// Stop after the current batch on interrupt; the import resumes from there.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	stderr := cmd.ErrOrStderr()
+	var resumed bool
+	progress, err := importer.New(stores.postgres, stores.redis, stores.scorer, stores.cfg.Ranking).Run(ctx, in, importer.Options{
+		Kind:       kind,
+		Format:     format,
+		Checkpoint: checkpoint,
+		Restart:    importRestart,
+		BatchSize:  importBatchSize,
+		Progress: func(p importer.Progress) {
+			if p.Skipped > 0 && !resumed {
+				resumed = true
+				fmt.Fprintf(stderr, "resumed %s after %d records\n", checkpoint, p.Skipped)
+			}
+			rate := float64(p.Position-p.Skipped) / time.Since(start).Seconds()
+			fmt.Fprintf(stderr, "%d records: %d imported, %d rejected (%.0f records/s)\n", p.Position, p.Imported, p.Rejected, rate)
+		},
+	})
+	switch {
+	case errors.Is(err, importer.ErrAlreadyImported):
+		return fmt.Errorf("%s was already imported (%d imported, %d rejected), use --restart to import it again", checkpoint, progress.Imported, progress.Rejected)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("import interrupted after %d records, run the command again to resume", progress.Position)
+	case err != nil:
+		return fmt.Errorf("import failed after %d records, run the command again to resume: %w", progress.Position, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported %d records, rejected %d, in %s\n", progress.Imported, progress.Rejected, time.Since(start).Round(time.Second))
+	return nil
+}
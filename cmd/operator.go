@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ranking-service/config"
+	"ranking-service/internal/anomaly"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/impression"
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+	"ranking-service/pkg/client"
+)
+
+// Operator commands call a running server, or the stores directly with --direct.
+var (
+	operatorServer string
+	operatorToken  string
+	operatorDirect bool
+	operatorOutput string
+
+	topLimit          int
+	topUser           string
+	topAt             string
+	topMaxPerOwner    int
+	topMaxPerCategory int
+
+	interactType   string
+	interactUser   string
+	interactViewer string
+	interactToken  string
+	interactWeight float64
+
+	topCmd = &cobra.Command{
+		Use:   "top",
+		Short: "Show the global top videos, or a user's with --user",
+		Args:  cobra.NoArgs,
+		RunE:  runTop,
+	}
+
+	rankCmd = &cobra.Command{
+		Use:   "rank <video>",
+		Short: "Show the rank of a video in the global ranking",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRank,
+	}
+
+	exportOwner  string
+	exportWindow time.Duration
+	exportFormat string
+	exportFile   string
+
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export a leaderboard as CSV or NDJSON",
+		Long:  "Export every visible video of the global leaderboard, of an owner's leaderboard (--owner) or of a windowed leaderboard (--window).",
+		Args:  cobra.NoArgs,
+		RunE:  runExport,
+	}
+
+	interactCmd = &cobra.Command{
+		Use:   "interact <video>",
+		Short: "Record an interaction with a video",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runInteract,
+	}
+)
+
+func init() {
+	defaultServer := os.Getenv("RANKING_SERVER_URL")
+	if defaultServer == "" {
+		defaultServer = "http://localhost:8080"
+	}
+	for _, cmd := range []*cobra.Command{topCmd, rankCmd, interactCmd, exportCmd} {
+		cmd.Flags().StringVar(&operatorServer, "server", defaultServer, "URL of the ranking service (env RANKING_SERVER_URL)")
+		cmd.Flags().StringVar(&operatorToken, "token", "", "Bearer token for the ranking service (default: env RANKING_TOKEN)")
+		cmd.Flags().BoolVar(&operatorDirect, "direct", false, "Talk to PostgreSQL and Redis directly, configured like the server, instead of calling it")
+		if cmd != exportCmd {
+			cmd.Flags().StringVarP(&operatorOutput, "output", "o", "table", "Output format: table or json")
+		}
+		// Execute reports the errors.
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+
+	topCmd.Flags().IntVarP(&topLimit, "limit", "n", 10, "Number of videos")
+	topCmd.Flags().StringVar(&topUser, "user", "", "Show the top videos of this user")
+	topCmd.Flags().StringVar(&topAt, "at", "", "Show the ranking as of this RFC 3339 time, from the nearest leaderboard snapshot")
+	topCmd.Flags().IntVar(&topMaxPerOwner, "max-per-owner", 0, "Maximum number of videos per owner, 0 disables the cap (default: server configuration)")
+	topCmd.Flags().IntVar(&topMaxPerCategory, "max-per-category", 0, "Maximum number of videos per category, 0 disables the cap (default: server configuration)")
+
+	exportCmd.Flags().StringVar(&exportOwner, "owner", "", "Export the leaderboard of this owner's videos")
+	exportCmd.Flags().DurationVar(&exportWindow, "window", 0, "Export the windowed leaderboard of this configured window, e.g. 24h")
+	exportCmd.Flags().StringVar(&exportFormat, "format", handlers.ExportCSV, "Export format: csv or ndjson")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "-", "File to write, - for standard output")
+
+	interactCmd.Flags().StringVar(&interactType, "type", "", "Interaction type: view, like, comment, share, watch_time or report")
+	interactCmd.Flags().StringVar(&interactUser, "user", "", "Owner of the video")
+	interactCmd.Flags().StringVar(&interactViewer, "viewer", "", "Viewer who interacted")
+	interactCmd.Flags().StringVar(&interactToken, "token", "", "Interaction token served to the viewer with the video, when tokens are enabled")
+	interactCmd.Flags().Float64Var(&interactWeight, "weight", 0, "Weight of the interaction, e.g. seconds of watch_time")
+	interactCmd.MarkFlagRequired("type")
+	interactCmd.MarkFlagRequired("user")
+}
+
+func runTop(cmd *cobra.Command, args []string) error {
+	var at time.Time
+	if topAt != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, topAt); err != nil {
+			return fmt.Errorf("invalid --at, expected an RFC 3339 time: %w", err)
+		}
+	}
+
+	if topUser != "" {
+		var videos []models.UserTopVideo
+		if operatorDirect {
+			h, err := newDirectHandler()
+			if err != nil {
+				return err
+			}
+			if videos, _, err = h.UserTopVideos(topUser, topLimit, at); err != nil {
+				return err
+			}
+		} else {
+			c, err := newOperatorClient()
+			if err != nil {
+				return err
+			}
+			if videos, err = c.UserTopVideos(cmd.Context(), topUser, client.UserTopOptions{Limit: topLimit, At: at}); err != nil {
+				return err
+			}
+		}
+		return writeOutput(cmd.OutOrStdout(), videos, func(w io.Writer) {
+			fmt.Fprintln(w, "RANK\tVIDEO\tTITLE\tCATEGORY\tSCORE\tEDITORIAL")
+			for i, v := range videos {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, v.VideoID, v.Title, v.Category, formatScore(v.Score), v.Editorial)
+			}
+		})
+	}
+
+	var videos []models.RankedVideo
+	if operatorDirect {
+		h, err := newDirectHandler()
+		if err != nil {
+			return err
+		}
+		maxPerOwner, maxPerCategory := h.DiversityCaps()
+		if cmd.Flags().Changed("max-per-owner") {
+			maxPerOwner = topMaxPerOwner
+		}
+		if cmd.Flags().Changed("max-per-category") {
+			maxPerCategory = topMaxPerCategory
+		}
+		if videos, _, err = h.TopVideos(topLimit, maxPerOwner, maxPerCategory, at); err != nil {
+			return err
+		}
+	} else {
+		c, err := newOperatorClient()
+		if err != nil {
+			return err
+		}
+		opts := client.TopOptions{Limit: topLimit, At: at}
+		if cmd.Flags().Changed("max-per-owner") {
+			opts.MaxPerOwner = &topMaxPerOwner
+		}
+		if cmd.Flags().Changed("max-per-category") {
+			opts.MaxPerCategory = &topMaxPerCategory
+		}
+		if videos, err = c.TopVideos(cmd.Context(), opts); err != nil {
+			return err
+		}
+	}
+	return writeOutput(cmd.OutOrStdout(), videos, func(w io.Writer) {
+		fmt.Fprintln(w, "RANK\tVIDEO\tSCORE\tMOVEMENT\tEDITORIAL")
+		for i, v := range videos {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, v.VideoID, formatScore(v.Score), formatMovement(v), formatSlot(v))
+		}
+	})
+}
+
+func runRank(cmd *cobra.Command, args []string) error {
+	var (
+		rank *models.RankInfo
+		err  error
+	)
+	if operatorDirect {
+		h, herr := newDirectHandler()
+		if herr != nil {
+			return herr
+		}
+		rank, err = h.VideoRank(args[0])
+	} else {
+		c, cerr := newOperatorClient()
+		if cerr != nil {
+			return cerr
+		}
+		rank, err = c.VideoRank(cmd.Context(), args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(cmd.OutOrStdout(), rank, func(w io.Writer) {
+		fmt.Fprintln(w, "VIDEO\tRANK\tSCORE\tABOVE\tGAP")
+		above, gap := "-", "-"
+		if rank.Above != nil {
+			above, gap = rank.Above.VideoID, formatScore(rank.Gap)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", rank.VideoID, rank.Rank, formatScore(rank.Score), above, gap)
+	})
+}
+
+func runInteract(cmd *cobra.Command, args []string) error {
+	req := models.InteractionRequest{VideoID: args[0], Type: interactType, Weight: interactWeight, UserID: interactUser, ViewerID: interactViewer, Token: interactToken}
+
+	result := client.InteractionResult{VideoID: args[0]}
+	if operatorDirect {
+		h, err := newDirectHandler()
+		if err != nil {
+			return err
+		}
+		if err := h.ReloadWebhooks(); err != nil {
+			slog.Error("Failed to load webhook subscriptions", "error", err)
+		}
+		outcome, err := h.RecordInteraction(req)
+		if err != nil {
+			return err
+		}
+		result.Delta, result.Status = outcome.Delta, outcome.Status
+		switch outcome.Status {
+		case handlers.InteractionCapped:
+			result.Capped = outcome.Withheld
+		case handlers.InteractionQuarantined:
+			result.Quarantined = outcome.Withheld
+		}
+	} else {
+		c, err := newOperatorClient()
+		if err != nil {
+			return err
+		}
+		recorded, err := c.RecordInteraction(cmd.Context(), args[0], req)
+		if err != nil {
+			return err
+		}
+		result = *recorded
+	}
+
+	return writeOutput(cmd.OutOrStdout(), result, func(w io.Writer) {
+		fmt.Fprintln(w, "VIDEO\tDELTA\tSTATUS")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.VideoID, formatScore(result.Delta), result.Status)
+	})
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	contentTypes := map[string]string{handlers.ExportCSV: "text/csv", handlers.ExportNDJSON: "application/x-ndjson"}
+	contentType, ok := contentTypes[exportFormat]
+	if !ok {
+		return fmt.Errorf("unknown export format %q, expected csv or ndjson", exportFormat)
+	}
+	if exportOwner != "" && exportWindow > 0 {
+		return fmt.Errorf("--owner and --window cannot be combined")
+	}
+
+	out := cmd.OutOrStdout()
+	if exportFile != "-" {
+		f, err := os.Create(exportFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	board := models.Leaderboard{Owner: exportOwner, Window: exportWindow}
+	if operatorDirect {
+		h, err := newDirectHandler()
+		if err != nil {
+			return err
+		}
+		if err := h.ExportLeaderboard(w, board, exportFormat); err != nil {
+			return err
+		}
+	} else {
+		c, err := newOperatorClient()
+		if err != nil {
+			return err
+		}
+		if err := c.ExportLeaderboard(cmd.Context(), client.ExportOptions{Owner: exportOwner, Window: exportWindow}, contentType, w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// newOperatorClient creates a client for the server of --server, authenticated with
+// --token, or with the API key of env RANKING_API_KEY.
+func newOperatorClient() (*client.Client, error) {
+	token := operatorToken
+	if token == "" {
+		token = os.Getenv("RANKING_TOKEN")
+	}
+	return client.New(operatorServer, client.WithToken(token), client.WithAPIKey(os.Getenv("RANKING_API_KEY")))
+}
+
+// newDirectHandler creates a handler on the stores, configured like the server.
+func newDirectHandler() (*handlers.RankingHandler, error) {
+	stores, err := newDirectStores()
+	if err != nil {
+		return nil, err
+	}
+	opts := []handlers.Option{handlers.WithConfig(stores.cfg.Ranking), handlers.WithScorer(stores.scorer)}
+	if stores.cfg.Anomaly.Enabled {
+		detector, err := anomaly.New(stores.redis, stores.cfg.Anomaly)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anomaly detection configuration: %w", err)
+		}
+		opts = append(opts, handlers.WithAnomalyDetector(detector))
+	}
+	if stores.cfg.InteractionToken.Enabled {
+		tokens, err := impression.New(stores.redis, stores.cfg.InteractionToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interaction token configuration: %w", err)
+		}
+		opts = append(opts, handlers.WithInteractionTokens(tokens))
+	}
+	return handlers.NewRankingHandler(stores.postgres, stores.redis, opts...), nil
+}
+
+// directStores are the stores and ranking strategy configured like the server.
+type directStores struct {
+	cfg      config.ServerConfig
+	postgres *repository.PostgresDB
+	redis    *repository.RedisDB
+	scorer   scoring.Scorer
+}
+
+// newDirectStores connects to the stores and sets up the ranking strategy, configured
+// like the server.
+func newDirectStores() (*directStores, error) {
+	cfg := config.MustLoadServerConfigFromEnv()
+
+	postgresDb, err := repository.NewPostgresDB(cfg.Postgres)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect PostgreSQL: %w", err)
+	}
+	redisDb, err := repository.NewRedisDB(cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect Redis: %w", err)
+	}
+	scorer, err := scoring.New(cfg.Ranking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up ranking strategy: %w", err)
+	}
+	if formulaScorer, ok := scorer.(*scoring.FormulaScorer); ok {
+		reloadScoringFormula(formulaScorer, postgresDb)
+	}
+	return &directStores{cfg: cfg, postgres: postgresDb, redis: redisDb, scorer: scorer}, nil
+}
+
+// writeOutput writes v as indented JSON with --output json, or as a table.
+func writeOutput(out io.Writer, v any, table func(w io.Writer)) error {
+	switch operatorOutput {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table":
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown output format %q, expected table or json", operatorOutput)
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// formatMovement describes the movement of a video, e.g. "up 3" or "new".
+func formatMovement(v models.RankedVideo) string {
+	switch {
+	case v.Movement == "":
+		return "-"
+	case v.RankChange > 0:
+		return fmt.Sprintf("%s %d", v.Movement, v.RankChange)
+	case v.RankChange < 0:
+		return fmt.Sprintf("%s %d", v.Movement, -v.RankChange)
+	}
+	return v.Movement
+}
+
+// formatSlot tells editorial and exploration slots apart from organic ones.
+func formatSlot(v models.RankedVideo) string {
+	if v.Exploration {
+		return "exploration"
+	}
+	if v.Editorial == "" {
+		return "-"
+	}
+	return v.Editorial
+}
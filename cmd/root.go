@@ -18,6 +18,7 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(runService)
+	rootCmd.AddCommand(topCmd, rankCmd, interactCmd, exportCmd, importCmd)
 }
 
 func Execute() {
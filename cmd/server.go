@@ -2,22 +2,35 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 
 	_ "ranking-service/docs" // swagger generated docs
 
 	"ranking-service/config"
+	"ranking-service/internal/anomaly"
+	"ranking-service/internal/auth"
+	"ranking-service/internal/grpcserver"
 	"ranking-service/internal/handlers"
+	"ranking-service/internal/impression"
+	"ranking-service/internal/ratelimit"
 	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+	"ranking-service/proto/rankingpb"
 )
 
 var (
@@ -31,15 +44,23 @@ var (
 	}
 )
 
-//	@title			Ranking Service API
-//	@version		1.0
-//	@description	Swagger docs for Ranking Service API
+// runServer serves the REST and gRPC APIs.
 //
-// // @securityDefinitions.apikey	Bearer
-// // @in							header
-// // @name						Authorization
+//	@title						Ranking Service API
+//	@version					1.0
+//	@description				Swagger docs for Ranking Service API
 //
-//	@BasePath		/
+//	@securityDefinitions.apikey	Bearer
+//	@in							header
+//	@name						Authorization
+//	@description				JWT bearer token, as "Bearer <token>", granting the scope of the endpoint: interactions:write, rankings:read, videos:write or admin.
+//
+//	@securityDefinitions.apikey	ApiKey
+//	@in							header
+//	@name						X-API-Key
+//	@description				API key of a client application, created by admins, granting the scope of the endpoint. Requests beyond its quotas get 429.
+//
+//	@BasePath					/
 func runServer() {
 	cfg := config.MustLoadServerConfigFromEnv()
 
@@ -56,27 +77,344 @@ func runServer() {
 	}
 
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
+	// Stream routes accept bearer tokens in the query, which the logs must not leak.
+	router := gin.New()
+	router.Use(auth.Logger(), gin.Recovery())
 
 	// Swagger docs
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	rankingHandler := handlers.NewRankingHandler(postgresDb, redisDb)
-
-	// API Endpoints
-	router.POST("/videos/:video_id/interaction", rankingHandler.UpdateVideoScoreHandler())
-	router.GET("/videos/top", rankingHandler.GetGlobalTopVideosHandler())
-	router.GET("/users/:userID/videos/top", rankingHandler.GetUserTopVideosHandler())
+	scorer, err := scoring.New(cfg.Ranking)
+	if err != nil {
+		slog.Error("Failed to set up ranking strategy:", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Using ranking strategy", "strategy", scorer.Name())
+	if err := scoring.ValidateViewerCaps(cfg.Ranking.ViewerCaps); err != nil {
+		slog.Error("Invalid viewer caps:", "error", err)
+		os.Exit(1)
+	}
 
 	// Notify server start/stop
 	ctx, stop := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+
+	if formulaScorer, ok := scorer.(*scoring.FormulaScorer); ok {
+		reloadScoringFormula(formulaScorer, postgresDb)
+		go func() {
+			ticker := time.NewTicker(cfg.Ranking.FormulaReloadInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					reloadScoringFormula(formulaScorer, postgresDb)
+				}
+			}
+		}()
+	}
+
+	if cfg.Ranking.ScoreSampleInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Ranking.ScoreSampleInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					sampleScores(postgresDb, cfg.Ranking)
+				}
+			}
+		}()
+	}
+
+	if cfg.Ranking.SnapshotInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Ranking.SnapshotInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					snapshotLeaderboard(redisDb, postgresDb, cfg.Ranking)
+				}
+			}
+		}()
+	}
+
+	handlerOptions := []handlers.Option{handlers.WithConfig(cfg.Ranking), handlers.WithScorer(scorer)}
+	if cfg.Anomaly.Enabled {
+		// Anyone could approve the quarantined interactions without authentication.
+		if !cfg.Auth.Enabled {
+			slog.Error("Anomaly detection requires authentication, set AUTH_ENABLED=true")
+			os.Exit(1)
+		}
+		detector, err := anomaly.New(redisDb, cfg.Anomaly)
+		if err != nil {
+			slog.Error("Failed to set up anomaly detection:", "error", err)
+			os.Exit(1)
+		}
+		handlerOptions = append(handlerOptions, handlers.WithAnomalyDetector(detector))
+	}
+	if cfg.InteractionToken.Enabled {
+		// Tokens are issued to the authenticated viewer, so they require authentication.
+		if !cfg.Auth.Enabled {
+			slog.Error("Interaction tokens require authentication, set AUTH_ENABLED=true")
+			os.Exit(1)
+		}
+		tokens, err := impression.New(redisDb, cfg.InteractionToken)
+		if err != nil {
+			slog.Error("Failed to set up interaction tokens:", "error", err)
+			os.Exit(1)
+		}
+		handlerOptions = append(handlerOptions, handlers.WithInteractionTokens(tokens))
+	}
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter, err = ratelimit.New(redisDb, cfg.RateLimit)
+		if err != nil {
+			slog.Error("Failed to set up rate limiting:", "error", err)
+			os.Exit(1)
+		}
+		handlerOptions = append(handlerOptions, handlers.WithRateLimiter(limiter))
+	}
+	rankingHandler := handlers.NewRankingHandler(postgresDb, redisDb, handlerOptions...)
+
+	go func() {
+		if err := rankingHandler.RunLiveUpdates(ctx); err != nil {
+			slog.Error("Failed to subscribe to ranking updates", "error", err)
+		}
+	}()
+
+	go rankingHandler.RunWebhooks(ctx)
+
+	// The time_decay strategy decays the scores of every video periodically.
+	if _, ok := scorer.(*scoring.TimeDecayScorer); ok {
+		if cfg.Ranking.DecayInterval <= 0 {
+			slog.Error("The time_decay strategy requires a positive RANKING_DECAY_INTERVAL")
+			os.Exit(1)
+		}
+		go func() {
+			ticker := time.NewTicker(cfg.Ranking.DecayInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := rankingHandler.DecayScores(time.Now()); err != nil {
+						slog.Error("Failed to decay scores", "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	// Authentication: each route group requires a scope of the API key or bearer token.
+	// API keys are always accepted, bearer tokens when a secret or key set is configured.
+	authorize := func(scope string) gin.HandlerFunc { return func(c *gin.Context) { c.Next() } }
+	authorizeStream := authorize
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if cfg.Auth.Enabled {
+		var verifier *auth.Verifier
+		if cfg.Auth.HS256Secret != "" || cfg.Auth.JWKSFile != "" {
+			verifier, err = auth.NewVerifier(cfg.Auth)
+			if err != nil {
+				slog.Error("Failed to set up authentication:", "error", err)
+				os.Exit(1)
+			}
+		} else {
+			slog.Warn("No HS256 secret or JWKS file, only API keys are accepted")
+		}
+		authenticator := auth.NewAuthenticator(verifier, auth.NewAPIKeys(postgresDb, redisDb, cfg.Auth.APIKeyCacheTTL))
+		authorize = authenticator.Require
+		authorizeStream = authenticator.RequireWithQueryToken
+		unaryInterceptors = append(unaryInterceptors, grpcserver.UnaryAuthInterceptor(authenticator))
+		streamInterceptors = append(streamInterceptors, grpcserver.StreamAuthInterceptor(authenticator))
+	} else {
+		slog.Warn("Authentication is disabled, set AUTH_ENABLED to require API keys or bearer tokens")
+	}
+
+	// Rate limiting of interaction ingestion, after authentication identified the client.
+	// The handler limits the interactions of batches and streams one by one.
+	limit := func(route string) gin.HandlerFunc { return func(c *gin.Context) { c.Next() } }
+	if limiter != nil {
+		limit = limiter.Middleware
+		unaryInterceptors = append(unaryInterceptors, grpcserver.UnaryRateLimitInterceptor(limiter))
+	}
+
+	// API Endpoints
+	interactions := router.Group("/", authorize(auth.ScopeInteractionsWrite))
+	interactions.POST("/videos/:video_id/interaction", limit(ratelimit.RouteInteraction), rankingHandler.UpdateVideoScoreHandler())
+	interactions.POST("/interactions/batch", rankingHandler.RecordInteractionsHandler())
+
+	rankings := router.Group("/", authorize(auth.ScopeRankingsRead))
+	rankings.GET("/videos/top", rankingHandler.GetGlobalTopVideosHandler())
+	rankings.GET("/videos/top/diff", rankingHandler.DiffSnapshotsHandler())
+	rankings.GET("/videos/top/export", rankingHandler.ExportLeaderboardHandler())
+	rankings.GET("/users/:userID/videos/top", rankingHandler.GetUserTopVideosHandler())
+	rankings.GET("/videos/:video_id/stats", rankingHandler.GetVideoStatsHandler())
+	rankings.GET("/videos/:video_id/history", rankingHandler.GetScoreHistoryHandler())
+	rankings.GET("/videos/:video_id/explain", rankingHandler.ExplainVideoScoreHandler())
+	rankings.GET("/videos/:video_id/rank", rankingHandler.GetVideoRankHandler())
+	rankings.GET("/videos/:video_id", rankingHandler.GetVideoHandler())
+
+	// Browsers cannot set headers on streams, so they may pass the token as access_token.
+	streams := router.Group("/", authorizeStream(auth.ScopeRankingsRead))
+	streams.GET("/videos/top/stream", rankingHandler.StreamTopVideosHandler())
+	streams.GET("/videos/top/ws", rankingHandler.StreamTopVideosWebSocketHandler())
+
+	// Video catalog
+	catalog := router.Group("/", authorize(auth.ScopeVideosWrite))
+	catalog.POST("/videos", rankingHandler.CreateVideoHandler())
+	catalog.PATCH("/videos/:video_id", rankingHandler.UpdateVideoHandler())
+	catalog.DELETE("/videos/:video_id", rankingHandler.DeleteVideoHandler())
+
+	// Admin routes
+	if !cfg.Auth.Enabled {
+		slog.Warn("Authentication is disabled, the /admin endpoints are open to every client")
+	}
+	mountAdminRoutes(router.Group("/admin", authorize(auth.ScopeAdmin)), rankingHandler)
+
+	// gRPC API, sharing the handler logic and scopes of the REST API.
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(unaryInterceptors...), grpc.ChainStreamInterceptor(streamInterceptors...))
+	rankingpb.RegisterRankingServiceServer(grpcServer, grpcserver.NewServer(rankingHandler))
+	reflection.Register(grpcServer)
+
 	go func() {
 		slog.Info("Starting ranking service server on ", "address", fmt.Sprintf("%s:%s", cfg.ListenAddr, cfg.Port))
 		if err := router.Run(":" + cfg.Port); err != nil {
 			slog.Error("Failed to run server", "error", err)
 		}
 	}()
+	go func() {
+		slog.Info("Starting ranking service gRPC server on ", "address", fmt.Sprintf("%s:%s", cfg.ListenAddr, cfg.GRPCPort))
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			slog.Error("Failed to listen for gRPC", "error", err)
+			return
+		}
+		if err := grpcServer.Serve(listener); err != nil {
+			slog.Error("Failed to run gRPC server", "error", err)
+		}
+	}()
 	<-ctx.Done()
+	grpcServer.GracefulStop()
 	slog.Info("Shutdown ranking service server")
 }
+
+// mountAdminRoutes serves the admin endpoints on the admin group.
+func mountAdminRoutes(admin *gin.RouterGroup, rankingHandler *handlers.RankingHandler) {
+	admin.PUT("/videos/:video_id/moderation", rankingHandler.SetModerationStatusHandler())
+	admin.GET("/videos/:video_id/moderation", rankingHandler.GetModerationHistoryHandler())
+	admin.POST("/editorial", rankingHandler.CreateEditorialRuleHandler())
+	admin.GET("/editorial", rankingHandler.ListEditorialRulesHandler())
+	admin.DELETE("/editorial/:id", rankingHandler.DeleteEditorialRuleHandler())
+	admin.GET("/scoring/formula", rankingHandler.GetScoringFormulaHandler())
+	admin.PUT("/scoring/formula", rankingHandler.SetScoringFormulaHandler())
+	admin.POST("/webhooks", rankingHandler.CreateWebhookHandler())
+	admin.GET("/webhooks", rankingHandler.ListWebhooksHandler())
+	admin.DELETE("/webhooks/:id", rankingHandler.DeleteWebhookHandler())
+	admin.GET("/webhooks/deliveries", rankingHandler.ListWebhookDeliveriesHandler())
+	admin.POST("/webhooks/deliveries/:id/retry", rankingHandler.RetryWebhookDeliveryHandler())
+	admin.POST("/api-keys", rankingHandler.CreateAPIKeyHandler())
+	admin.GET("/api-keys", rankingHandler.ListAPIKeysHandler())
+	admin.DELETE("/api-keys/:id", rankingHandler.RevokeAPIKeyHandler())
+	admin.POST("/api-keys/:id/rotate", rankingHandler.RotateAPIKeyHandler())
+	admin.GET("/api-keys/:id/usage", rankingHandler.GetAPIKeyUsageHandler())
+	admin.GET("/anomalies", rankingHandler.ListAnomaliesHandler())
+	admin.GET("/anomalies/:id", rankingHandler.GetAnomalyHandler())
+	admin.POST("/anomalies/:id/approve", rankingHandler.ApproveAnomalyHandler())
+	admin.POST("/anomalies/:id/discard", rankingHandler.DiscardAnomalyHandler())
+}
+
+// reloadScoringFormula swaps in the latest formula saved through the admin API, if any.
+func reloadScoringFormula(scorer *scoring.FormulaScorer, postgresDb *repository.PostgresDB) {
+	saved, err := postgresDb.GetActiveScoringFormula()
+	if errors.Is(err, repository.ErrScoringFormulaNotFound) {
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to load scoring formula", "error", err)
+		return
+	}
+	if saved.Expression == scorer.Formula().String() {
+		return
+	}
+
+	compiled, err := scoring.CompileFormula(saved.Expression)
+	if err != nil {
+		slog.Error("Saved scoring formula is invalid", "expression", saved.Expression, "error", err)
+		return
+	}
+	scorer.Swap(compiled)
+	slog.Info("Scoring formula reloaded", "expression", saved.Expression)
+}
+
+// sampleScores records the score of the videos that received interactions during the
+// last interval and drops the samples past retention. Sample times are aligned on the
+// interval so that replicas sampling concurrently record the same samples.
+func sampleScores(postgresDb *repository.PostgresDB, cfg config.RankingConfig) {
+	at := time.Now().Truncate(cfg.ScoreSampleInterval)
+	recorded, err := postgresDb.RecordScoreSamples(at.Add(-cfg.ScoreSampleInterval), at)
+	if err != nil {
+		slog.Error("Failed to record score samples", "error", err)
+		return
+	}
+	slog.Debug("Score samples recorded", "count", recorded)
+
+	if cfg.ScoreSampleRetention > 0 {
+		if _, err := postgresDb.DeleteScoreSamplesBefore(at.Add(-cfg.ScoreSampleRetention)); err != nil {
+			slog.Error("Failed to delete expired score samples", "error", err)
+		}
+	}
+}
+
+// snapshotLeaderboard copies the top of the global ranking to PostgreSQL and drops the
+// snapshots past retention. Like score samples, snapshot times are aligned on the interval.
+func snapshotLeaderboard(redisDb *repository.RedisDB, postgresDb *repository.PostgresDB, cfg config.RankingConfig) {
+	at := time.Now().Truncate(cfg.SnapshotInterval)
+	videos, err := redisDb.GetTopVideos(cfg.SnapshotSize)
+	if err != nil {
+		slog.Error("Failed to fetch ranking for snapshot", "error", err)
+		return
+	}
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.VideoID
+	}
+	catalog, err := postgresDb.GetVideos(ids)
+	if err != nil {
+		slog.Error("Failed to fetch videos for snapshot", "error", err)
+		return
+	}
+	byID := make(map[string]models.Video, len(catalog))
+	for _, v := range catalog {
+		byID[v.VideoID] = v
+	}
+
+	snapshot := models.LeaderboardSnapshot{Ranking: models.SnapshotRankingGlobal, TakenAt: at}
+	for i, v := range videos {
+		snapshot.Entries = append(snapshot.Entries, models.SnapshotEntry{
+			Rank:     i + 1,
+			VideoID:  v.VideoID,
+			UserID:   byID[v.VideoID].UserID,
+			Category: byID[v.VideoID].Category,
+			Score:    v.Score,
+		})
+	}
+	if err := postgresDb.SaveSnapshot(&snapshot); err != nil && !errors.Is(err, repository.ErrSnapshotExists) {
+		slog.Error("Failed to save leaderboard snapshot", "error", err)
+		return
+	}
+
+	if cfg.SnapshotRetention > 0 {
+		if _, err := postgresDb.DeleteSnapshotsBefore(at.Add(-cfg.SnapshotRetention)); err != nil {
+			slog.Error("Failed to delete expired leaderboard snapshots", "error", err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 )
@@ -22,11 +23,156 @@ type PostgresConfig struct {
 	Password string `env:"PASSWORD, default=password"`
 }
 
+type RankingConfig struct {
+	// Strategy selects how interactions are scored: linear, time_decay or formula.
+	Strategy      string        `env:"STRATEGY, default=linear"`
+	DecayHalfLife time.Duration `env:"DECAY_HALF_LIFE, default=168h"`
+	// DecayInterval is how often the time_decay strategy decays every score.
+	DecayInterval time.Duration `env:"DECAY_INTERVAL, default=1m"`
+	// Formula is the initial formula of the formula strategy. A formula saved
+	// through the admin API takes precedence and is reloaded periodically.
+	Formula               string        `env:"FORMULA"`
+	FormulaReloadInterval time.Duration `env:"FORMULA_RELOAD_INTERVAL, default=30s"`
+
+	// RequireRegisteredVideos rejects interactions for videos missing from the catalog.
+	RequireRegisteredVideos bool `env:"REQUIRE_REGISTERED_VIDEOS, default=false"`
+
+	// Diversity re-ranking of the global top, 0 disables a cap.
+	DiversityMaxPerOwner    int `env:"DIVERSITY_MAX_PER_OWNER, default=0"`
+	DiversityMaxPerCategory int `env:"DIVERSITY_MAX_PER_CATEGORY, default=0"`
+	// DiversityMaxCandidates bounds how deep the ranking is scanned to fill a page.
+	DiversityMaxCandidates int `env:"DIVERSITY_MAX_CANDIDATES, default=1000"`
+
+	// Freshness boost of the global top, a weight of 0 disables it.
+	FreshnessWeight   float64       `env:"FRESHNESS_WEIGHT, default=0"`
+	FreshnessHalfLife time.Duration `env:"FRESHNESS_HALF_LIFE, default=24h"`
+	// FreshnessCandidates is how many top videos are re-scored by the boost.
+	FreshnessCandidates int `env:"FRESHNESS_CANDIDATES, default=100"`
+
+	// Cold-start exploration of the global top, a rate of 0 disables it.
+	// Each slot goes with probability ExplorationRate to a recent low-score video.
+	ExplorationRate     float64       `env:"EXPLORATION_RATE, default=0"`
+	ExplorationMaxAge   time.Duration `env:"EXPLORATION_MAX_AGE, default=72h"`
+	ExplorationMaxScore float64       `env:"EXPLORATION_MAX_SCORE, default=10"`
+	ExplorationPoolSize int           `env:"EXPLORATION_POOL_SIZE, default=100"`
+
+	// Score history: the score of every video with new interactions is sampled
+	// every ScoreSampleInterval, and samples are kept for ScoreSampleRetention.
+	// An interval of 0 disables sampling.
+	ScoreSampleInterval  time.Duration `env:"SCORE_SAMPLE_INTERVAL, default=5m"`
+	ScoreSampleRetention time.Duration `env:"SCORE_SAMPLE_RETENTION, default=720h"`
+
+	// Leaderboard snapshots: the top SnapshotSize videos of the global ranking are
+	// copied every SnapshotInterval and kept for SnapshotRetention. Queries with at=
+	// are served from the nearest snapshot, at most one interval away.
+	// An interval of 0 disables snapshots.
+	SnapshotInterval  time.Duration `env:"SNAPSHOT_INTERVAL, default=1h"`
+	SnapshotSize      int           `env:"SNAPSHOT_SIZE, default=1000"`
+	SnapshotRetention time.Duration `env:"SNAPSHOT_RETENTION, default=2160h"`
+	// MovementWindow is how far back the snapshot used for rank movements is taken,
+	// 0 disables movements in top responses.
+	MovementWindow time.Duration `env:"MOVEMENT_WINDOW, default=24h"`
+
+	// Live leaderboard streams: changes are pushed at most once per coalesce
+	// interval, for tops of up to StreamMaxLimit videos.
+	StreamCoalesceInterval time.Duration `env:"STREAM_COALESCE_INTERVAL, default=1s"`
+	StreamMaxLimit         int           `env:"STREAM_MAX_LIMIT, default=100"`
+	StreamKeepAlive        time.Duration `env:"STREAM_KEEP_ALIVE, default=15s"`
+
+	// LeaderboardWindows are the tumbling windows of the windowed leaderboards, which
+	// rank videos by the score they gained during the current window.
+	LeaderboardWindows []time.Duration `env:"LEADERBOARD_WINDOWS, default=24h,168h"`
+
+	// Per-viewer caps: a viewer adds at most ViewerCaps[type] score points to a video
+	// with interactions of a type during each ViewerCapPeriod, e.g. share:10,like:1.
+	// Interactions beyond a cap are counted as capped but not scored. Interactions of
+	// a capped type are rejected without viewer ID. Caps are charged with the weight
+	// of the interactions, excluding the re-scoring of the previous score, and are not
+	// charged for quarantined interactions.
+	ViewerCaps      map[string]float64 `env:"VIEWER_CAPS"`
+	ViewerCapPeriod time.Duration      `env:"VIEWER_CAP_PERIOD, default=24h"`
+
+	// Webhooks: due deliveries are sent and the top is checked for new entries
+	// every WebhookInterval. Failed deliveries are retried with an exponential
+	// backoff from WebhookBackoff up to WebhookMaxBackoff, and moved to the
+	// dead-letter list after WebhookMaxAttempts.
+	WebhookInterval    time.Duration `env:"WEBHOOK_INTERVAL, default=5s"`
+	WebhookTimeout     time.Duration `env:"WEBHOOK_TIMEOUT, default=10s"`
+	WebhookMaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS, default=8"`
+	WebhookBackoff     time.Duration `env:"WEBHOOK_BACKOFF, default=30s"`
+	WebhookMaxBackoff  time.Duration `env:"WEBHOOK_MAX_BACKOFF, default=1h"`
+	WebhookBatchSize   int           `env:"WEBHOOK_BATCH_SIZE, default=50"`
+}
+
+// AuthConfig configures the JWT bearer authentication of the APIs. Tokens are signed
+// with HS256 by the shared secret or with RS256 by a key of the JWKS file, and must
+// not be expired; the issuer and audience are checked when set. API keys managed by
+// admins are accepted as well, in the X-API-Key header. Without authentication, every
+// endpoint, the admin ones included, is open.
+type AuthConfig struct {
+	Enabled     bool          `env:"ENABLED, default=false"`
+	HS256Secret string        `env:"HS256_SECRET"`
+	JWKSFile    string        `env:"JWKS_FILE"`
+	Issuer      string        `env:"ISSUER"`
+	Audience    string        `env:"AUDIENCE"`
+	Leeway      time.Duration `env:"LEEWAY, default=30s"` // Tolerated clock skew.
+	// APIKeyCacheTTL is how long API keys are cached, and so how long a revoked key
+	// may still be accepted.
+	APIKeyCacheTTL time.Duration `env:"API_KEY_CACHE_TTL, default=30s"`
+}
+
+// RateLimitConfig configures the rate limiting of interaction ingestion. Rules are
+// comma-separated route:key=limit/window items, e.g. interaction:ip=120/1m: at most
+// 120 requests of an IP address to the interaction route in any minute. Routes are
+// interaction, batch and grpc_interaction; keys are client (API key or token
+// subject), viewer, ip and video. Batches and gRPC streams are charged per
+// interaction, with the batch and grpc_interaction rules.
+type RateLimitConfig struct {
+	Enabled bool   `env:"ENABLED, default=false"`
+	Rules   string `env:"RULES, default=interaction:client=6000/1m,interaction:ip=300/1m,interaction:viewer=60/1m,interaction:video=3000/1m,batch:client=6000/1m,batch:viewer=60/1m,batch:video=3000/1m,grpc_interaction:client=6000/1m,grpc_interaction:viewer=60/1m,grpc_interaction:video=3000/1m"`
+}
+
+// AnomalyConfig configures the detection of suspicious spikes of interactions.
+// Interactions are counted per video in tumbling windows, and the baseline of a
+// video is the moving average of its previous windows, each weighing BaselineWeight.
+// Once a window has MinInteractions, it is anomalous when it has more than RateFactor
+// times the baseline, or when its interactions with a viewer ID come from fewer than
+// MinDiversity distinct viewers per interaction. The score deltas of anomalous
+// interactions are quarantined until an admin reviews them, so anomaly detection
+// requires authentication.
+type AnomalyConfig struct {
+	Enabled         bool          `env:"ENABLED, default=false"`
+	Window          time.Duration `env:"WINDOW, default=5m"`
+	BaselineWeight  float64       `env:"BASELINE_WEIGHT, default=0.2"`
+	MinInteractions int64         `env:"MIN_INTERACTIONS, default=50"`
+	RateFactor      float64       `env:"RATE_FACTOR, default=5"`
+	MinDiversity    float64       `env:"MIN_DIVERSITY, default=0.2"`
+}
+
+// InteractionTokenConfig configures the signed tokens that bind a viewer to a video
+// served in a top list. When enabled, which requires authentication, top lists carry
+// a token for each of their first videos, issued to the subject of the bearer token
+// or to the viewer ID declared by an API key client. Tokens are signed with HS256 by
+// the secret and valid for TTL, and every interaction must present a valid token of
+// its viewer and video. A token can be used for a single interaction, and is released
+// when the interaction fails.
+type InteractionTokenConfig struct {
+	Enabled bool          `env:"ENABLED, default=false"`
+	Secret  string        `env:"SECRET"`
+	TTL     time.Duration `env:"TTL, default=15m"`
+}
+
 type ServerConfig struct {
-	Port       string         `env:"PORT, default=8080"`
-	ListenAddr string         `env:"LISTEN_ADDR, default=0.0.0.0"`
-	Redis      RedisConfig    `env:", prefix=REDIS_"`
-	Postgres   PostgresConfig `env:", prefix=POSTGRES_"`
+	Port             string                 `env:"PORT, default=8080"`
+	GRPCPort         string                 `env:"GRPC_PORT, default=9090"`
+	ListenAddr       string                 `env:"LISTEN_ADDR, default=0.0.0.0"`
+	Redis            RedisConfig            `env:", prefix=REDIS_"`
+	Postgres         PostgresConfig         `env:", prefix=POSTGRES_"`
+	Ranking          RankingConfig          `env:", prefix=RANKING_"`
+	Auth             AuthConfig             `env:", prefix=AUTH_"`
+	RateLimit        RateLimitConfig        `env:", prefix=RATE_LIMIT_"`
+	Anomaly          AnomalyConfig          `env:", prefix=ANOMALY_"`
+	InteractionToken InteractionTokenConfig `env:", prefix=INTERACTION_TOKEN_"`
 }
 
 func MustLoadServerConfigFromEnv() ServerConfig {
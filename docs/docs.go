@@ -15,30 +15,32 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/users/{userID}/videos/top": {
+        "/admin/anomalies": {
             "get": {
-                "description": "Get the top ranked videos for a specific user.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
                 ],
+                "description": "List the suspicious spikes of interactions detected during ingestion. Pending anomalies form the review queue, oldest first: their interactions are quarantined, not scored, until approved or discarded. Other statuses are listed newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Retrieve personalized top videos for a user",
+                "summary": "List anomalies",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "pending (default), approved, discarded or all",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Number of videos to retrieve",
+                        "description": "Number of anomalies to retrieve (default: 100)",
                         "name": "limit",
                         "in": "query"
                     }
@@ -46,6 +48,15 @@ const docTemplate = `{
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Anomaly"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -54,43 +65,278 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/top": {
+        "/admin/anomalies/{id}": {
             "get": {
-                "description": "Get the top ranked videos globally.",
-                "consumes": [
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get an anomaly with the interactions, counters and score delta it quarantined.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get an anomaly",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Anomaly ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Anomaly"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/anomalies/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Approve a pending anomaly: its quarantined interactions are counted and their score delta is applied to the video. Later anomalous interactions of the video go to a new anomaly.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Videos"
+                    "Admin"
                 ],
-                "summary": "Retrieve global top videos",
+                "summary": "Approve an anomaly",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Number of videos to retrieve",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Anomaly ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Anomaly"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/anomalies/{id}/discard": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Discard a pending anomaly: its quarantined interactions are dropped and the score of the video is left unchanged. Later anomalous interactions of the video go to a new anomaly.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Discard an anomaly",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Anomaly ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Anomaly"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
                     }
                 ],
+                "description": "List the API keys, including the revoked ones, without their secrets.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.APIKey"
                             }
                         }
                     }
                 }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create an API key for a client application, granting space-separated scopes (interactions:write, rankings:read, videos:write or admin), with optional per-minute and daily quotas (0 is unlimited). The key is only returned here: only its hash is stored. Clients send it in the X-API-Key header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "API key payload",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
             }
         },
-        "/videos/{video_id}/interaction": {
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke an API key and its rotated secret. Replicas may accept it for up to AUTH_API_KEY_CACHE_TTL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKey"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{id}/rotate": {
             "post": {
-                "description": "Update a video's score by processing interactions (views, likes, etc.). The payload must include userID.",
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replace the secret of an API key, keeping its ID, scopes, quotas and usage. The previous secret stays valid for the grace period (e.g. 1h, at most 168h, default 0) so that clients can be updated. The new key is only returned here.",
                 "consumes": [
                     "application/json"
                 ],
@@ -98,30 +344,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Videos"
+                    "Admin"
                 ],
-                "summary": "Update video score based on interaction",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Video ID",
-                        "name": "video_id",
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Interaction payload",
-                        "name": "interaction",
+                        "description": "Rotation payload",
+                        "name": "rotation",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.InteractionRequest"
+                            "$ref": "#/definitions/models.RotateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -129,34 +394,2409 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.InteractionRequest": {
-            "type": "object",
-            "required": [
-                "type",
-                "user_id",
-                "video_id",
-                "weight"
-            ],
-            "properties": {
-                "type": {
-                    "description": "e.g., view, like, comment, share, watch_time",
-                    "type": "string"
-                },
-                "user_id": {
-                    "description": "Required: Owner of the video.",
-                    "type": "string"
-                },
-                "video_id": {
-                    "type": "string"
-                },
-                "weight": {
-                    "description": "Used for interactions like watch_time.",
-                    "type": "number"
-                }
-            }
+        },
+        "/admin/api-keys/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Count the requests of an API key during the current minute and each of the last UTC days, against its quotas.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the usage of an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of days to report, today included (default: 7, max: 31)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyUsage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/editorial": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "List pins and boosts. With active=true only the rules currently in effect are returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List editorial rules",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only list rules currently in effect",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EditorialRule"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Pin a video at a position or boost its score in the leaderboards for a period of time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create an editorial rule",
+                "parameters": [
+                    {
+                        "description": "Editorial rule payload",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EditorialRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.EditorialRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/editorial/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove a pin or a boost.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete an editorial rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Editorial rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scoring/formula": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the formula used by the formula ranking strategy and the variables it may use.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the scoring formula",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Validate and save a scoring formula. When the formula strategy is active it is swapped in immediately; other replicas pick it up on their next reload. Videos are re-scored on their next interaction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Replace the scoring formula",
+                "parameters": [
+                    {
+                        "description": "Formula payload",
+                        "name": "formula",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoringFormulaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/videos/{video_id}/moderation": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the current moderation status of a video and the audit trail of decisions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get moderation history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Hide, ban or quarantine a video (or make it visible again). Moderated videos are removed from every leaderboard while their score keeps accumulating.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Moderate a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Moderation payload",
+                        "name": "moderation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the webhook subscriptions, without their secrets.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List webhook subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookSubscription"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Subscribe a URL to ranking events: top_entered when a video enters the top N of the global leaderboard, or score_threshold when a video's score reaches a threshold. Both can be restricted to a creator's videos. Deliveries are signed with HMAC-SHA256 in the X-Webhook-Signature header; the secret is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a webhook subscription",
+                "parameters": [
+                    {
+                        "description": "Webhook subscription payload",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedWebhookSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the latest webhook deliveries, newest first, with the outcome of their last attempt. Filter on status=dead for the dead-letter list.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only list the deliveries of this subscription",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries to retrieve (default: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/deliveries/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Requeue a dead-lettered delivery for immediate delivery, with a fresh set of attempts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Retry a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDelivery"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove a webhook subscription. Its delivery log is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/interactions/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update the scores of videos with up to 1000 interactions at once, in order. Invalid interactions, and interactions beyond the rate limits of the batch route, are rejected and reported without failing the batch.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Record a batch of interactions",
+                "parameters": [
+                    {
+                        "description": "Batch of interactions",
+                        "name": "interactions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchInteractionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchInteractionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userID}/videos/top": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the top ranked videos for a specific user. Active editorial pins and boosts on the user's videos are applied and marked in the response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Retrieve personalized top videos for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of videos to retrieve (1 to 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Serve the user's videos of the nearest global leaderboard snapshot to this RFC 3339 time",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Viewer served the videos on behalf of an API key client, who gets an interaction token for each of the first 100 videos when tokens are enabled. Bearer tokens get them for their subject",
+                        "name": "viewer_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "X-Snapshot-Taken-At": {
+                                "type": "string",
+                                "description": "Time of the snapshot that served an at= query"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Register a video in the catalog with its owner and metadata.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Register a video",
+                "parameters": [
+                    {
+                        "description": "Video payload",
+                        "name": "video",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateVideoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Video"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/top": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the IDs of the top ranked videos globally, or the videos with their scores and annotations with detail=true. Optionally caps the videos per owner or category, boosts fresh videos and reserves exploration slots, then applies the active editorial pins and boosts. Editorial and exploration slots are marked in the response, as well as the movement of each video since the leaderboard snapshot of one movement window (24h by default) earlier.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Retrieve global top videos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of videos to retrieve (1 to 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of videos per owner, 0 disables the cap",
+                        "name": "max_per_owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of videos per category, 0 disables the cap",
+                        "name": "max_per_category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Serve the ranking as of this RFC 3339 time, from the nearest leaderboard snapshot",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Serve the videos with their scores, editorial and exploration marks, movements and interaction tokens instead of their IDs",
+                        "name": "detail",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Viewer served the ranking on behalf of an API key client, who gets an interaction token for each of the first 100 videos with detail=true when tokens are enabled. Bearer tokens get them for their subject",
+                        "name": "viewer_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "X-Snapshot-Taken-At": {
+                                "type": "string",
+                                "description": "Time of the snapshot that served an at= query"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/top/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Compare the top of the global leaderboard between the snapshots nearest to two times. Each video of the newer top gets its previous rank and movement; videos that left the top are listed as dropped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Compare leaderboard snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Older time, RFC 3339 (default: one movement window before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Newer time, RFC 3339 (default: now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of the compared top (default: 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SnapshotDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/top/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Stream every visible video of the global leaderboard, of an owner's leaderboard, or of a windowed leaderboard ranking videos by the score gained during the current window. The format is negotiated with the Accept header: CSV (the default) or NDJSON.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Export a leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export the leaderboard of this owner's videos",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export the windowed leaderboard of this configured window, e.g. 24h",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExportedVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/top/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Stream the global top over Server-Sent Events. The first event is a snapshot of the top, then an update event lists the entries, exits and reorderings along with the full top whenever it changes. Updates are coalesced and pushed at most once per coalesce interval.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Stream global top videos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of videos in the top (default: 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LeaderboardEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/top/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Same events as the Server-Sent Events stream, sent as JSON text messages over a WebSocket.",
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Stream global top videos over WebSocket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of videos in the top (default: 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/models.LeaderboardEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the catalog entry of a video, including soft-deleted ones.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Get a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Video"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Soft-delete a video (or hard-delete it with hard=true) and remove it from every ranking.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Delete a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the record instead of soft-deleting it",
+                        "name": "hard",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update the title, category or owner of a video.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Update a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "video",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateVideoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Video"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}/explain": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Break the score of a video down by interaction type and decay under the active scoring strategy, list the freshness, editorial and moderation adjustments applied at read time, and show the gap to the video at the next-higher rank.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Explain a video's score",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoreExplanation"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the score of a video over a time range, in buckets of the given resolution. Each bucket holds the score at its end and the lowest and highest sampled scores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Retrieve the score history of a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range, RFC 3339 (default: 24 hours before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, RFC 3339 (default: now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket size, e.g. 5m or 1h (default: 1h)",
+                        "name": "resolution",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoreHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}/interaction": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update a video's score by processing interactions (views, likes, etc.). The payload must include userID, and viewer_id for the interaction types capped per viewer. Interactions beyond the score cap of their viewer are counted but not scored, with the status capped and the withheld score in capped. Interactions of a video whose rate or viewer diversity is anomalous are quarantined until an admin reviews them, with the status quarantined and the held score in quarantined. When interaction tokens are enabled, the payload must carry the viewer_id and the token of the video served to the viewer in a top list; a token is valid for one interaction, unless the interaction fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Update video score based on interaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Interaction payload",
+                        "name": "interaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.InteractionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}/rank": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the rank of a video in the organic global ranking, and the score it takes to overtake the video at the next-higher rank. Hidden videos are not ranked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Retrieve a video's rank",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RankInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{video_id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": [],
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the number of interactions per type, the total watch time and the score of a video.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Videos"
+                ],
+                "summary": "Retrieve video statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VideoStatsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "dailyQuota": {
+                    "description": "Requests per UTC day, 0 is unlimited.",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "perMinuteQuota": {
+                    "description": "Requests per minute, 0 is unlimited.",
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "Start of the secret, to recognize the key.",
+                    "type": "string"
+                },
+                "previousExpiresAt": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "rotatedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Space-separated, e.g. \"interactions:write rankings:read\".",
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKeyDailyUsage": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "YYYY-MM-DD.",
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.APIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "daily_quota": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "per_minute_quota": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "description": "Space-separated scopes.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKeyUsage": {
+            "type": "object",
+            "properties": {
+                "daily": {
+                    "description": "Requests per day up to today, newest first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyDailyUsage"
+                    }
+                },
+                "dailyQuota": {
+                    "type": "integer"
+                },
+                "minute": {
+                    "description": "Requests during the current minute.",
+                    "type": "integer"
+                },
+                "perMinuteQuota": {
+                    "type": "integer"
+                },
+                "today": {
+                    "description": "Requests during the current UTC day.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Anomaly": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "number"
+                },
+                "comments": {
+                    "type": "integer"
+                },
+                "delta": {
+                    "type": "number"
+                },
+                "detectedAt": {
+                    "type": "string"
+                },
+                "diversity": {
+                    "description": "Distinct viewers per interaction with a viewer ID.",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interactions": {
+                    "description": "Quarantined interactions, their summed weight and counters. The score they add\nis computed by the ranking strategy on approval.",
+                    "type": "integer"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "Reason and observation of the last anomalous interaction.",
+                    "type": "string"
+                },
+                "reports": {
+                    "type": "integer"
+                },
+                "reviewedAt": {
+                    "type": "string"
+                },
+                "shares": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "description": "Owner of the video.",
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                },
+                "watchTime": {
+                    "type": "number"
+                },
+                "windowInteractions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchInteractionRequest": {
+            "type": "object",
+            "required": [
+                "interactions"
+            ],
+            "properties": {
+                "interactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InteractionRequest"
+                    }
+                }
+            }
+        },
+        "models.BatchInteractionResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RejectedInteraction"
+                    }
+                }
+            }
+        },
+        "models.CreateVideoRequest": {
+            "type": "object",
+            "required": [
+                "user_id",
+                "video_id"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Optional upload time, defaults to now.",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Owner of the video.",
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "dailyQuota": {
+                    "description": "Requests per UTC day, 0 is unlimited.",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "perMinuteQuota": {
+                    "description": "Requests per minute, 0 is unlimited.",
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "Start of the secret, to recognize the key.",
+                    "type": "string"
+                },
+                "previousExpiresAt": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "rotatedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Space-separated, e.g. \"interactions:write rankings:read\".",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreatedWebhookSubscription": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "topN": {
+                    "description": "Filters: TopN for top_entered, Threshold for score_threshold, and\noptionally the owner of the videos for both.",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EditorialRule": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "pin or boost",
+                    "type": "string"
+                },
+                "multiplier": {
+                    "description": "Score multiplier, for boosts.",
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "1-based position, for pins.",
+                    "type": "integer"
+                },
+                "startsAt": {
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EditorialRuleRequest": {
+            "type": "object",
+            "required": [
+                "ends_at",
+                "kind",
+                "video_id"
+            ],
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "pin or boost",
+                    "type": "string"
+                },
+                "multiplier": {
+                    "description": "Required for boosts.",
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Required for pins.",
+                    "type": "integer"
+                },
+                "starts_at": {
+                    "description": "Defaults to now.",
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedVideo": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "userID": {
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InteractionRequest": {
+            "type": "object",
+            "required": [
+                "type",
+                "user_id",
+                "video_id",
+                "weight"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token is the interaction token of the video served to the viewer in a top list,\nrequired when interaction tokens are enabled.",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "e.g., view, like, comment, share, watch_time, report",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Required: Owner of the video.",
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                },
+                "viewer_id": {
+                    "description": "ViewerID identifies the viewer who interacted, for per-viewer rate limits and score caps.",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Used for interactions like watch_time.",
+                    "type": "number"
+                }
+            }
+        },
+        "models.LeaderboardEvent": {
+            "type": "object",
+            "properties": {
+                "entered": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RankedVideo"
+                    }
+                },
+                "exited": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "moved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LeaderboardMove"
+                    }
+                },
+                "top": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RankedVideo"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LeaderboardMove": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerationRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "visible, hidden, banned or quarantined",
+                    "type": "string"
+                }
+            }
+        },
+        "models.RankInfo": {
+            "type": "object",
+            "properties": {
+                "above": {
+                    "description": "Above is the video at the next-higher rank, Gap the score it takes to overtake it.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RankedVideo"
+                        }
+                    ]
+                },
+                "gap": {
+                    "type": "number"
+                },
+                "rank": {
+                    "description": "1-based, 0 when the video is not ranked or hidden.",
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RankedVideo": {
+            "type": "object",
+            "properties": {
+                "editorial": {
+                    "description": "pin or boost when placed by an editor.",
+                    "type": "string"
+                },
+                "exploration": {
+                    "description": "Set on slots given to cold-start exploration.",
+                    "type": "boolean"
+                },
+                "interactionToken": {
+                    "description": "InteractionToken is the token of this impression, set when a viewer ID is given.",
+                    "type": "string"
+                },
+                "movement": {
+                    "type": "string"
+                },
+                "previousRank": {
+                    "description": "Movement of the organic rank since a previous leaderboard snapshot, set when both\nare available.",
+                    "type": "integer"
+                },
+                "rankChange": {
+                    "description": "Positive when the video moved up.",
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RejectedInteraction": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "description": "Position in the batch, from 0.",
+                    "type": "integer"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RotateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "grace_period": {
+                    "description": "How long the previous secret stays valid, e.g. 1h. Defaults to 0.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScoreBucket": {
+            "type": "object",
+            "properties": {
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Score at the end of the bucket.",
+                    "type": "number"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScoreContribution": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ScoreExplanation": {
+            "type": "object",
+            "properties": {
+                "adjustments": {
+                    "description": "Adjustments are applied when leaderboards are read: freshness, editorial boosts and moderation.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScoreContribution"
+                    }
+                },
+                "contributions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScoreContribution"
+                    }
+                },
+                "effectiveScore": {
+                    "type": "number"
+                },
+                "formula": {
+                    "type": "string"
+                },
+                "rank": {
+                    "$ref": "#/definitions/models.RankInfo"
+                },
+                "score": {
+                    "description": "Score is the stored score and Contributions its breakdown under the active strategy.",
+                    "type": "number"
+                },
+                "strategy": {
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScoreHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScoreBucket"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "resolution": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScoringFormulaRequest": {
+            "type": "object",
+            "required": [
+                "expression"
+            ],
+            "properties": {
+                "expression": {
+                    "description": "e.g. likes*1 + shares*2 + log1p(views)*0.5 - reports*5",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SnapshotDiff": {
+            "type": "object",
+            "properties": {
+                "dropped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SnapshotDiffEntry"
+                    }
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SnapshotDiffEntry"
+                    }
+                },
+                "from": {
+                    "description": "Time of the older snapshot.",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Time of the newer snapshot.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SnapshotDiffEntry": {
+            "type": "object",
+            "properties": {
+                "movement": {
+                    "description": "up, down, same, new or dropped.",
+                    "type": "string"
+                },
+                "previousRank": {
+                    "description": "0 when the video is new.",
+                    "type": "integer"
+                },
+                "previousScore": {
+                    "type": "number"
+                },
+                "rank": {
+                    "description": "0 when the video dropped out.",
+                    "type": "integer"
+                },
+                "rankChange": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateVideoRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Video": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Set when the video is soft-deleted.",
+                    "type": "string"
+                },
+                "lastInteractionAt": {
+                    "type": "string"
+                },
+                "moderatedAt": {
+                    "type": "string"
+                },
+                "moderationReason": {
+                    "type": "string"
+                },
+                "moderationStatus": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "description": "Index this field to optimize queries by user_id.",
+                    "type": "string"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VideoStatsResponse": {
+            "type": "object",
+            "properties": {
+                "cappedInteractions": {
+                    "description": "Interactions beyond the per-viewer caps, counted but not scored, and the score they were not given.",
+                    "type": "integer"
+                },
+                "cappedScore": {
+                    "type": "number"
+                },
+                "counts": {
+                    "description": "Interactions per counter: views, likes, comments, shares, reports.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "totalWatchTime": {
+                    "type": "number"
+                },
+                "videoID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "responseStatus": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscriptionID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookSubscription": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "topN": {
+                    "description": "Filters: TopN for top_entered, Threshold for score_threshold, and\noptionally the owner of the videos for both.",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "event",
+                "url"
+            ],
+            "properties": {
+                "event": {
+                    "description": "top_entered or score_threshold",
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Generated when empty.",
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "Required for score_threshold.",
+                    "type": "number"
+                },
+                "top_n": {
+                    "description": "For top_entered, defaults to 10.",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Only notify about this creator's videos.",
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKey": {
+            "description": "API key of a client application, created by admins, granting the scope of the endpoint. Requests beyond its quotas get 429.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "Bearer": {
+            "description": "JWT bearer token, as \"Bearer \u003ctoken\u003e\", granting the scope of the endpoint: interactions:write, rankings:read, videos:write or admin.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -165,7 +2805,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "",
-	BasePath:         "/",
+	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "Ranking Service API",
 	Description:      "Swagger docs for Ranking Service API",
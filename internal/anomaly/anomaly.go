@@ -0,0 +1,72 @@
+// Package anomaly flags videos whose interaction rate or viewer diversity deviates
+// sharply from their baseline, with windows counted in Redis so that every replica
+// sees the same traffic.
+package anomaly
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"ranking-service/config"
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// Detector observes the interactions of videos and flags the anomalous ones.
+type Detector struct {
+	redis repository.RedisRepository
+	cfg   config.AnomalyConfig
+}
+
+// New creates a Detector of the thresholds of cfg.
+func New(redis repository.RedisRepository, cfg config.AnomalyConfig) (*Detector, error) {
+	switch {
+	case cfg.Window < time.Second:
+		return nil, errors.New("anomaly window must be at least 1s")
+	case cfg.BaselineWeight <= 0 || cfg.BaselineWeight > 1:
+		return nil, errors.New("anomaly baseline weight must be in (0, 1]")
+	case cfg.MinInteractions < 1:
+		return nil, errors.New("anomaly minimum interactions must be positive")
+	case cfg.RateFactor <= 1:
+		return nil, errors.New("anomaly rate factor must be greater than 1")
+	case cfg.MinDiversity < 0 || cfg.MinDiversity > 1:
+		return nil, errors.New("anomaly minimum diversity must be in [0, 1]")
+	}
+	return &Detector{redis: redis, cfg: cfg}, nil
+}
+
+// Observe counts an interaction of a video, by a viewer if the ID is not empty, and
+// returns the reason why the video's current window is anomalous, or an empty
+// string. Interactions are never flagged while Redis is unavailable.
+func (d *Detector) Observe(videoID, viewerID string, at time.Time) (string, models.AnomalyObservation) {
+	observation, err := d.redis.ObserveInteraction(videoID, viewerID, d.cfg.Window, d.cfg.BaselineWeight, at)
+	if err != nil {
+		slog.Error("Failed to observe interaction, skipping anomaly detection", "videoID", videoID, "error", err)
+		return "", observation
+	}
+	return d.Detect(observation), observation
+}
+
+// Detect returns the reason why an observation is anomalous, or an empty string.
+// The rate is only compared once the video has a baseline, and the diversity once
+// enough interactions have a viewer ID.
+func (d *Detector) Detect(observation models.AnomalyObservation) string {
+	if observation.HasBaseline && observation.Interactions >= d.cfg.MinInteractions &&
+		float64(observation.Interactions) > d.cfg.RateFactor*observation.Baseline {
+		return models.AnomalyReasonRate
+	}
+	if observation.ViewerInteractions >= d.cfg.MinInteractions && Diversity(observation) < d.cfg.MinDiversity {
+		return models.AnomalyReasonDiversity
+	}
+	return ""
+}
+
+// Diversity is the number of distinct viewers per interaction with a viewer ID, 1
+// when there are none.
+func Diversity(observation models.AnomalyObservation) float64 {
+	if observation.ViewerInteractions == 0 {
+		return 1
+	}
+	return float64(observation.DistinctViewers) / float64(observation.ViewerInteractions)
+}
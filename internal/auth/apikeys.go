@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// apiKeyPrefix starts every API key secret, so that leaked keys are easy to spot.
+const apiKeyPrefix = "rk_"
+
+// Errors of the API key authentication.
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrQuotaExceeded = errors.New("API key quota exceeded")
+)
+
+// IsScope reports whether scope is one of the scopes of the APIs.
+func IsScope(scope string) bool {
+	switch scope {
+	case ScopeInteractionsWrite, ScopeRankingsRead, ScopeVideosWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// GenerateAPIKey returns a new random API key secret and its hash.
+func GenerateAPIKey() (key, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of an API key secret stored in place of the secret. The
+// secrets are random, so an unsalted SHA-256 is enough and allows lookups by hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix returns the start of an API key secret, displayed to recognize the key.
+func APIKeyPrefix(key string) string {
+	return key[:min(len(key), len(apiKeyPrefix)+6)]
+}
+
+// APIKeyScopes returns the scopes granted by an API key.
+func APIKeyScopes(key *models.APIKey) []string {
+	return strings.Fields(key.Scopes)
+}
+
+// cachedAPIKey is an API key looked up by hash, cached until expiresAt.
+type cachedAPIKey struct {
+	key       *models.APIKey
+	expiresAt time.Time
+}
+
+// APIKeys authenticates API keys stored in PostgreSQL and enforces their quotas in
+// Redis. Keys are cached for a short time to spare a query per request.
+type APIKeys struct {
+	postgres repository.PostgresRepository
+	redis    repository.RedisRepository
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+}
+
+// NewAPIKeys creates an APIKeys caching keys for ttl.
+func NewAPIKeys(postgres repository.PostgresRepository, redis repository.RedisRepository, ttl time.Duration) *APIKeys {
+	return &APIKeys{postgres: postgres, redis: redis, ttl: ttl, cache: make(map[string]cachedAPIKey)}
+}
+
+// lookup returns the API key with the secret, from the cache or PostgreSQL.
+func (k *APIKeys) lookup(hash string, now time.Time) (*models.APIKey, error) {
+	k.mu.Lock()
+	cached, ok := k.cache[hash]
+	k.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.key, nil
+	}
+
+	key, err := k.postgres.GetAPIKeyByHash(hash)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API key: %w", err)
+	}
+	if k.ttl > 0 {
+		k.mu.Lock()
+		k.cache[hash] = cachedAPIKey{key: key, expiresAt: now.Add(k.ttl)}
+		k.mu.Unlock()
+	}
+	return key, nil
+}
+
+// Authenticate returns the API key with the secret, unless it is revoked or the
+// secret was replaced by a rotation whose grace period is over.
+func (k *APIKeys) Authenticate(secret string, now time.Time) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	hash := HashAPIKey(secret)
+	key, err := k.lookup(hash, now)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil && !now.Before(*key.RevokedAt) {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.Hash != hash && (key.PreviousExpiresAt == nil || !now.Before(*key.PreviousExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// Authorize authenticates an API key, checks that it grants scope and counts the
+// request against its quotas. It returns the usage of the key after the request, or
+// ErrQuotaExceeded with the usage that exhausted the quota. Quotas are not enforced
+// while Redis is unavailable.
+func (k *APIKeys) Authorize(secret, scope string, now time.Time) (*models.APIKey, models.APIKeyUsage, error) {
+	key, err := k.Authenticate(secret, now)
+	if err != nil {
+		return nil, models.APIKeyUsage{}, err
+	}
+	scopes := APIKeyScopes(key)
+	if !slices.Contains(scopes, scope) && !slices.Contains(scopes, ScopeAdmin) {
+		return key, models.APIKeyUsage{}, ErrInsufficientScope
+	}
+
+	usage, allowed, err := k.redis.ConsumeAPIKeyQuota(key.ID, key.PerMinuteQuota, key.DailyQuota, now)
+	if err != nil {
+		slog.Error("Failed to check API key quota", "keyID", key.ID, "error", err)
+		return key, models.APIKeyUsage{PerMinuteQuota: key.PerMinuteQuota, DailyQuota: key.DailyQuota}, nil
+	}
+	if !allowed {
+		return key, usage, ErrQuotaExceeded
+	}
+	return key, usage, nil
+}
+
+// QuotaRetryAfter returns how long a client whose usage exceeds a quota has to wait
+// for the quota to reset: the next minute, or the next UTC day.
+func QuotaRetryAfter(usage models.APIKeyUsage, now time.Time) time.Duration {
+	if usage.DailyQuota > 0 && usage.Today >= int64(usage.DailyQuota) {
+		day := now.UTC().Truncate(24 * time.Hour)
+		return day.Add(24 * time.Hour).Sub(now)
+	}
+	return now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+}
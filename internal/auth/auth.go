@@ -0,0 +1,191 @@
+// Package auth authenticates API requests with JWT bearer tokens or API keys carrying
+// scopes.
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"ranking-service/config"
+)
+
+// Scopes granted by tokens. Admin grants every scope.
+const (
+	ScopeInteractionsWrite = "interactions:write"
+	ScopeRankingsRead      = "rankings:read"
+	ScopeVideosWrite       = "videos:write"
+	ScopeAdmin             = "admin"
+)
+
+// Errors of Verify.
+var (
+	ErrMissingToken      = errors.New("missing bearer token")
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// Claims are the claims of an access token. Scopes are read from the space-separated
+// scope claim, or from the scp array.
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"`
+	Scp   []string `json:"scp,omitempty"`
+}
+
+// Scopes returns the scopes granted by the token.
+func (c *Claims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// HasScope reports whether the token grants scope, directly or through admin.
+func (c *Claims) HasScope(scope string) bool {
+	scopes := c.Scopes()
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// Verifier verifies access tokens signed with HS256 by a shared secret, or with
+// RS256 by a key of a local JSON Web Key Set.
+type Verifier struct {
+	secret  []byte
+	keys    map[string]*rsa.PublicKey // RSA keys of the key set by key ID.
+	methods []string
+	parser  *jwt.Parser
+}
+
+// NewVerifier creates a Verifier of the tokens accepted by cfg. At least one of the
+// HS256 secret and the JWKS file is required.
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	v := &Verifier{}
+	if cfg.HS256Secret != "" {
+		v.secret = []byte(cfg.HS256Secret)
+		v.methods = append(v.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		v.methods = append(v.methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(v.methods) == 0 {
+		return nil, errors.New("authentication requires an HS256 secret or a JWKS file")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify checks the signature and validity of a token and returns its claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	var claims Claims
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+// Authorize verifies a token and checks that it grants scope.
+func (v *Verifier) Authorize(token, scope string) (*Claims, error) {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.HasScope(scope) {
+		return claims, ErrInsufficientScope
+	}
+	return claims, nil
+}
+
+// key returns the key verifying a token. The parser only accepts the configured
+// methods, so HMAC tokens never get an RSA key and conversely.
+func (v *Verifier) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return v.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+		// Tokens may omit the key ID when the key set holds a single key.
+		if kid == "" && len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// jwk is a JSON Web Key. Only RSA signing keys are used.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// loadJWKS reads the RSA signing keys of a JSON Web Key Set file by key ID.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != jwt.SigningMethodRS256.Alg()) {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %q: %w", k.Kid, err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent of key %q", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS file holds no RSA signing key")
+	}
+	return keys, nil
+}
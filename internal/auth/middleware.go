@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/models"
+)
+
+// Gin context keys of the credentials of an authenticated request.
+const (
+	claimsKey = "auth.claims"
+	apiKeyKey = "auth.api_key"
+)
+
+// Principal is the authenticated caller of a request: the claims of a bearer token,
+// or an API key with its quota usage after the request.
+type Principal struct {
+	Claims *Claims
+	APIKey *models.APIKey
+	Usage  models.APIKeyUsage
+}
+
+// ClientID identifies the client application of the principal: its API key, or the
+// subject of its token. It is empty for tokens without subject.
+func (p Principal) ClientID() string {
+	switch {
+	case p.APIKey != nil:
+		return "key:" + strconv.FormatUint(uint64(p.APIKey.ID), 10)
+	case p.Claims != nil && p.Claims.Subject != "":
+		return "sub:" + p.Claims.Subject
+	}
+	return ""
+}
+
+type principalContextKey struct{}
+
+// NewContext returns a context carrying the principal of a call.
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of an authenticated call, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// Authenticator authenticates requests with an API key, in the X-API-Key header, or
+// with a bearer token. Either may be disabled by passing nil.
+type Authenticator struct {
+	verifier *Verifier
+	keys     *APIKeys
+	now      func() time.Time
+}
+
+// NewAuthenticator creates an Authenticator of bearer tokens checked by verifier and
+// of API keys.
+func NewAuthenticator(verifier *Verifier, keys *APIKeys) *Authenticator {
+	return &Authenticator{verifier: verifier, keys: keys, now: time.Now}
+}
+
+// Authorize authenticates the API key, if any, or the bearer token and checks that it
+// grants scope. It returns ErrMissingToken without credentials.
+func (a *Authenticator) Authorize(apiKey, token, scope string) (Principal, error) {
+	switch {
+	case apiKey != "":
+		if a.keys == nil {
+			return Principal{}, ErrInvalidAPIKey
+		}
+		key, usage, err := a.keys.Authorize(apiKey, scope, a.now())
+		return Principal{APIKey: key, Usage: usage}, err
+	case token != "" && a.verifier == nil:
+		return Principal{}, ErrInvalidToken
+	case token != "":
+		claims, err := a.verifier.Authorize(token, scope)
+		return Principal{Claims: claims}, err
+	}
+	return Principal{}, ErrMissingToken
+}
+
+// Require returns a middleware that rejects the requests without a valid bearer token
+// granting scope: 401 for a missing or invalid token, 403 for a missing scope.
+func (v *Verifier) Require(scope string) gin.HandlerFunc {
+	return NewAuthenticator(v, nil).Require(scope)
+}
+
+// RequireWithQueryToken is like Require, but also accepts the token in the
+// access_token query parameter, for clients that cannot set headers such as browser
+// EventSource and WebSocket connections.
+func (v *Verifier) RequireWithQueryToken(scope string) gin.HandlerFunc {
+	return NewAuthenticator(v, nil).RequireWithQueryToken(scope)
+}
+
+// Require returns a middleware that rejects the requests without a valid API key or
+// bearer token granting scope: 401 for missing or invalid credentials, 403 for a
+// missing scope and 429 for an API key over its quota.
+func (a *Authenticator) Require(scope string) gin.HandlerFunc {
+	return a.require(scope, false)
+}
+
+// RequireWithQueryToken is like Require, but also accepts the bearer token in the
+// access_token query parameter, for clients that cannot set headers such as browser
+// EventSource and WebSocket connections.
+func (a *Authenticator) RequireWithQueryToken(scope string) gin.HandlerFunc {
+	return a.require(scope, true)
+}
+
+func (a *Authenticator) require(scope string, query bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := BearerToken(c.GetHeader("Authorization"))
+		if token == "" && query {
+			token = c.Query("access_token")
+		}
+
+		principal, err := a.Authorize(c.GetHeader("X-API-Key"), token, scope)
+		if principal.APIKey != nil {
+			setQuotaHeaders(c, principal.Usage)
+		}
+		switch {
+		case errors.Is(err, ErrMissingToken):
+			c.Header("WWW-Authenticate", `Bearer scope="`+scope+`"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		case errors.Is(err, ErrInvalidAPIKey):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		case errors.Is(err, ErrInsufficientScope) && principal.APIKey != nil:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+			return
+		case errors.Is(err, ErrInsufficientScope):
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token lacks the " + scope + " scope"})
+			return
+		case errors.Is(err, ErrQuotaExceeded):
+			retryAfter := QuotaRetryAfter(principal.Usage, a.now())
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "API key quota exceeded"})
+			return
+		case errors.Is(err, ErrInvalidToken):
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid bearer token"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate request"})
+			return
+		}
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), principal))
+		if principal.Claims != nil {
+			c.Set(claimsKey, principal.Claims)
+		}
+		if principal.APIKey != nil {
+			c.Set(apiKeyKey, principal.APIKey)
+		}
+		c.Next()
+	}
+}
+
+// setQuotaHeaders reports the remaining requests of the quotas of an API key.
+func setQuotaHeaders(c *gin.Context, usage models.APIKeyUsage) {
+	if usage.PerMinuteQuota > 0 {
+		c.Header("X-Quota-Minute-Limit", strconv.Itoa(usage.PerMinuteQuota))
+		c.Header("X-Quota-Minute-Remaining", strconv.FormatInt(max(int64(usage.PerMinuteQuota)-usage.Minute, 0), 10))
+	}
+	if usage.DailyQuota > 0 {
+		c.Header("X-Quota-Day-Limit", strconv.Itoa(usage.DailyQuota))
+		c.Header("X-Quota-Day-Remaining", strconv.FormatInt(max(int64(usage.DailyQuota)-usage.Today, 0), 10))
+	}
+}
+
+// BearerToken extracts the token of an Authorization header, or returns an empty string.
+func BearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// ClaimsFromContext returns the claims of a request authenticated by a bearer token, if any.
+func ClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	claims, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	return claims.(*Claims), true
+}
+
+// APIKeyFromContext returns the API key of a request authenticated by an API key, if any.
+func APIKeyFromContext(c *gin.Context) (*models.APIKey, bool) {
+	key, ok := c.Get(apiKeyKey)
+	if !ok {
+		return nil, false
+	}
+	return key.(*models.APIKey), true
+}
+
+// accessTokenParam matches the value of the access_token query parameter.
+var accessTokenParam = regexp.MustCompile(`((?:^|[?&;])access_token=)[^&;#]*`)
+
+// RedactAccessToken replaces the access_token query parameter of a request path.
+func RedactAccessToken(path string) string {
+	return accessTokenParam.ReplaceAllString(path, "${1}REDACTED")
+}
+
+// Logger returns a middleware logging the requests like gin.Logger, with the bearer
+// tokens passed in the access_token query parameter redacted.
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			RedactAccessToken(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,160 @@
+package formula
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// node is an expression tree node.
+type node interface {
+	eval(vars map[string]float64) float64
+	// String renders the node back to an expression.
+	String() string
+}
+
+// precedence returns the binding strength of an operator; operands bind tightest.
+func precedence(n node) int {
+	if b, ok := n.(binaryNode); ok {
+		switch b.op {
+		case "+", "-":
+			return 1
+		case "*", "/":
+			return 2
+		case "^":
+			return 3
+		}
+	}
+	if _, ok := n.(unaryNode); ok {
+		return 3
+	}
+	return 4
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n numberNode) eval(map[string]float64) float64 {
+	return n.value
+}
+
+func (n numberNode) String() string {
+	return strconv.FormatFloat(n.value, 'g', -1, 64)
+}
+
+type varNode struct {
+	name string
+}
+
+// eval returns the variable's value; missing counters count as 0.
+func (n varNode) eval(vars map[string]float64) float64 {
+	return vars[n.name]
+}
+
+func (n varNode) String() string {
+	return n.name
+}
+
+type unaryNode struct {
+	operand node
+}
+
+func (n unaryNode) eval(vars map[string]float64) float64 {
+	return -n.operand.eval(vars)
+}
+
+func (n unaryNode) String() string {
+	if precedence(n.operand) < 3 {
+		return "-(" + n.operand.String() + ")"
+	}
+	return "-" + n.operand.String()
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(vars map[string]float64) float64 {
+	left, right := n.left.eval(vars), n.right.eval(vars)
+	switch n.op {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	case "/":
+		return left / right
+	case "^":
+		return math.Pow(left, right)
+	}
+	return math.NaN()
+}
+
+func (n binaryNode) String() string {
+	p := precedence(n)
+	left, right := n.left.String(), n.right.String()
+	// ^ is right-associative, the other operators are left-associative.
+	if lp := precedence(n.left); lp < p || (lp == p && n.op == "^") {
+		left = "(" + left + ")"
+	}
+	if rp := precedence(n.right); rp < p || (rp == p && n.op != "^" && n.op != "+" && n.op != "*") {
+		right = "(" + right + ")"
+	}
+	if n.op == "^" {
+		return left + "^" + right
+	}
+	return left + " " + n.op + " " + right
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n callNode) eval(vars map[string]float64) float64 {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.eval(vars)
+	}
+	return n.fn.call(args)
+}
+
+func (n callNode) String() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.String()
+	}
+	return n.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// function is a built-in function callable from formulas.
+type function struct {
+	minArgs, maxArgs int // maxArgs < 0 means variadic.
+	call             func(args []float64) float64
+}
+
+var functions = map[string]function{
+	"log":   {1, 1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log1p": {1, 1, func(a []float64) float64 { return math.Log1p(a[0]) }},
+	"sqrt":  {1, 1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"exp":   {1, 1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"abs":   {1, 1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"min": {2, -1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {2, -1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
@@ -0,0 +1,254 @@
+// Package formula implements a small, safe expression language for scoring
+// videos from their counters, e.g. `likes*1 + shares*2 + log1p(views)*0.5 - reports*5`.
+//
+// Expressions support numbers, variables, + - * / ^, parentheses and the
+// functions log, log1p, sqrt, exp, abs, min and max. They have no side effects
+// and no loops, and are fully validated when compiled.
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// MaxLength bounds the size of an expression.
+	MaxLength = 1024
+	// maxDepth bounds the nesting of an expression.
+	maxDepth = 64
+)
+
+// ErrNotFinite is returned when an evaluation yields NaN or an infinity,
+// e.g. on a division by zero.
+var ErrNotFinite = errors.New("formula result is not a finite number")
+
+// Formula is a compiled expression.
+type Formula struct {
+	source string
+	root   node
+}
+
+// Compile parses and validates an expression. Only the given variables may be used.
+func Compile(source string, variables []string) (*Formula, error) {
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("formula is longer than %d characters", MaxLength)
+	}
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		allowed[v] = true
+	}
+	p := &parser{tokens: tokens, variables: allowed}
+	root, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Formula{source: source, root: root}, nil
+}
+
+// String returns the source of the formula.
+func (f *Formula) String() string {
+	return f.source
+}
+
+// Eval evaluates the formula. Variables missing from vars count as 0.
+func (f *Formula) Eval(vars map[string]float64) (float64, error) {
+	v := f.root.eval(vars)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, ErrNotFinite
+	}
+	return v, nil
+}
+
+// Term is a top-level additive term of a formula.
+type Term struct {
+	Expression string
+	Value      float64
+}
+
+// Terms evaluates the top-level terms of the formula separately, e.g.
+// `likes*2 - reports` has the terms `likes * 2` and `-reports`. The values of
+// the terms add up to the value of the formula; terms without a finite value count as 0.
+func (f *Formula) Terms(vars map[string]float64) []Term {
+	var terms []Term
+	var collect func(n node, negative bool)
+	collect = func(n node, negative bool) {
+		if b, ok := n.(binaryNode); ok && (b.op == "+" || b.op == "-") {
+			collect(b.left, negative)
+			collect(b.right, negative != (b.op == "-"))
+			return
+		}
+		term := Term{Expression: n.String(), Value: n.eval(vars)}
+		if negative {
+			term.Expression = unaryNode{operand: n}.String()
+			term.Value = 0 - term.Value
+		}
+		if math.IsNaN(term.Value) || math.IsInf(term.Value, 0) {
+			term.Value = 0
+		}
+		terms = append(terms, term)
+	}
+	collect(f.root, false)
+	return terms
+}
+
+type parser struct {
+	tokens    []token
+	pos       int
+	variables map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseExpression parses a sum: term (("+" | "-") term)*.
+func (p *parser) parseExpression(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("formula is nested more than %d levels", maxDepth)
+	}
+	left, err := p.parseTerm(depth)
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.next()
+		right, err := p.parseTerm(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses a product: unary (("*" | "/") unary)*.
+func (p *parser) parseTerm(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "*" || tok.text == "/"); tok = p.peek() {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation or a power: "-" unary | power.
+func (p *parser) parseUnary(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("formula is nested more than %d levels", maxDepth)
+	}
+	if tok := p.peek(); tok.kind == tokenOperator && (tok.text == "-" || tok.text == "+") {
+		p.next()
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "+" {
+			return operand, nil
+		}
+		return unaryNode{operand: operand}, nil
+	}
+	return p.parsePower(depth)
+}
+
+// parsePower parses a right-associative power: primary ("^" unary)?.
+func (p *parser) parsePower(depth int) (node, error) {
+	base, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == "^" {
+		p.next()
+		exponent, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: "^", left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+// parsePrimary parses a number, a variable, a function call or a parenthesized expression.
+func (p *parser) parsePrimary(depth int) (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return numberNode{value: tok.value}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(tok, depth)
+		}
+		if !p.variables[tok.text] {
+			return nil, fmt.Errorf("unknown variable %q at position %d", tok.text, tok.pos)
+		}
+		return varNode{name: tok.text}, nil
+	case tokenLParen:
+		inner, err := p.parseExpression(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d", closing.pos)
+		}
+		return inner, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of formula")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// parseCall parses the arguments of a call to a built-in function.
+func (p *parser) parseCall(name token, depth int) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	p.next() // "("
+
+	var args []node
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseExpression(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokenRParen {
+		return nil, fmt.Errorf("expected \")\" at position %d", closing.pos)
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments to %s at position %d", name.text, name.pos)
+	}
+	return callNode{name: name.text, fn: fn, args: args}, nil
+}
@@ -0,0 +1,84 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator // + - * / ^
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64 // Set for numbers.
+	pos   int
+}
+
+// tokenize splits an expression into tokens.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// Exponent, e.g. 1e-3.
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case r == '+' || r == '-' || r == '*' || r == '/' || r == '^':
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), pos: i})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/internal/auth"
+	"ranking-service/proto/rankingpb"
+)
+
+// methodScopes are the scopes required by the methods, as for the REST API.
+var methodScopes = map[string]string{
+	rankingpb.RankingService_RecordInteraction_FullMethodName:  auth.ScopeInteractionsWrite,
+	rankingpb.RankingService_StreamInteractions_FullMethodName: auth.ScopeInteractionsWrite,
+	rankingpb.RankingService_GetTopVideos_FullMethodName:       auth.ScopeRankingsRead,
+	rankingpb.RankingService_GetUserTopVideos_FullMethodName:   auth.ScopeRankingsRead,
+	rankingpb.RankingService_GetVideoRank_FullMethodName:       auth.ScopeRankingsRead,
+}
+
+// UnaryAuthInterceptor rejects the calls without an API key, in the x-api-key metadata,
+// or a bearer token, in the authorization metadata, granting the scope of the method.
+// Methods of other services, such as reflection, are not checked.
+func UnaryAuthInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorize(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the UnaryAuthInterceptor of streaming methods.
+func StreamAuthInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream is a server stream with the context of the authenticated call.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize authenticates a call, and returns its context carrying the principal.
+func authorize(ctx context.Context, authenticator *auth.Authenticator, method string) (context.Context, error) {
+	scope, ok := methodScopes[method]
+	if !ok {
+		return ctx, nil
+	}
+	var apiKey, token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		}
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = auth.BearerToken(values[0])
+		}
+	}
+
+	principal, err := authenticator.Authorize(apiKey, token, scope)
+	switch {
+	case errors.Is(err, auth.ErrInsufficientScope):
+		return nil, status.Errorf(codes.PermissionDenied, "credentials lack the %s scope", scope)
+	case errors.Is(err, auth.ErrQuotaExceeded):
+		return nil, status.Error(codes.ResourceExhausted, "API key quota exceeded")
+	case errors.Is(err, auth.ErrMissingToken):
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	case errors.Is(err, auth.ErrInvalidAPIKey):
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	case errors.Is(err, auth.ErrInvalidToken):
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	case err != nil:
+		return nil, status.Error(codes.Internal, "failed to authenticate call")
+	}
+	return auth.NewContext(ctx, principal), nil
+}
@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/internal/auth"
+	"ranking-service/internal/ratelimit"
+	"ranking-service/proto/rankingpb"
+)
+
+// UnaryRateLimitInterceptor limits the RecordInteraction calls with the rules of the
+// grpc_interaction route, rejecting the calls beyond a limit with ResourceExhausted
+// and a retry-after header. It runs after the UnaryAuthInterceptor, which identifies
+// the client. The interactions of StreamInteractions are limited one by one by the
+// server, with the rate limiter of the handler.
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		interaction, ok := req.(*rankingpb.Interaction)
+		if !ok || info.FullMethod != rankingpb.RankingService_RecordInteraction_FullMethodName {
+			return handler(ctx, req)
+		}
+
+		decision, key := limiter.Check(ratelimit.RouteGRPCInteraction, rateLimitRequest(ctx, interaction))
+		if !decision.Allowed {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ratelimit.RetryAfterSeconds(decision))))
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit of the %s exceeded", key)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitRequest identifies the sender of an interaction received in the context of
+// a call.
+func rateLimitRequest(ctx context.Context, interaction *rankingpb.Interaction) ratelimit.Request {
+	request := ratelimit.Request{Viewer: interaction.GetViewerId(), Video: interaction.GetVideoId()}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		request.Client = principal.ClientID()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		request.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(request.IP); err == nil {
+			request.IP = host
+		}
+	}
+	return request
+}
@@ -0,0 +1,262 @@
+// Package grpcserver exposes the ranking service over gRPC. It shares the handler
+// logic and repositories of the REST API through the RankingHandler.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/impression"
+	"ranking-service/internal/ratelimit"
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+	"ranking-service/proto/rankingpb"
+)
+
+// defaultLimit is the number of videos served when a request does not set one.
+const defaultLimit = 10
+
+// Server implements rankingpb.RankingServiceServer.
+type Server struct {
+	rankingpb.UnimplementedRankingServiceServer
+
+	handler *handlers.RankingHandler
+}
+
+// NewServer creates a Server backed by the given handler.
+func NewServer(handler *handlers.RankingHandler) *Server {
+	return &Server{handler: handler}
+}
+
+// RecordInteraction updates a video's score based on an interaction.
+func (s *Server) RecordInteraction(ctx context.Context, req *rankingpb.Interaction) (*rankingpb.InteractionResult, error) {
+	outcome, err := s.recordInteraction(req)
+	if err != nil {
+		return nil, interactionStatus("RecordInteraction", err)
+	}
+	result := &rankingpb.InteractionResult{VideoId: req.GetVideoId(), Delta: outcome.Delta}
+	switch outcome.Status {
+	case handlers.InteractionCapped:
+		result.Capped = outcome.Withheld
+	case handlers.InteractionQuarantined:
+		result.Quarantined = outcome.Withheld
+	}
+	return result, nil
+}
+
+// StreamInteractions ingests a stream of interactions. Rejected interactions are
+// reported in the summary; any other failure ends the stream.
+func (s *Server) StreamInteractions(stream rankingpb.RankingService_StreamInteractionsServer) error {
+	summary := &rankingpb.StreamInteractionsSummary{}
+	for index := int64(0); ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Each interaction of the stream is charged against the rate limits.
+		if decision, key := s.handler.LimitInteraction(ratelimit.RouteGRPCInteraction, rateLimitRequest(stream.Context(), req)); !decision.Allowed {
+			summary.Rejected = append(summary.Rejected, &rankingpb.RejectedInteraction{
+				Index:   index,
+				VideoId: req.GetVideoId(),
+				Error:   "rate limit of the " + key + " exceeded",
+			})
+			continue
+		}
+		if _, err := s.recordInteraction(req); err != nil {
+			st := interactionStatus("StreamInteractions", err)
+			if status.Code(st) == codes.Internal {
+				return st
+			}
+			summary.Rejected = append(summary.Rejected, &rankingpb.RejectedInteraction{
+				Index:   index,
+				VideoId: req.GetVideoId(),
+				Error:   status.Convert(st).Message(),
+			})
+			continue
+		}
+		summary.Accepted++
+	}
+}
+
+// recordInteraction records an interaction.
+func (s *Server) recordInteraction(req *rankingpb.Interaction) (handlers.InteractionOutcome, error) {
+	return s.handler.RecordInteraction(models.InteractionRequest{
+		VideoID:  req.GetVideoId(),
+		Type:     req.GetType(),
+		Weight:   req.GetWeight(),
+		UserID:   req.GetUserId(),
+		ViewerID: req.GetViewerId(),
+		Token:    req.GetToken(),
+	})
+}
+
+// interactionStatus converts an error of RecordInteraction to a gRPC status.
+func interactionStatus(method string, err error) error {
+	switch {
+	case errors.Is(err, handlers.ErrMissingVideoID), errors.Is(err, handlers.ErrMissingUserID), errors.Is(err, handlers.ErrMissingViewerID):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, scoring.ErrUnknownInteraction):
+		return status.Error(codes.InvalidArgument, "unknown interaction type")
+	case errors.Is(err, handlers.ErrVideoNotRegistered):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, handlers.ErrVideoDeleted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, impression.ErrMissingToken), errors.Is(err, impression.ErrInvalidToken):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, impression.ErrTokenUsed):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	slog.Error(method+": Failed to record interaction", "error", err)
+	return status.Error(codes.Internal, "failed to update video score")
+}
+
+// GetTopVideos retrieves the top-ranked videos globally.
+func (s *Server) GetTopVideos(ctx context.Context, req *rankingpb.GetTopVideosRequest) (*rankingpb.GetTopVideosResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, handlers.MaxTopLimit)
+	maxPerOwner, maxPerCategory := s.handler.DiversityCaps()
+	if req.MaxPerOwner != nil {
+		maxPerOwner = max(int(req.GetMaxPerOwner()), 0)
+	}
+	if req.MaxPerCategory != nil {
+		maxPerCategory = max(int(req.GetMaxPerCategory()), 0)
+	}
+
+	videos, takenAt, err := s.handler.TopVideos(limit, maxPerOwner, maxPerCategory, fromTimestamp(req.GetAt()))
+	if errors.Is(err, repository.ErrSnapshotNotFound) {
+		return nil, status.Error(codes.NotFound, "no leaderboard snapshot near the requested time")
+	}
+	if err != nil {
+		slog.Error("GetTopVideos: Failed to fetch top videos", "error", err)
+		return nil, status.Error(codes.Internal, "error fetching top videos")
+	}
+
+	resp := &rankingpb.GetTopVideosResponse{
+		Videos:          make([]*rankingpb.RankedVideo, len(videos)),
+		SnapshotTakenAt: toTimestamp(takenAt),
+	}
+	viewerID, now := handlers.TokenViewer(ctx, req.GetViewerId()), time.Now()
+	for i, v := range videos {
+		if i < handlers.MaxInteractionTokens {
+			v.InteractionToken = s.handler.InteractionToken(viewerID, v.VideoID, now)
+		}
+		resp.Videos[i] = toRankedVideo(v)
+	}
+	return resp, nil
+}
+
+// GetUserTopVideos retrieves the top videos of a user.
+func (s *Server) GetUserTopVideos(ctx context.Context, req *rankingpb.GetUserTopVideosRequest) (*rankingpb.GetUserTopVideosResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing user_id")
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, handlers.MaxTopLimit)
+
+	videos, takenAt, err := s.handler.UserTopVideos(req.GetUserId(), limit, fromTimestamp(req.GetAt()))
+	if errors.Is(err, repository.ErrSnapshotNotFound) {
+		return nil, status.Error(codes.NotFound, "no leaderboard snapshot near the requested time")
+	}
+	if err != nil {
+		slog.Error("GetUserTopVideos: Failed to fetch personalized videos", "error", err)
+		return nil, status.Error(codes.Internal, "error fetching personalized videos")
+	}
+
+	resp := &rankingpb.GetUserTopVideosResponse{
+		UserId:          req.GetUserId(),
+		Videos:          make([]*rankingpb.UserVideo, len(videos)),
+		SnapshotTakenAt: toTimestamp(takenAt),
+	}
+	viewerID, now := handlers.TokenViewer(ctx, req.GetViewerId()), time.Now()
+	for i, v := range videos {
+		resp.Videos[i] = &rankingpb.UserVideo{
+			VideoId:   v.VideoID,
+			UserId:    v.UserID,
+			Title:     v.Title,
+			Category:  v.Category,
+			Score:     v.Score,
+			Editorial: v.Editorial,
+			CreatedAt: toTimestamp(v.CreatedAt),
+		}
+		if i < handlers.MaxInteractionTokens {
+			resp.Videos[i].InteractionToken = s.handler.InteractionToken(viewerID, v.VideoID, now)
+		}
+	}
+	return resp, nil
+}
+
+// GetVideoRank retrieves the rank of a video in the global ranking.
+func (s *Server) GetVideoRank(ctx context.Context, req *rankingpb.GetVideoRankRequest) (*rankingpb.VideoRank, error) {
+	if req.GetVideoId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing video_id")
+	}
+
+	rank, err := s.handler.VideoRank(req.GetVideoId())
+	if errors.Is(err, handlers.ErrVideoNotRanked) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		slog.Error("GetVideoRank: Failed to fetch video rank", "error", err)
+		return nil, status.Error(codes.Internal, "error fetching video rank")
+	}
+
+	resp := &rankingpb.VideoRank{
+		VideoId: rank.VideoID,
+		Rank:    rank.Rank,
+		Score:   rank.Score,
+		Gap:     rank.Gap,
+	}
+	if rank.Above != nil {
+		resp.Above = toRankedVideo(*rank.Above)
+	}
+	return resp, nil
+}
+
+func toRankedVideo(v models.RankedVideo) *rankingpb.RankedVideo {
+	return &rankingpb.RankedVideo{
+		VideoId:      v.VideoID,
+		Score:        v.Score,
+		Editorial:    v.Editorial,
+		Exploration:  v.Exploration,
+		PreviousRank: int32(v.PreviousRank),
+		Movement:     v.Movement,
+		RankChange:   int32(v.RankChange),
+
+		InteractionToken: v.InteractionToken,
+	}
+}
+
+// toTimestamp converts a time, leaving the zero time unset.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp converts a timestamp, mapping an unset one to the zero time.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
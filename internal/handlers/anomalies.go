@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// maxAnomalies bounds the anomalies listed at once.
+const maxAnomalies = 1000
+
+// ListAnomaliesHandler lists the anomalies of the review queue.
+//
+//	@Summary		List anomalies
+//	@Description	List the suspicious spikes of interactions detected during ingestion. Pending anomalies form the review queue, oldest first: their interactions are quarantined, not scored, until approved or discarded. Other statuses are listed newest first.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			status	query		string	false	"pending (default), approved, discarded or all"
+//	@Param			limit	query		int		false	"Number of anomalies to retrieve (default: 100)"
+//	@Success		200		{array}		models.Anomaly
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/anomalies [get]
+func (h *RankingHandler) ListAnomaliesHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.AnomalyPending)
+		switch status {
+		case models.AnomalyPending, models.AnomalyApproved, models.AnomalyDiscarded:
+		case "all":
+			status = ""
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown anomaly status"})
+			return
+		}
+		limit := 100
+		if l := c.Query("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				limit = min(parsed, maxAnomalies)
+			}
+		}
+
+		anomalies, err := h.postgres.ListAnomalies(status, limit)
+		if err != nil {
+			slog.Error("ListAnomaliesHandler: Failed to fetch anomalies", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch anomalies"})
+			return
+		}
+		c.JSON(http.StatusOK, anomalies)
+	}
+}
+
+// GetAnomalyHandler retrieves an anomaly.
+//
+//	@Summary		Get an anomaly
+//	@Description	Get an anomaly with the interactions, counters and score delta it quarantined.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Anomaly ID"
+//	@Success		200	{object}	models.Anomaly
+//	@Failure		404	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/anomalies/{id} [get]
+func (h *RankingHandler) GetAnomalyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		anomaly, ok := h.anomalyFromParam(c, "GetAnomalyHandler")
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, anomaly)
+	}
+}
+
+// ApproveAnomalyHandler approves the interactions quarantined by an anomaly.
+//
+//	@Summary		Approve an anomaly
+//	@Description	Approve a pending anomaly: its quarantined interactions are counted and their score delta is applied to the video. Later anomalous interactions of the video go to a new anomaly.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Anomaly ID"
+//	@Success		200	{object}	models.Anomaly
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		410	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/anomalies/{id}/approve [post]
+func (h *RankingHandler) ApproveAnomalyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		pending, ok := h.anomalyFromParam(c, "ApproveAnomalyHandler")
+		if !ok {
+			return
+		}
+		// The score of deleted videos is frozen: their anomalies can only be discarded.
+		video, err := h.postgres.GetVideo(pending.VideoID)
+		if err != nil && !errors.Is(err, repository.ErrVideoNotFound) {
+			slog.Error("ApproveAnomalyHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+		if video != nil && video.Status == models.VideoStatusDeleted {
+			c.JSON(http.StatusGone, gin.H{"error": "Video has been deleted"})
+			return
+		}
+
+		// The anomaly is only approved once its interactions are applied.
+		anomaly, ok := h.reviewAnomaly(c, pending.ID, models.AnomalyApproved, "ApproveAnomalyHandler", func(anomaly *models.Anomaly) error {
+			return h.applyAnomaly(anomaly, video)
+		})
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, anomaly)
+	}
+}
+
+// DiscardAnomalyHandler discards the interactions quarantined by an anomaly.
+//
+//	@Summary		Discard an anomaly
+//	@Description	Discard a pending anomaly: its quarantined interactions are dropped and the score of the video is left unchanged. Later anomalous interactions of the video go to a new anomaly.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Anomaly ID"
+//	@Success		200	{object}	models.Anomaly
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/anomalies/{id}/discard [post]
+func (h *RankingHandler) DiscardAnomalyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anomaly ID"})
+			return
+		}
+		anomaly, ok := h.reviewAnomaly(c, uint(id), models.AnomalyDiscarded, "DiscardAnomalyHandler", nil)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, anomaly)
+	}
+}
+
+// reviewAnomaly moves a pending anomaly to the status once apply, if not nil,
+// succeeded, or responds with an error and returns false.
+func (h *RankingHandler) reviewAnomaly(c *gin.Context, id uint, status, handler string, apply func(*models.Anomaly) error) (*models.Anomaly, bool) {
+	anomaly, err := h.postgres.ReviewAnomaly(id, status, time.Now(), apply)
+	switch {
+	case errors.Is(err, repository.ErrAnomalyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly not found"})
+		return nil, false
+	case errors.Is(err, repository.ErrAnomalyReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Anomaly has already been reviewed"})
+		return nil, false
+	case err != nil:
+		slog.Error(handler+": Failed to review anomaly", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review anomaly"})
+		return nil, false
+	}
+	return anomaly, true
+}
+
+// applyAnomaly counts the interactions quarantined by an approved anomaly and re-scores
+// the video with them through the active ranking strategy, like RecordInteraction does
+// for each interaction. The catalog entry of the video is nil for unregistered videos.
+// The interactions are applied once, even when the approval then fails to be saved.
+func (h *RankingHandler) applyAnomaly(anomaly *models.Anomaly, video *models.Video) error {
+	claimed, err := h.redis.ClaimAnomaly(anomaly.ID)
+	if err != nil {
+		return fmt.Errorf("failed to claim anomaly in Redis: %w", err)
+	}
+	if !claimed {
+		// Applied by a previous approval that failed to be saved.
+		return nil
+	}
+
+	now := time.Now()
+	var state scoring.VideoState
+	if video != nil {
+		state = scoring.VideoState{Exists: true, Score: video.Score, CreatedAt: video.CreatedAt}
+		if video.LastInteractionAt != nil {
+			state.LastInteractionAt = *video.LastInteractionAt
+		}
+	}
+
+	increments := anomaly.Counters()
+	if state.Counters, err = h.redis.IncrementCounters(anomaly.VideoID, increments); err != nil {
+		h.releaseAnomaly(anomaly.ID)
+		return fmt.Errorf("failed to update counters in Redis: %w", err)
+	}
+
+	// Scores of strategies other than linear are not additive: the video is re-scored
+	// from its current state. Custom strategies get the summed weight added.
+	update := scoring.ScoreUpdate{Delta: anomaly.Delta, Weight: anomaly.Delta}
+	if bulk, ok := h.scorer.(scoring.BulkScorer); ok {
+		update, err = bulk.ScoreBulk(increments, anomaly.Delta, now, state)
+	}
+	if err == nil {
+		err = h.applyScoreUpdate(anomaly.VideoID, anomaly.UserID, video, state, update, increments, now)
+	}
+	if err != nil {
+		// The anomaly stays pending: withdraw the counters so that a retry counts them once.
+		if err := h.withdrawCounters(anomaly.VideoID, increments, nil); err != nil {
+			slog.Error("Failed to withdraw counters of anomaly", "id", anomaly.ID, "error", err)
+		}
+		h.releaseAnomaly(anomaly.ID)
+		return err
+	}
+	return nil
+}
+
+// releaseAnomaly withdraws the claim of an anomaly that failed to apply, so that a
+// retry applies it.
+func (h *RankingHandler) releaseAnomaly(id uint) {
+	if err := h.redis.ReleaseAnomaly(id); err != nil {
+		slog.Error("Failed to release anomaly", "id", id, "error", err)
+	}
+}
+
+// anomalyFromParam fetches the anomaly of the id path parameter, or responds with an
+// error and returns false.
+func (h *RankingHandler) anomalyFromParam(c *gin.Context, handler string) (*models.Anomaly, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anomaly ID"})
+		return nil, false
+	}
+	anomaly, err := h.postgres.GetAnomaly(uint(id))
+	if errors.Is(err, repository.ErrAnomalyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly not found"})
+		return nil, false
+	}
+	if err != nil {
+		slog.Error(handler+": Failed to fetch anomaly", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch anomaly"})
+		return nil, false
+	}
+	return anomaly, true
+}
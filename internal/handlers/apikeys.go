@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/auth"
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+const (
+	defaultAPIKeyUsageDays = 7
+	maxAPIKeyUsageDays     = 31
+	// maxAPIKeyGracePeriod bounds how long a rotated secret stays valid.
+	maxAPIKeyGracePeriod = 7 * 24 * time.Hour
+)
+
+// CreateAPIKeyHandler creates an API key.
+//
+//	@Summary		Create an API key
+//	@Description	Create an API key for a client application, granting space-separated scopes (interactions:write, rankings:read, videos:write or admin), with optional per-minute and daily quotas (0 is unlimited). The key is only returned here: only its hash is stored. Clients send it in the X-API-Key header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		models.APIKeyRequest	true	"API key payload"
+//	@Success		201	{object}	models.CreatedAPIKey
+//	@Failure		400	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/api-keys [post]
+func (h *RankingHandler) CreateAPIKeyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.APIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing name"})
+			return
+		}
+		scopes := strings.Fields(req.Scopes)
+		if len(scopes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing scopes"})
+			return
+		}
+		for _, scope := range scopes {
+			if !auth.IsScope(scope) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope " + scope})
+				return
+			}
+		}
+		if req.PerMinuteQuota < 0 || req.DailyQuota < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quotas must not be negative"})
+			return
+		}
+
+		secret, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			slog.Error("CreateAPIKeyHandler: Failed to generate API key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+			return
+		}
+		key := models.APIKey{
+			Name:           req.Name,
+			Prefix:         auth.APIKeyPrefix(secret),
+			Hash:           hash,
+			Scopes:         strings.Join(scopes, " "),
+			PerMinuteQuota: req.PerMinuteQuota,
+			DailyQuota:     req.DailyQuota,
+		}
+		if err := h.postgres.CreateAPIKey(&key); err != nil {
+			slog.Error("CreateAPIKeyHandler: Failed to create API key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+			return
+		}
+		c.JSON(http.StatusCreated, models.CreatedAPIKey{APIKey: key, Key: secret})
+	}
+}
+
+// ListAPIKeysHandler lists the API keys.
+//
+//	@Summary		List API keys
+//	@Description	List the API keys, including the revoked ones, without their secrets.
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{array}	models.APIKey
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/api-keys [get]
+func (h *RankingHandler) ListAPIKeysHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		keys, err := h.postgres.ListAPIKeys()
+		if err != nil {
+			slog.Error("ListAPIKeysHandler: Failed to fetch API keys", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+			return
+		}
+		c.JSON(http.StatusOK, keys)
+	}
+}
+
+// RevokeAPIKeyHandler revokes an API key.
+//
+//	@Summary		Revoke an API key
+//	@Description	Revoke an API key and its rotated secret. Replicas may accept it for up to AUTH_API_KEY_CACHE_TTL.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"API key ID"
+//	@Success		200	{object}	models.APIKey
+//	@Failure		404	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/api-keys/{id} [delete]
+func (h *RankingHandler) RevokeAPIKeyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		key, ok := h.apiKeyFromParam(c, "RevokeAPIKeyHandler")
+		if !ok {
+			return
+		}
+		if key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			if err := h.postgres.UpdateAPIKey(key); err != nil {
+				slog.Error("RevokeAPIKeyHandler: Failed to revoke API key", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, key)
+	}
+}
+
+// RotateAPIKeyHandler replaces the secret of an API key.
+//
+//	@Summary		Rotate an API key
+//	@Description	Replace the secret of an API key, keeping its ID, scopes, quotas and usage. The previous secret stays valid for the grace period (e.g. 1h, at most 168h, default 0) so that clients can be updated. The new key is only returned here.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int							true	"API key ID"
+//	@Param			rotation	body		models.RotateAPIKeyRequest	false	"Rotation payload"
+//	@Success		200			{object}	models.CreatedAPIKey
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		409			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/api-keys/{id}/rotate [post]
+func (h *RankingHandler) RotateAPIKeyHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.RotateAPIKeyRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+				return
+			}
+		}
+		var gracePeriod time.Duration
+		if req.GracePeriod != "" {
+			var err error
+			gracePeriod, err = time.ParseDuration(req.GracePeriod)
+			if err != nil || gracePeriod < 0 || gracePeriod > maxAPIKeyGracePeriod {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "grace_period must be a duration between 0 and 168h"})
+				return
+			}
+		}
+
+		key, ok := h.apiKeyFromParam(c, "RotateAPIKeyHandler")
+		if !ok {
+			return
+		}
+		if key.RevokedAt != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "API key is revoked"})
+			return
+		}
+
+		secret, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			slog.Error("RotateAPIKeyHandler: Failed to generate API key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate API key"})
+			return
+		}
+		now := time.Now()
+		key.PreviousHash, key.PreviousExpiresAt = "", nil
+		if gracePeriod > 0 {
+			expiresAt := now.Add(gracePeriod)
+			key.PreviousHash, key.PreviousExpiresAt = key.Hash, &expiresAt
+		}
+		key.Hash = hash
+		key.Prefix = auth.APIKeyPrefix(secret)
+		key.RotatedAt = &now
+		if err := h.postgres.UpdateAPIKey(key); err != nil {
+			slog.Error("RotateAPIKeyHandler: Failed to rotate API key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate API key"})
+			return
+		}
+		c.JSON(http.StatusOK, models.CreatedAPIKey{APIKey: *key, Key: secret})
+	}
+}
+
+// GetAPIKeyUsageHandler reports the usage of an API key.
+//
+//	@Summary		Get the usage of an API key
+//	@Description	Count the requests of an API key during the current minute and each of the last UTC days, against its quotas.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id		path		int	true	"API key ID"
+//	@Param			days	query		int	false	"Number of days to report, today included (default: 7, max: 31)"
+//	@Success		200		{object}	models.APIKeyUsage
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/api-keys/{id}/usage [get]
+func (h *RankingHandler) GetAPIKeyUsageHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		days := defaultAPIKeyUsageDays
+		if v := c.Query("days"); v != "" {
+			var err error
+			days, err = strconv.Atoi(v)
+			if err != nil || days < 1 || days > maxAPIKeyUsageDays {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 31"})
+				return
+			}
+		}
+
+		key, ok := h.apiKeyFromParam(c, "GetAPIKeyUsageHandler")
+		if !ok {
+			return
+		}
+		usage, err := h.redis.GetAPIKeyUsage(key.ID, days, time.Now())
+		if err != nil {
+			slog.Error("GetAPIKeyUsageHandler: Failed to fetch API key usage", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API key usage"})
+			return
+		}
+		usage.PerMinuteQuota, usage.DailyQuota = key.PerMinuteQuota, key.DailyQuota
+		c.JSON(http.StatusOK, usage)
+	}
+}
+
+// apiKeyFromParam fetches the API key of the id path parameter, or responds with an
+// error and returns false.
+func (h *RankingHandler) apiKeyFromParam(c *gin.Context, handler string) (*models.APIKey, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return nil, false
+	}
+	key, err := h.postgres.GetAPIKey(uint(id))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return nil, false
+	}
+	if err != nil {
+		slog.Error(handler+": Failed to fetch API key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API key"})
+		return nil, false
+	}
+	return key, true
+}
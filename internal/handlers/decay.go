@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"ranking-service/internal/scoring"
+)
+
+// DecayScores decays the scores of every video, in Redis and PostgreSQL, by the time
+// elapsed since they were last decayed, when the time_decay strategy is active. The
+// clock is shared in Redis, so replicas decaying concurrently decay each period once.
+// Scores are scaled in place, so interactions recorded meanwhile are kept.
+func (h *RankingHandler) DecayScores(now time.Time) error {
+	decayScorer, ok := h.scorer.(*scoring.TimeDecayScorer)
+	if !ok {
+		return nil
+	}
+	elapsed, err := h.redis.AdvanceDecayClock(now)
+	if err != nil {
+		return fmt.Errorf("failed to advance the decay clock: %w", err)
+	}
+	if elapsed <= 0 {
+		return nil
+	}
+
+	factor := decayScorer.Factor(elapsed)
+	if err := h.redis.ScaleScores(factor); err != nil {
+		return fmt.Errorf("failed to decay scores in Redis: %w", err)
+	}
+	if err := h.postgres.ScaleVideoScores(factor); err != nil {
+		return fmt.Errorf("failed to decay scores in PostgreSQL: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+// diversityCaps returns the per-owner and per-category caps for the request.
+// The max_per_owner and max_per_category query parameters override the configuration.
+func (h *RankingHandler) diversityCaps(c *gin.Context) (int, int) {
+	maxPerOwner, maxPerCategory := h.DiversityCaps()
+	if v := c.Query("max_per_owner"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			maxPerOwner = parsed
+		}
+	}
+	if v := c.Query("max_per_category"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			maxPerCategory = parsed
+		}
+	}
+	return maxPerOwner, maxPerCategory
+}
+
+// DiversityCaps returns the configured per-owner and per-category caps.
+func (h *RankingHandler) DiversityCaps() (int, int) {
+	return h.cfg.DiversityMaxPerOwner, h.cfg.DiversityMaxPerCategory
+}
+
+// getDiverseTopVideos fills a page of the global ranking while capping the videos
+// per owner and per category, pulling further candidates from Redis as needed.
+func (h *RankingHandler) getDiverseTopVideos(limit, maxPerOwner, maxPerCategory int) ([]models.RankedVideo, error) {
+	d := rerank.NewDiversifier(limit, maxPerOwner, maxPerCategory)
+	maxCandidates := h.cfg.DiversityMaxCandidates
+	if maxCandidates < limit {
+		maxCandidates = limit
+	}
+
+	offset, scanned := 0, 0
+	for !d.Full() && scanned < maxCandidates {
+		page, next, err := h.redis.ScanTopVideos(offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		scanned += len(page)
+
+		ids := make([]string, len(page))
+		for i, v := range page {
+			ids[i] = v.VideoID
+		}
+		videos, err := h.postgres.GetVideos(ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]models.Video, len(videos))
+		for _, v := range videos {
+			byID[v.VideoID] = v
+		}
+
+		for _, v := range page {
+			d.Offer(v, byID[v.VideoID].UserID, byID[v.VideoID].Category)
+		}
+
+		if next == 0 {
+			break
+		}
+		offset = next
+	}
+	return d.Videos(), nil
+}
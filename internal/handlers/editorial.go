@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+// CreateEditorialRuleHandler creates a pin or a boost.
+//
+//	@Summary		Create an editorial rule
+//	@Description	Pin a video at a position or boost its score in the leaderboards for a period of time.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			rule	body		models.EditorialRuleRequest	true	"Editorial rule payload"
+//	@Success		201		{object}	models.EditorialRule
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/editorial [post]
+func (h *RankingHandler) CreateEditorialRuleHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.EditorialRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if req.VideoID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing video_id in payload"})
+			return
+		}
+
+		rule := models.EditorialRule{
+			VideoID:  req.VideoID,
+			Kind:     req.Kind,
+			Note:     req.Note,
+			StartsAt: time.Now(),
+			EndsAt:   req.EndsAt,
+		}
+		if req.StartsAt != nil {
+			rule.StartsAt = *req.StartsAt
+		}
+		if !rule.EndsAt.After(rule.StartsAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+			return
+		}
+
+		switch req.Kind {
+		case models.EditorialPin:
+			if req.Position < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Pins require a position of at least 1"})
+				return
+			}
+			rule.Position = req.Position
+		case models.EditorialBoost:
+			if req.Multiplier <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Boosts require a positive multiplier"})
+				return
+			}
+			rule.Multiplier = req.Multiplier
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown editorial rule kind"})
+			return
+		}
+
+		if err := h.postgres.CreateEditorialRule(&rule); err != nil {
+			slog.Error("CreateEditorialRuleHandler: Failed to create editorial rule", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create editorial rule"})
+			return
+		}
+		h.publishRankingUpdate(models.RankingUpdate{})
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// ListEditorialRulesHandler lists the editorial rules.
+//
+//	@Summary		List editorial rules
+//	@Description	List pins and boosts. With active=true only the rules currently in effect are returned.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			active	query	bool	false	"Only list rules currently in effect"
+//	@Success		200		{array}	models.EditorialRule
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/editorial [get]
+func (h *RankingHandler) ListEditorialRulesHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var (
+			rules []models.EditorialRule
+			err   error
+		)
+		if c.Query("active") == "true" {
+			rules, err = h.postgres.GetActiveEditorialRules(time.Now())
+		} else {
+			rules, err = h.postgres.ListEditorialRules()
+		}
+		if err != nil {
+			slog.Error("ListEditorialRulesHandler: Failed to fetch editorial rules", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch editorial rules"})
+			return
+		}
+		c.JSON(http.StatusOK, rules)
+	}
+}
+
+// DeleteEditorialRuleHandler removes an editorial rule.
+//
+//	@Summary		Delete an editorial rule
+//	@Description	Remove a pin or a boost.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Editorial rule ID"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/editorial/{id} [delete]
+func (h *RankingHandler) DeleteEditorialRuleHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid editorial rule ID"})
+			return
+		}
+
+		if err := h.postgres.DeleteEditorialRule(uint(id)); err != nil {
+			if errors.Is(err, repository.ErrEditorialRuleNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Editorial rule not found"})
+				return
+			}
+			slog.Error("DeleteEditorialRuleHandler: Failed to delete editorial rule", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete editorial rule"})
+			return
+		}
+		h.publishRankingUpdate(models.RankingUpdate{})
+		c.JSON(http.StatusOK, gin.H{"id": id, "status": "deleted"})
+	}
+}
+
+// applyGlobalEditorial applies the active editorial rules to the global ranking.
+// Editorial rules are best effort: on failure the organic ranking is served.
+func (h *RankingHandler) applyGlobalEditorial(videos []models.RankedVideo, limit int) []models.RankedVideo {
+	rules, err := h.postgres.GetActiveEditorialRules(time.Now())
+	if err != nil {
+		slog.Error("Failed to fetch editorial rules", "error", err)
+		return videos
+	}
+	if len(rules) == 0 {
+		return videos
+	}
+
+	scores, err := h.redis.GetVideoScores(rerank.EditorialVideoIDs(videos, rules))
+	if err != nil {
+		slog.Error("Failed to fetch editorial video scores", "error", err)
+		return videos
+	}
+	return rerank.ApplyEditorial(videos, rules, scores, limit)
+}
+
+// applyUserEditorial applies the active editorial rules that target the user's videos.
+func (h *RankingHandler) applyUserEditorial(userID string, videos []models.Video, limit int) []models.UserTopVideo {
+	result := make([]models.UserTopVideo, len(videos))
+	for i, v := range videos {
+		result[i] = models.UserTopVideo{Video: v}
+	}
+
+	rules, err := h.postgres.GetActiveEditorialRules(time.Now())
+	if err != nil {
+		slog.Error("Failed to fetch editorial rules", "error", err)
+		return result
+	}
+	if len(rules) == 0 {
+		return result
+	}
+
+	ranked := make([]models.RankedVideo, len(videos))
+	byID := make(map[string]models.Video, len(videos))
+	for i, v := range videos {
+		ranked[i] = models.RankedVideo{VideoID: v.VideoID, Score: v.Score}
+		byID[v.VideoID] = v
+	}
+
+	extra, err := h.postgres.GetVideos(rerank.EditorialVideoIDs(ranked, rules))
+	if err != nil {
+		slog.Error("Failed to fetch editorial videos", "error", err)
+		return result
+	}
+	scores := make(map[string]float64, len(extra))
+	for _, v := range extra {
+		if v.UserID != userID || v.ModerationStatus != models.ModerationVisible {
+			continue
+		}
+		scores[v.VideoID] = v.Score
+		byID[v.VideoID] = v
+	}
+
+	ranked = rerank.ApplyEditorial(ranked, rules, scores, limit)
+	result = make([]models.UserTopVideo, len(ranked))
+	for i, r := range ranked {
+		result[i] = models.UserTopVideo{Video: byID[r.VideoID], Editorial: r.Editorial}
+	}
+	return result
+}
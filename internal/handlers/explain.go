@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/rerank"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// ExplainVideoScoreHandler breaks the score of a video down.
+//
+//	@Summary		Explain a video's score
+//	@Description	Break the score of a video down by interaction type and decay under the active scoring strategy, list the freshness, editorial and moderation adjustments applied at read time, and show the gap to the video at the next-higher rank.
+//	@Tags			Videos
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Success		200			{object}	models.ScoreExplanation
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id}/explain [get]
+func (h *RankingHandler) ExplainVideoScoreHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+
+		video, err := h.postgres.GetVideo(videoID)
+		if err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("ExplainVideoScoreHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+
+		counters, err := h.getCounters(videoID)
+		if err != nil {
+			slog.Error("ExplainVideoScoreHandler: Failed to fetch counters", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video statistics"})
+			return
+		}
+
+		rank, err := h.redis.GetVideoRank(videoID)
+		if err != nil {
+			slog.Error("ExplainVideoScoreHandler: Failed to fetch rank", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video rank"})
+			return
+		}
+
+		now := time.Now()
+		state := scoring.VideoState{
+			Exists:    true,
+			Score:     video.Score,
+			CreatedAt: video.CreatedAt,
+			Counters:  counters,
+		}
+		if video.LastInteractionAt != nil {
+			state.LastInteractionAt = *video.LastInteractionAt
+		}
+
+		explanation := models.ScoreExplanation{
+			VideoID:       videoID,
+			Strategy:      h.scorer.Name(),
+			Score:         video.Score,
+			Contributions: []models.ScoreContribution{},
+			Rank:          rank,
+		}
+		if explainer, ok := h.scorer.(scoring.Explainer); ok {
+			explanation.Contributions = explainer.Explain(state, now)
+		}
+		if formulaScorer, ok := h.scorer.(*scoring.FormulaScorer); ok {
+			explanation.Formula = formulaScorer.Formula().String()
+		}
+
+		explanation.Adjustments, explanation.EffectiveScore = h.explainAdjustments(video, video.Score, now)
+
+		c.JSON(http.StatusOK, explanation)
+	}
+}
+
+// explainAdjustments lists the read-time adjustments applied to a video's score, in
+// the order the global ranking applies them, and returns the resulting score.
+// Pins do not change the score and are reported with a value of 0.
+func (h *RankingHandler) explainAdjustments(video *models.Video, score float64, now time.Time) ([]models.ScoreContribution, float64) {
+	adjustments := []models.ScoreContribution{}
+
+	if video.ModerationStatus != "" && video.ModerationStatus != models.ModerationVisible {
+		detail := video.ModerationStatus
+		if video.ModerationReason != "" {
+			detail += ": " + video.ModerationReason
+		}
+		return append(adjustments, models.ScoreContribution{Component: "moderation", Value: -score, Detail: detail}), 0
+	}
+
+	if h.cfg.FreshnessWeight > 0 {
+		boost := rerank.FreshnessBoost(now.Sub(video.CreatedAt), h.cfg.FreshnessWeight, h.cfg.FreshnessHalfLife)
+		adjustments = append(adjustments, models.ScoreContribution{
+			Component: "freshness",
+			Value:     score * (boost - 1),
+			Detail:    fmt.Sprintf("× %.4g", boost),
+		})
+		score *= boost
+	}
+
+	rules, err := h.postgres.GetActiveEditorialRules(now)
+	if err != nil {
+		slog.Error("Failed to fetch editorial rules", "error", err)
+		return adjustments, score
+	}
+	for _, rule := range rules {
+		if rule.VideoID != video.VideoID {
+			continue
+		}
+		switch rule.Kind {
+		case models.EditorialBoost:
+			adjustments = append(adjustments, models.ScoreContribution{
+				Component: "editorial_boost",
+				Value:     score * (rule.Multiplier - 1),
+				Detail:    fmt.Sprintf("× %g", rule.Multiplier),
+			})
+			score *= rule.Multiplier
+		case models.EditorialPin:
+			adjustments = append(adjustments, models.ScoreContribution{
+				Component: "editorial_pin",
+				Detail:    fmt.Sprintf("pinned at position %d", rule.Position),
+			})
+		}
+	}
+	return adjustments, score
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/models"
+)
+
+// Export formats.
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+// exportPageSize is the number of videos read from Redis at a time during an export.
+const exportPageSize = 1000
+
+// exportContentTypes maps the content types offered by the export endpoint to formats.
+var exportContentTypes = map[string]string{
+	"text/csv":             ExportCSV,
+	"application/x-ndjson": ExportNDJSON,
+}
+
+// ErrUnknownWindow is returned for windowed leaderboards that are not configured.
+var ErrUnknownWindow = errors.New("unknown leaderboard window")
+
+// ExportLeaderboard writes every visible video of a leaderboard to w as CSV or NDJSON,
+// reading the leaderboard page by page. Pages are flushed as they are written when w
+// is an http.Flusher. As the leaderboard may change during the export, a video that
+// moves between pages can be skipped or repeated.
+func (h *RankingHandler) ExportLeaderboard(w io.Writer, board models.Leaderboard, format string) error {
+	if board.Window > 0 && !h.hasWindow(board.Window) {
+		return ErrUnknownWindow
+	}
+
+	var (
+		write func(models.ExportedVideo) error
+		flush func() error
+	)
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"rank", "video_id", "score", "user_id", "category"}); err != nil {
+			return err
+		}
+		write = func(v models.ExportedVideo) error {
+			return cw.Write([]string{strconv.Itoa(v.Rank), v.VideoID, strconv.FormatFloat(v.Score, 'f', -1, 64), v.UserID, v.Category})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(v models.ExportedVideo) error {
+			return enc.Encode(v)
+		}
+		flush = func() error { return nil }
+	default:
+		return errors.New("unknown export format " + format)
+	}
+
+	at := time.Now()
+	rank, offset := 0, 0
+	for {
+		videos, next, err := h.redis.ScanLeaderboard(board, at, offset, exportPageSize)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(videos))
+		for i, v := range videos {
+			ids[i] = v.VideoID
+		}
+		catalog, err := h.postgres.GetVideos(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[string]models.Video, len(catalog))
+		for _, v := range catalog {
+			byID[v.VideoID] = v
+		}
+
+		for _, v := range videos {
+			rank++
+			video := byID[v.VideoID]
+			row := models.ExportedVideo{Rank: rank, VideoID: v.VideoID, Score: v.Score, UserID: video.UserID, Category: video.Category}
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		if next == 0 {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// hasWindow reports whether a windowed leaderboard is maintained for the window.
+func (h *RankingHandler) hasWindow(window time.Duration) bool {
+	return slices.Contains(h.cfg.LeaderboardWindows, window)
+}
+
+// ExportLeaderboardHandler streams a leaderboard.
+//
+//	@Summary		Export a leaderboard
+//	@Description	Stream every visible video of the global leaderboard, of an owner's leaderboard, or of a windowed leaderboard ranking videos by the score gained during the current window. The format is negotiated with the Accept header: CSV (the default) or NDJSON.
+//	@Tags			Videos
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Param			owner	query		string	false	"Export the leaderboard of this owner's videos"
+//	@Param			window	query		string	false	"Export the windowed leaderboard of this configured window, e.g. 24h"
+//	@Success		200		{array}		models.ExportedVideo
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		406		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/top/export [get]
+func (h *RankingHandler) ExportLeaderboardHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var board models.Leaderboard
+		board.Owner = c.Query("owner")
+		if v := c.Query("window"); v != "" {
+			window, err := time.ParseDuration(v)
+			if err != nil || window <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window, expected a duration such as 24h"})
+				return
+			}
+			board.Window = window
+		}
+		if board.Owner != "" && board.Window > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "owner and window cannot be combined"})
+			return
+		}
+		if board.Window > 0 && !h.hasWindow(board.Window) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown leaderboard window"})
+			return
+		}
+
+		contentType := c.NegotiateFormat("text/csv", "application/x-ndjson")
+		if contentType == "" {
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "Supported formats are text/csv and application/x-ndjson"})
+			return
+		}
+		format := exportContentTypes[contentType]
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="leaderboard-`+board.Name()+"."+format+`"`)
+		c.Status(http.StatusOK)
+		if err := h.ExportLeaderboard(c.Writer, board, format); err != nil {
+			slog.Error("ExportLeaderboardHandler: Failed to export leaderboard", "leaderboard", board.Name(), "error", err)
+			if !c.Writer.Written() {
+				c.Writer.Header().Del("Content-Disposition")
+				c.Writer.Header().Del("Content-Type")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export leaderboard"})
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// GetScoringFormulaHandler retrieves the active scoring formula.
+//
+//	@Summary		Get the scoring formula
+//	@Description	Get the formula used by the formula ranking strategy and the variables it may use.
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/scoring/formula [get]
+func (h *RankingHandler) GetScoringFormulaHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		expression := ""
+		if fs, ok := h.scorer.(*scoring.FormulaScorer); ok {
+			expression = fs.Formula().String()
+		} else {
+			saved, err := h.postgres.GetActiveScoringFormula()
+			if err != nil && !errors.Is(err, repository.ErrScoringFormulaNotFound) {
+				slog.Error("GetScoringFormulaHandler: Failed to fetch scoring formula", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scoring formula"})
+				return
+			}
+			if saved != nil {
+				expression = saved.Expression
+			}
+		}
+		if expression == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No scoring formula"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"expression": expression,
+			"strategy":   h.scorer.Name(),
+			"variables":  scoring.FormulaVariables,
+		})
+	}
+}
+
+// SetScoringFormulaHandler validates and saves a new scoring formula.
+//
+//	@Summary		Replace the scoring formula
+//	@Description	Validate and save a scoring formula. When the formula strategy is active it is swapped in immediately; other replicas pick it up on their next reload. Videos are re-scored on their next interaction.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			formula	body		models.ScoringFormulaRequest	true	"Formula payload"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/scoring/formula [put]
+func (h *RankingHandler) SetScoringFormulaHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.ScoringFormulaRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+
+		compiled, err := scoring.CompileFormula(req.Expression)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid formula", "err_details": err.Error()})
+			return
+		}
+
+		if err := h.postgres.SaveScoringFormula(&models.ScoringFormula{Expression: req.Expression}); err != nil {
+			slog.Error("SetScoringFormulaHandler: Failed to save scoring formula", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save scoring formula"})
+			return
+		}
+
+		active := false
+		if fs, ok := h.scorer.(*scoring.FormulaScorer); ok {
+			fs.Swap(compiled)
+			active = true
+		}
+
+		slog.Info("Scoring formula updated", "expression", req.Expression, "active", active)
+		c.JSON(http.StatusOK, gin.H{
+			"expression": req.Expression,
+			"active":     active,
+		})
+	}
+}
@@ -1,36 +1,130 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"ranking-service/config"
+	"ranking-service/internal/anomaly"
+	"ranking-service/internal/impression"
+	"ranking-service/internal/ratelimit"
 	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/internal/stream"
+	"ranking-service/internal/webhook"
 	"ranking-service/models"
 )
 
 type RankingHandler struct {
 	postgres repository.PostgresRepository
 	redis    repository.RedisRepository
+	cfg      config.RankingConfig
+	scorer   scoring.Scorer
+	stream   *stream.Hub
+	webhooks *webhook.Dispatcher
+	// anomalies quarantines the anomalous interactions, when set.
+	anomalies *anomaly.Detector
+	// tokens issues the interaction tokens of top lists and requires them on
+	// interactions, when set.
+	tokens *impression.Tokens
+	// limiter limits the interactions of batches and streams one by one, when set.
+	limiter *ratelimit.Limiter
 }
 
-func NewRankingHandler(postgres repository.PostgresRepository, redis repository.RedisRepository) *RankingHandler {
-	return &RankingHandler{postgres, redis}
+// Option customizes a RankingHandler.
+type Option func(*RankingHandler)
+
+// WithConfig sets the ranking configuration used by the handlers.
+func WithConfig(cfg config.RankingConfig) Option {
+	return func(h *RankingHandler) {
+		h.cfg = cfg
+	}
+}
+
+// WithAnomalyDetector quarantines the interactions flagged by the detector.
+func WithAnomalyDetector(detector *anomaly.Detector) Option {
+	return func(h *RankingHandler) {
+		h.anomalies = detector
+	}
+}
+
+// WithInteractionTokens issues interaction tokens in top lists and requires them on
+// interactions.
+func WithInteractionTokens(tokens *impression.Tokens) Option {
+	return func(h *RankingHandler) {
+		h.tokens = tokens
+	}
+}
+
+// WithRateLimiter limits the interactions of batches and gRPC streams one by one,
+// with the rules of their route.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(h *RankingHandler) {
+		h.limiter = limiter
+	}
+}
+
+// WithScorer sets the strategy used to score interactions.
+func WithScorer(scorer scoring.Scorer) Option {
+	return func(h *RankingHandler) {
+		h.scorer = scorer
+	}
+}
+
+func NewRankingHandler(postgres repository.PostgresRepository, redis repository.RedisRepository, opts ...Option) *RankingHandler {
+	h := &RankingHandler{
+		postgres: postgres,
+		redis:    redis,
+		scorer:   scoring.NewLinearScorer(scoring.DefaultWeights),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	interval := h.cfg.StreamCoalesceInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	h.stream = stream.NewHub(h.getStreamTopVideos, interval)
+	h.webhooks = webhook.NewDispatcher(postgres, redis, h.getStreamTopVideos, h.cfg)
+	return h
+}
+
+// MaxTopLimit bounds the number of videos of a top list.
+const MaxTopLimit = 1000
+
+// topLimit parses the limit of a top list request, clamped between 1 and MaxTopLimit.
+func topLimit(c *gin.Context) int {
+	limit := 10 // default value
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = min(max(parsed, 1), MaxTopLimit)
+		}
+	}
+	return limit
 }
 
 // UpdateVideoScoreHandler updates a video's score based on an interaction.
 //
 //	@Summary		Update video score based on interaction
-//	@Description	Update a video's score by processing interactions (views, likes, etc.). The payload must include userID.
+//	@Description	Update a video's score by processing interactions (views, likes, etc.). The payload must include userID, and viewer_id for the interaction types capped per viewer. Interactions beyond the score cap of their viewer are counted but not scored, with the status capped and the withheld score in capped. Interactions of a video whose rate or viewer diversity is anomalous are quarantined until an admin reviews them, with the status quarantined and the held score in quarantined. When interaction tokens are enabled, the payload must carry the viewer_id and the token of the video served to the viewer in a top list; a token is valid for one interaction, unless the interaction fails.
 //	@Tags			Videos
 //	@Accept			json
 //	@Produce		json
 //	@Param			video_id	path		string						true	"Video ID"
 //	@Param			interaction	body		models.InteractionRequest	true	"Interaction payload"
 //	@Success		200			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		409			{object}	map[string]interface{}
+//	@Failure		410			{object}	map[string]interface{}
+//	@Failure		429			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
 //	@Router			/videos/{video_id}/interaction [post]
 func (h *RankingHandler) UpdateVideoScoreHandler() func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -47,76 +141,109 @@ func (h *RankingHandler) UpdateVideoScoreHandler() func(c *gin.Context) {
 
 		fmt.Printf("req: %#v\n", req)
 
-		// Ensure userID is provided.
-		if req.UserID == "" {
+		outcome, err := h.RecordInteraction(req)
+		switch {
+		case errors.Is(err, ErrMissingUserID):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing userID in payload"})
 			return
-		}
-
-		// Determine score delta based on interaction type.
-		var delta float64
-		switch req.Type {
-		case "view":
-			delta = 0.1
-		case "like":
-			delta = 1.0
-		case "comment":
-			delta = 1.5
-		case "share":
-			delta = 2.0
-		case "watch_time":
-			delta = req.Weight // Weight provided dynamically.
-		default:
+		case errors.Is(err, ErrMissingViewerID):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing viewer_id, required by the viewer score caps"})
+			return
+		case errors.Is(err, ErrVideoNotRegistered):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video is not registered"})
+			return
+		case errors.Is(err, ErrVideoDeleted):
+			c.JSON(http.StatusGone, gin.H{"error": "Video has been deleted"})
+			return
+		case errors.Is(err, scoring.ErrUnknownInteraction):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown interaction type"})
 			return
-		}
-
-		// Update the score in Redis.
-		if err := h.redis.UpdateVideoScore(req.VideoID, delta); err != nil {
-			slog.Error("UpdateVideoScoreHandler: Failed to update score in Redis", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update score in Redis", "err_details": err.Error()})
+		case errors.Is(err, impression.ErrMissingToken):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing interaction token"})
 			return
-		}
-
-		// Update (or create) the video record in PostgreSQL using GORM.
-		if err := h.postgres.UpdateVideoScoreInPostgres(req.VideoID, req.UserID, delta); err != nil {
-			slog.Error("UpdateVideoScoreHandler: Failed to update score in PostgreSQL", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update score in PostgreSQL"})
+		case errors.Is(err, impression.ErrInvalidToken):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid interaction token"})
+			return
+		case errors.Is(err, impression.ErrTokenUsed):
+			c.JSON(http.StatusConflict, gin.H{"error": "Interaction token already used"})
+			return
+		case err != nil:
+			slog.Error("UpdateVideoScoreHandler: Failed to record interaction", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update video score", "err_details": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		resp := gin.H{
 			"videoID": req.VideoID,
-			"delta":   delta,
-			"status":  "updated",
-		})
+			"delta":   outcome.Delta,
+			"status":  outcome.Status,
+		}
+		switch outcome.Status {
+		case InteractionCapped:
+			resp["capped"] = outcome.Withheld
+		case InteractionQuarantined:
+			resp["quarantined"] = outcome.Withheld
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
 // GetGlobalTopVideosHandler retrieves the top-ranked videos globally using Redis.
 //
 //	@Summary		Retrieve global top videos
-//	@Description	Get the top ranked videos globally.
+//	@Description	Get the IDs of the top ranked videos globally, or the videos with their scores and annotations with detail=true. Optionally caps the videos per owner or category, boosts fresh videos and reserves exploration slots, then applies the active editorial pins and boosts. Editorial and exploration slots are marked in the response, as well as the movement of each video since the leaderboard snapshot of one movement window (24h by default) earlier.
 //	@Tags			Videos
 //	@Accept			json
 //	@Produce		json
-//	@Param			limit	query	int	false	"Number of videos to retrieve"
-//	@Success		200		{array}	string
+//	@Param			limit				query		int		false	"Number of videos to retrieve (1 to 1000)"
+//	@Param			max_per_owner		query		int		false	"Maximum number of videos per owner, 0 disables the cap"
+//	@Param			max_per_category	query		int		false	"Maximum number of videos per category, 0 disables the cap"
+//	@Param			at					query		string	false	"Serve the ranking as of this RFC 3339 time, from the nearest leaderboard snapshot"
+//	@Param			detail				query		bool	false	"Serve the videos with their scores, editorial and exploration marks, movements and interaction tokens instead of their IDs"
+//	@Param			viewer_id			query		string	false	"Viewer served the ranking on behalf of an API key client, who gets an interaction token for each of the first 100 videos with detail=true when tokens are enabled. Bearer tokens get them for their subject"
+//	@Success		200					{array}		string
+//	@Header			200					{string}	X-Snapshot-Taken-At	"Time of the snapshot that served an at= query"
+//	@Failure		400					{object}	map[string]interface{}
+//	@Failure		404					{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
 //	@Router			/videos/top [get]
 func (h *RankingHandler) GetGlobalTopVideosHandler() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		limit := 10 // default value
-		if l := c.Query("limit"); l != "" {
-			if parsed, err := strconv.Atoi(l); err == nil {
-				limit = parsed
-			}
+		limit := topLimit(c)
+
+		at, err := parseAt(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected an RFC 3339 time"})
+			return
 		}
 
-		videos, err := h.redis.GetTopVideos(limit)
+		maxPerOwner, maxPerCategory := h.diversityCaps(c)
+		videos, takenAt, err := h.TopVideos(limit, maxPerOwner, maxPerCategory, at)
+		if errors.Is(err, repository.ErrSnapshotNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No leaderboard snapshot near the requested time"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching top videos"})
 			return
 		}
+		if !takenAt.IsZero() {
+			c.Header(snapshotTakenAtHeader, takenAt.Format(time.RFC3339))
+		}
+		// The ranking is a list of video IDs unless the detailed videos are requested.
+		if detail, _ := strconv.ParseBool(c.Query("detail")); !detail {
+			ids := make([]string, len(videos))
+			for i, v := range videos {
+				ids[i] = v.VideoID
+			}
+			c.JSON(http.StatusOK, ids)
+			return
+		}
+		viewerID, now := TokenViewer(c.Request.Context(), c.Query("viewer_id")), time.Now()
+		for i := range videos[:min(len(videos), MaxInteractionTokens)] {
+			videos[i].InteractionToken = h.InteractionToken(viewerID, videos[i].VideoID, now)
+		}
+
 		c.JSON(http.StatusOK, videos)
 	}
 }
@@ -124,30 +251,47 @@ func (h *RankingHandler) GetGlobalTopVideosHandler() func(c *gin.Context) {
 // GetUserTopVideosHandler retrieves the top videos for a given user from PostgreSQL.
 //
 //	@Summary		Retrieve personalized top videos for a user
-//	@Description	Get the top ranked videos for a specific user.
+//	@Description	Get the top ranked videos for a specific user. Active editorial pins and boosts on the user's videos are applied and marked in the response.
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		string	true	"User ID"
-//	@Param			limit	query		int		false	"Number of videos to retrieve"
-//	@Success		200		{object}	map[string]interface{}
+//	@Param			userID		path		string	true	"User ID"
+//	@Param			limit		query		int		false	"Number of videos to retrieve (1 to 1000)"
+//	@Param			at			query		string	false	"Serve the user's videos of the nearest global leaderboard snapshot to this RFC 3339 time"
+//	@Param			viewer_id	query		string	false	"Viewer served the videos on behalf of an API key client, who gets an interaction token for each of the first 100 videos when tokens are enabled. Bearer tokens get them for their subject"
+//	@Success		200			{object}	map[string]interface{}
+//	@Header			200			{string}	X-Snapshot-Taken-At	"Time of the snapshot that served an at= query"
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
 //	@Router			/users/{userID}/videos/top [get]
 func (h *RankingHandler) GetUserTopVideosHandler() func(c *gin.Context) {
 	return func(c *gin.Context) {
 		userID := c.Param("userID")
-		limit := 10 // default value
-		if l := c.Query("limit"); l != "" {
-			if parsed, err := strconv.Atoi(l); err == nil {
-				limit = parsed
-			}
+		limit := topLimit(c)
+
+		at, err := parseAt(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected an RFC 3339 time"})
+			return
 		}
 
-		// Retrieve top videos for the user from PostgreSQL.
-		videos, err := h.postgres.GetUserTopVideosFromDB(userID, limit)
+		videos, takenAt, err := h.UserTopVideos(userID, limit, at)
+		if errors.Is(err, repository.ErrSnapshotNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No leaderboard snapshot near the requested time"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching personalized videos"})
 			return
 		}
+		if !takenAt.IsZero() {
+			c.Header(snapshotTakenAtHeader, takenAt.Format(time.RFC3339))
+		}
+		viewerID, now := TokenViewer(c.Request.Context(), c.Query("viewer_id")), time.Now()
+		for i := range videos[:min(len(videos), MaxInteractionTokens)] {
+			videos[i].InteractionToken = h.InteractionToken(viewerID, videos[i].VideoID, now)
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"userID": userID,
@@ -155,3 +299,30 @@ func (h *RankingHandler) GetUserTopVideosHandler() func(c *gin.Context) {
 		})
 	}
 }
+
+// GetVideoRankHandler retrieves the rank of a video in the global ranking.
+//
+//	@Summary		Retrieve a video's rank
+//	@Description	Get the rank of a video in the organic global ranking, and the score it takes to overtake the video at the next-higher rank. Hidden videos are not ranked.
+//	@Tags			Videos
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Success		200			{object}	models.RankInfo
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id}/rank [get]
+func (h *RankingHandler) GetVideoRankHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		rank, err := h.VideoRank(c.Param("video_id"))
+		if errors.Is(err, ErrVideoNotRanked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video is not ranked"})
+			return
+		}
+		if err != nil {
+			slog.Error("GetVideoRankHandler: Failed to fetch rank", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video rank"})
+			return
+		}
+		c.JSON(http.StatusOK, rank)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/history"
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+const (
+	defaultHistoryRange      = 24 * time.Hour
+	defaultHistoryResolution = time.Hour
+	// maxHistoryBuckets bounds the size of a score history response.
+	maxHistoryBuckets = 1000
+)
+
+// GetScoreHistoryHandler retrieves the score of a video over time.
+//
+//	@Summary		Retrieve the score history of a video
+//	@Description	Get the score of a video over a time range, in buckets of the given resolution. Each bucket holds the score at its end and the lowest and highest sampled scores.
+//	@Tags			Videos
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Param			from		query		string	false	"Start of the range, RFC 3339 (default: 24 hours before to)"
+//	@Param			to			query		string	false	"End of the range, RFC 3339 (default: now)"
+//	@Param			resolution	query		string	false	"Bucket size, e.g. 5m or 1h (default: 1h)"
+//	@Success		200			{object}	models.ScoreHistoryResponse
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id}/history [get]
+func (h *RankingHandler) GetScoreHistoryHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+
+		to := time.Now()
+		if v := c.Query("to"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, expected an RFC 3339 time"})
+				return
+			}
+			to = parsed
+		}
+		from := to.Add(-defaultHistoryRange)
+		if v := c.Query("from"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, expected an RFC 3339 time"})
+				return
+			}
+			from = parsed
+		}
+		resolution := defaultHistoryResolution
+		if v := c.Query("resolution"); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil || parsed <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution, expected a positive duration"})
+				return
+			}
+			resolution = parsed
+		}
+		// Align buckets on the resolution so that charts line up across requests.
+		from = from.Truncate(resolution)
+		if !from.Before(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+			return
+		}
+		if to.Sub(from)/resolution >= maxHistoryBuckets {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many buckets, use a coarser resolution or a shorter range"})
+			return
+		}
+
+		if _, err := h.postgres.GetVideo(videoID); err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("GetScoreHistoryHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+
+		samples, err := h.postgres.GetScoreSamples(videoID, from, to)
+		if err != nil {
+			slog.Error("GetScoreHistoryHandler: Failed to fetch score samples", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch score history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ScoreHistoryResponse{
+			VideoID:    videoID,
+			From:       from,
+			To:         to,
+			Resolution: resolution.String(),
+			Buckets:    history.Bucket(samples, from, to, resolution),
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/anomaly"
+	"ranking-service/internal/auth"
+	"ranking-service/internal/impression"
+	"ranking-service/internal/ratelimit"
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// Errors of RecordInteraction for interactions that are rejected.
+var (
+	ErrMissingVideoID     = errors.New("missing videoID")
+	ErrMissingUserID      = errors.New("missing userID")
+	ErrVideoNotRegistered = errors.New("video is not registered")
+	ErrVideoDeleted       = errors.New("video has been deleted")
+	ErrMissingViewerID    = errors.New("missing viewerID, required by the viewer score caps")
+)
+
+// Statuses of recorded interactions.
+const (
+	InteractionUpdated     = "updated"
+	InteractionCapped      = "capped"
+	InteractionQuarantined = "quarantined"
+)
+
+// InteractionOutcome is how a recorded interaction changed the score of its video.
+type InteractionOutcome struct {
+	Status string
+	Delta  float64 // Added to the score.
+	// Withheld is the score that was not added: beyond the cap of the viewer when
+	// capped, or held for review when quarantined.
+	Withheld float64
+}
+
+// RecordInteraction counts an interaction and updates the video's score in Redis and
+// PostgreSQL with the active ranking strategy. Interactions that exceed the cap of
+// their viewer are counted as capped, and anomalous ones are quarantined until
+// reviewed: the score is left unchanged in both cases. Shared by the REST and gRPC APIs.
+func (h *RankingHandler) RecordInteraction(req models.InteractionRequest) (InteractionOutcome, error) {
+	if req.VideoID == "" {
+		return InteractionOutcome{}, ErrMissingVideoID
+	}
+	if req.UserID == "" {
+		return InteractionOutcome{}, ErrMissingUserID
+	}
+
+	// Check the catalog: deleted videos never receive interactions, unknown
+	// videos only when registration is required.
+	video, err := h.postgres.GetVideo(req.VideoID)
+	switch {
+	case errors.Is(err, repository.ErrVideoNotFound):
+		if h.cfg.RequireRegisteredVideos {
+			return InteractionOutcome{}, ErrVideoNotRegistered
+		}
+	case err != nil:
+		return InteractionOutcome{}, fmt.Errorf("failed to fetch video: %w", err)
+	case video.Status == models.VideoStatusDeleted:
+		return InteractionOutcome{}, ErrVideoDeleted
+	}
+
+	// Determine the score update with the active ranking strategy.
+	interaction := scoring.Interaction{
+		VideoID: req.VideoID,
+		UserID:  req.UserID,
+		Type:    req.Type,
+		Weight:  req.Weight,
+		At:      time.Now(),
+	}
+	var state scoring.VideoState
+	if video != nil {
+		state = scoring.VideoState{Exists: true, Score: video.Score, CreatedAt: video.CreatedAt}
+		if video.LastInteractionAt != nil {
+			state.LastInteractionAt = *video.LastInteractionAt
+		}
+	}
+
+	// Count the interaction; the counters feed formula-based strategies.
+	increments, err := scoring.CounterIncrements(interaction)
+	if err != nil {
+		return InteractionOutcome{}, err
+	}
+	// Capped interactions must name their viewer, or omitting it would bypass the cap.
+	if h.viewerCapped(req.Type) && req.ViewerID == "" {
+		return InteractionOutcome{}, ErrMissingViewerID
+	}
+	// Interactions redeem the token of an impression of the video by their viewer. The
+	// token is released if the interaction fails, so that the viewer can retry it.
+	if h.tokens != nil {
+		tokenID, err := h.tokens.Redeem(req.Token, req.ViewerID, req.VideoID)
+		if err != nil {
+			return InteractionOutcome{}, err
+		}
+		outcome, err := h.scoreInteraction(req, video, interaction, state, increments)
+		if err != nil {
+			if err := h.tokens.Release(tokenID); err != nil {
+				slog.Error("Failed to release interaction token", "videoID", req.VideoID, "error", err)
+			}
+		}
+		return outcome, err
+	}
+	return h.scoreInteraction(req, video, interaction, state, increments)
+}
+
+// scoreInteraction counts an interaction accepted by RecordInteraction and updates the
+// score of its video, unless the interaction is capped or quarantined.
+func (h *RankingHandler) scoreInteraction(req models.InteractionRequest, video *models.Video, interaction scoring.Interaction, state scoring.VideoState, increments map[string]float64) (InteractionOutcome, error) {
+	var err error
+	if state.Counters, err = h.redis.IncrementCounters(req.VideoID, increments); err != nil {
+		return InteractionOutcome{}, fmt.Errorf("failed to update counters in Redis: %w", err)
+	}
+
+	update, err := h.scorer.Score(interaction, state)
+	if err != nil {
+		// The interaction is rejected: it must not be counted either.
+		if err := h.withdrawCounters(req.VideoID, increments, nil); err != nil {
+			slog.Error("Failed to withdraw counters of rejected interaction", "videoID", req.VideoID, "error", err)
+		}
+		return InteractionOutcome{}, err
+	}
+	delta := update.Delta
+
+	var reason string
+	var observation models.AnomalyObservation
+	if h.anomalies != nil {
+		reason, observation = h.anomalies.Observe(req.VideoID, req.ViewerID, interaction.At)
+	}
+	if reason != "" {
+		// Hold the interaction in the pending anomaly of the video until it is reviewed.
+		if err := h.withdrawCounters(req.VideoID, increments, nil); err != nil {
+			return InteractionOutcome{}, err
+		}
+		owner := req.UserID
+		if video != nil {
+			owner = video.UserID
+		}
+		err := h.postgres.QuarantineInteraction(models.Anomaly{
+			VideoID:            req.VideoID,
+			UserID:             owner,
+			Reason:             reason,
+			WindowInteractions: observation.Interactions,
+			Baseline:           observation.Baseline,
+			Diversity:          anomaly.Diversity(observation),
+		}, increments, update.Weight)
+		if err != nil {
+			return InteractionOutcome{}, fmt.Errorf("failed to quarantine interaction in PostgreSQL: %w", err)
+		}
+		return InteractionOutcome{Status: InteractionQuarantined, Withheld: update.Weight}, nil
+	}
+
+	// The cap is charged with the weight of the interaction, which excludes the
+	// re-scoring of the previous score. Quarantined interactions are not charged: their
+	// approval applies them regardless of the caps.
+	within, err := h.withinViewerCap(req, update.Weight, interaction.At)
+	if err != nil {
+		return InteractionOutcome{}, err
+	}
+	if !within {
+		// Move the interaction to the capped counters, which the strategies ignore.
+		cappedIncrements := map[string]float64{scoring.CounterCappedInteractions: 1, scoring.CounterCappedScore: update.Weight}
+		if err := h.withdrawCounters(req.VideoID, increments, cappedIncrements); err != nil {
+			return InteractionOutcome{}, err
+		}
+		if err := h.postgres.IncrementVideoStats(req.VideoID, cappedIncrements); err != nil {
+			return InteractionOutcome{}, fmt.Errorf("failed to update stats in PostgreSQL: %w", err)
+		}
+		return InteractionOutcome{Status: InteractionCapped, Withheld: update.Weight}, nil
+	}
+
+	if err := h.applyScoreUpdate(req.VideoID, req.UserID, video, state, update, increments, interaction.At); err != nil {
+		return InteractionOutcome{}, err
+	}
+	return InteractionOutcome{Status: InteractionUpdated, Delta: delta}, nil
+}
+
+// applyScoreUpdate stores the score update of a video in Redis and PostgreSQL along
+// with the counter increments of its interactions, and notifies the live streams,
+// leaderboards and webhooks. Hidden videos are not ranked, so neither the streams
+// nor the webhooks are notified of their scores. The catalog entry of the video is
+// nil for unregistered videos, whose record is created for userID.
+func (h *RankingHandler) applyScoreUpdate(videoID, userID string, video *models.Video, state scoring.VideoState, update scoring.ScoreUpdate, increments map[string]float64, at time.Time) error {
+	// Update the score in Redis.
+	var err error
+	if update.Score != nil {
+		err = h.redis.SetVideoScore(videoID, *update.Score)
+	} else {
+		err = h.redis.UpdateVideoScore(videoID, update.Delta)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update score in Redis: %w", err)
+	}
+	score := state.Score + update.Delta
+	if update.Score != nil {
+		score = *update.Score
+	}
+	h.publishScoreUpdate(video, videoID, score)
+
+	owner := userID
+	if video != nil {
+		owner = video.UserID
+	}
+	// The owner and windowed leaderboards are secondary: failures are logged. Windowed
+	// leaderboards accumulate the weight of the interactions, not the re-scoring of the score.
+	if err := h.redis.UpdateLeaderboards(videoID, owner, update.Weight, h.cfg.LeaderboardWindows, at); err != nil {
+		slog.Error("Failed to update leaderboards", "videoID", videoID, "error", err)
+	}
+
+	// Update (or create) the video record in PostgreSQL using GORM.
+	if update.Score != nil {
+		err = h.postgres.SetVideoScoreInPostgres(videoID, userID, *update.Score)
+	} else {
+		err = h.postgres.UpdateVideoScoreInPostgres(videoID, userID, update.Delta)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update score in PostgreSQL: %w", err)
+	}
+	if len(increments) > 0 {
+		if err := h.postgres.IncrementVideoStats(videoID, increments); err != nil {
+			return fmt.Errorf("failed to update stats in PostgreSQL: %w", err)
+		}
+	}
+
+	if video == nil || video.ModerationStatus == "" || video.ModerationStatus == models.ModerationVisible {
+		h.webhooks.ScoreChanged(videoID, owner, state.Score, score)
+	}
+	return nil
+}
+
+// withdrawCounters reverts the counter increments of an interaction that is not
+// scored, adding the given increments instead.
+func (h *RankingHandler) withdrawCounters(videoID string, increments, instead map[string]float64) error {
+	moved := maps.Clone(instead)
+	if moved == nil {
+		moved = make(map[string]float64, len(increments))
+	}
+	for name, value := range increments {
+		moved[name] = -value
+	}
+	if _, err := h.redis.IncrementCounters(videoID, moved); err != nil {
+		return fmt.Errorf("failed to update counters in Redis: %w", err)
+	}
+	return nil
+}
+
+// MaxInteractionTokens bounds the interaction tokens issued in one response: the
+// videos beyond it are served without token.
+const MaxInteractionTokens = 100
+
+// TokenViewer returns the viewer whom the caller of ctx may be issued interaction
+// tokens for: the subject of its bearer token, whatever viewer it declares, or the
+// declared viewer for an API key, whose service serves viewers on their behalf.
+// Unauthenticated callers get an empty string, and no tokens.
+func TokenViewer(ctx context.Context, viewerID string) string {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	switch {
+	case !ok:
+		return ""
+	case principal.APIKey != nil:
+		return viewerID
+	case principal.Claims != nil:
+		return principal.Claims.Subject
+	}
+	return ""
+}
+
+// InteractionToken issues the token of an impression of a video by a viewer, which
+// the viewer presents to interact with the video. It returns an empty string when
+// tokens are disabled or the viewer is unknown.
+func (h *RankingHandler) InteractionToken(viewerID, videoID string, now time.Time) string {
+	if h.tokens == nil || viewerID == "" {
+		return ""
+	}
+	token, err := h.tokens.Issue(viewerID, videoID, now)
+	if err != nil {
+		slog.Error("Failed to issue interaction token", "videoID", videoID, "error", err)
+		return ""
+	}
+	return token
+}
+
+// viewerCapped reports whether the interactions of a type are capped per viewer.
+func (h *RankingHandler) viewerCapped(interactionType string) bool {
+	_, ok := h.cfg.ViewerCaps[interactionType]
+	return ok && h.cfg.ViewerCapPeriod > 0
+}
+
+// withinViewerCap reports whether the score contribution of an interaction is within
+// the cap of its viewer for the interaction type, adding it to the viewer's total if
+// so. Interactions without cap, and contributions that are not positive, are always
+// within.
+func (h *RankingHandler) withinViewerCap(req models.InteractionRequest, contribution float64, at time.Time) (bool, error) {
+	if !h.viewerCapped(req.Type) || contribution <= 0 {
+		return true, nil
+	}
+	limit := h.cfg.ViewerCaps[req.Type]
+	within, err := h.redis.ConsumeViewerCap(req.VideoID, req.ViewerID, req.Type, contribution, limit, h.cfg.ViewerCapPeriod, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to check viewer cap in Redis: %w", err)
+	}
+	return within, nil
+}
+
+// maxBatchInteractions bounds the size of a batch of interactions.
+const maxBatchInteractions = 1000
+
+// RecordInteractionsHandler records a batch of interactions.
+//
+//	@Summary		Record a batch of interactions
+//	@Description	Update the scores of videos with up to 1000 interactions at once, in order. Invalid interactions, and interactions beyond the rate limits of the batch route, are rejected and reported without failing the batch.
+//	@Tags			Videos
+//	@Accept			json
+//	@Produce		json
+//	@Param			interactions	body		models.BatchInteractionRequest	true	"Batch of interactions"
+//	@Success		200				{object}	models.BatchInteractionResponse
+//	@Failure		400				{object}	map[string]interface{}
+//	@Failure		429				{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/interactions/batch [post]
+func (h *RankingHandler) RecordInteractionsHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.BatchInteractionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if len(req.Interactions) > maxBatchInteractions {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A batch holds at most 1000 interactions"})
+			return
+		}
+
+		// Each interaction is charged against the rate limits of the batch route.
+		limited := ratelimit.Request{IP: c.ClientIP()}
+		if principal, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+			limited.Client = principal.ClientID()
+		}
+		resp := models.BatchInteractionResponse{Rejected: []models.RejectedInteraction{}}
+		for i, interaction := range req.Interactions {
+			limited.Viewer, limited.Video = interaction.ViewerID, interaction.VideoID
+			if decision, key := h.LimitInteraction(ratelimit.RouteBatch, limited); !decision.Allowed {
+				ratelimit.SetHeaders(c.Writer.Header(), decision)
+				resp.Rejected = append(resp.Rejected, models.RejectedInteraction{Index: i, VideoID: interaction.VideoID, Error: "Rate limit of the " + key + " exceeded"})
+				continue
+			}
+			_, err := h.RecordInteraction(interaction)
+			if err == nil {
+				resp.Accepted++
+				continue
+			}
+			if reason := rejectionReason(err); reason != "" {
+				resp.Rejected = append(resp.Rejected, models.RejectedInteraction{Index: i, VideoID: interaction.VideoID, Error: reason})
+				continue
+			}
+			slog.Error("RecordInteractionsHandler: Failed to record interaction", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update video score", "accepted": resp.Accepted})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// LimitInteraction charges an interaction of a batch or stream against the rate
+// limits of its route. It returns the decision for the most constrained rule and that
+// rule's key, allowing every interaction when rate limiting is disabled.
+func (h *RankingHandler) LimitInteraction(route string, req ratelimit.Request) (models.RateLimitDecision, string) {
+	if h.limiter == nil {
+		return models.RateLimitDecision{Allowed: true}, ""
+	}
+	return h.limiter.Check(route, req)
+}
+
+// rejectionReason describes why RecordInteraction rejected an interaction, or returns
+// an empty string for failures that are not the interaction's fault.
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, ErrMissingVideoID):
+		return "Missing videoID"
+	case errors.Is(err, ErrMissingUserID):
+		return "Missing userID in payload"
+	case errors.Is(err, ErrVideoNotRegistered):
+		return "Video is not registered"
+	case errors.Is(err, ErrVideoDeleted):
+		return "Video has been deleted"
+	case errors.Is(err, ErrMissingViewerID):
+		return "Missing viewer_id, required by the viewer score caps"
+	case errors.Is(err, impression.ErrMissingToken):
+		return "Missing interaction token"
+	case errors.Is(err, impression.ErrInvalidToken):
+		return "Invalid interaction token"
+	case errors.Is(err, impression.ErrTokenUsed):
+		return "Interaction token already used"
+	case errors.Is(err, scoring.ErrUnknownInteraction):
+		return "Unknown interaction type"
+	}
+	return ""
+}
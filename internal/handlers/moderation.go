@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// SetModerationStatusHandler changes the moderation status of a video.
+//
+//	@Summary		Moderate a video
+//	@Description	Hide, ban or quarantine a video (or make it visible again). Moderated videos are removed from every leaderboard while their score keeps accumulating.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			video_id	path		string						true	"Video ID"
+//	@Param			moderation	body		models.ModerationRequest	true	"Moderation payload"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/videos/{video_id}/moderation [put]
+func (h *RankingHandler) SetModerationStatusHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+
+		var req models.ModerationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if !models.IsValidModerationStatus(req.Status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown moderation status"})
+			return
+		}
+
+		if err := h.postgres.SetModerationStatus(videoID, req.Status, req.Reason); err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("SetModerationStatusHandler: Failed to update moderation status", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update moderation status"})
+			return
+		}
+
+		if err := h.redis.SetVideoHidden(videoID, req.Status != models.ModerationVisible); err != nil {
+			slog.Error("SetModerationStatusHandler: Failed to update Redis hidden set", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rankings"})
+			return
+		}
+
+		slog.Info("Video moderated", "videoID", videoID, "status", req.Status, "reason", req.Reason)
+		h.publishRankingUpdate(models.RankingUpdate{})
+
+		c.JSON(http.StatusOK, gin.H{
+			"videoID": videoID,
+			"status":  req.Status,
+		})
+	}
+}
+
+// GetModerationHistoryHandler retrieves the moderation decisions of a video.
+//
+//	@Summary		Get moderation history
+//	@Description	Get the current moderation status of a video and the audit trail of decisions.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/videos/{video_id}/moderation [get]
+func (h *RankingHandler) GetModerationHistoryHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+
+		video, err := h.postgres.GetVideo(videoID)
+		if err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("GetModerationHistoryHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+
+		events, err := h.postgres.GetModerationHistory(videoID)
+		if err != nil {
+			slog.Error("GetModerationHistoryHandler: Failed to fetch moderation history", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"videoID": videoID,
+			"status":  video.ModerationStatus,
+			"score":   video.Score,
+			"history": events,
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/history"
+	"ranking-service/internal/repository"
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+// snapshotTakenAtHeader tells clients which snapshot served a historical query.
+const snapshotTakenAtHeader = "X-Snapshot-Taken-At"
+
+// parseAt parses the at query parameter of the top endpoints.
+// It returns the zero time when the parameter is absent.
+func parseAt(c *gin.Context) (time.Time, error) {
+	v := c.Query("at")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// getGlobalSnapshot retrieves the global leaderboard snapshot nearest to at,
+// at most one snapshot interval away.
+func (h *RankingHandler) getGlobalSnapshot(at time.Time) (*models.LeaderboardSnapshot, error) {
+	return h.postgres.GetNearestSnapshot(models.SnapshotRankingGlobal, at, h.cfg.SnapshotInterval)
+}
+
+// getGlobalTopVideosAt rebuilds a page of the global ranking as it was at the given
+// time: the snapshot is diversified and re-scored for freshness as of that time, then
+// the editorial rules active at that time are applied. Exploration slots are random
+// and not replayed. It also returns the snapshot.
+func (h *RankingHandler) getGlobalTopVideosAt(limit, maxPerOwner, maxPerCategory int, at time.Time) ([]models.RankedVideo, *models.LeaderboardSnapshot, error) {
+	snapshot, err := h.getGlobalSnapshot(at)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var videos []models.RankedVideo
+	if maxPerOwner > 0 || maxPerCategory > 0 {
+		d := rerank.NewDiversifier(len(snapshot.Entries), maxPerOwner, maxPerCategory)
+		for _, e := range snapshot.Entries {
+			d.Offer(models.RankedVideo{VideoID: e.VideoID, Score: e.Score}, e.UserID, e.Category)
+		}
+		videos = d.Videos()
+	} else {
+		videos = make([]models.RankedVideo, len(snapshot.Entries))
+		for i, e := range snapshot.Entries {
+			videos[i] = models.RankedVideo{VideoID: e.VideoID, Score: e.Score}
+		}
+	}
+
+	videos = h.applyFreshness(videos, len(videos), at)
+	return h.applySnapshotEditorial(videos, limit, at), snapshot, nil
+}
+
+// getUserTopVideosAt rebuilds the top videos of a user as they were at the given time,
+// from the user's videos in the global snapshot. It also returns the time of the snapshot.
+func (h *RankingHandler) getUserTopVideosAt(userID string, limit int, at time.Time) ([]models.UserTopVideo, time.Time, error) {
+	snapshot, err := h.getGlobalSnapshot(at)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var ranked []models.RankedVideo
+	entries := make(map[string]models.SnapshotEntry)
+	for _, e := range snapshot.Entries {
+		if e.UserID == userID {
+			ranked = append(ranked, models.RankedVideo{VideoID: e.VideoID, Score: e.Score})
+			entries[e.VideoID] = e
+		}
+	}
+	ranked = h.applySnapshotEditorial(ranked, limit, at)
+
+	ids := make([]string, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.VideoID
+	}
+	catalog, err := h.postgres.GetVideos(ids)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	byID := make(map[string]models.Video, len(catalog))
+	for _, v := range catalog {
+		byID[v.VideoID] = v
+	}
+
+	result := make([]models.UserTopVideo, len(ranked))
+	for i, r := range ranked {
+		// Videos deleted since the snapshot only keep what the snapshot recorded.
+		video, ok := byID[r.VideoID]
+		if !ok {
+			e := entries[r.VideoID]
+			video = models.Video{VideoID: e.VideoID, UserID: e.UserID, Category: e.Category}
+		}
+		video.Score = entries[r.VideoID].Score
+		result[i] = models.UserTopVideo{Video: video, Editorial: r.Editorial}
+	}
+	return result, snapshot.TakenAt, nil
+}
+
+// applySnapshotEditorial applies the editorial rules active at the given time to a
+// snapshot ranking. Videos outside the snapshot have no historical score and are ignored.
+func (h *RankingHandler) applySnapshotEditorial(videos []models.RankedVideo, limit int, at time.Time) []models.RankedVideo {
+	rules, err := h.postgres.GetActiveEditorialRules(at)
+	if err != nil {
+		slog.Error("Failed to fetch editorial rules", "error", err)
+		rules = nil
+	}
+	return rerank.ApplyEditorial(videos, rules, nil, limit)
+}
+
+// applyMovement sets the movement of each video in the organic ranking since the
+// snapshot taken one movement window before at. The organic ranks of the videos are
+// those of the snapshot serving the page, or the live ranks without one. Movements are
+// best effort: without a snapshot or the ranks they are left out.
+func (h *RankingHandler) applyMovement(videos []models.RankedVideo, snapshot *models.LeaderboardSnapshot, at time.Time) []models.RankedVideo {
+	if h.cfg.MovementWindow <= 0 {
+		return videos
+	}
+	previous, err := h.postgres.GetNearestSnapshot(models.SnapshotRankingGlobal, at.Add(-h.cfg.MovementWindow), h.cfg.SnapshotInterval)
+	if err != nil {
+		if !errors.Is(err, repository.ErrSnapshotNotFound) {
+			slog.Error("Failed to fetch snapshot for rank movements", "error", err)
+		}
+		return videos
+	}
+
+	var current map[string]int64
+	if snapshot != nil {
+		current = make(map[string]int64, len(snapshot.Entries))
+		for _, e := range snapshot.Entries {
+			current[e.VideoID] = int64(e.Rank)
+		}
+	} else {
+		ids := make([]string, len(videos))
+		for i, v := range videos {
+			ids[i] = v.VideoID
+		}
+		if current, err = h.redis.GetVideoRanks(ids); err != nil {
+			slog.Error("Failed to fetch ranks for rank movements", "error", err)
+			return videos
+		}
+	}
+	return history.Annotate(videos, current, previous.Entries)
+}
+
+// DiffSnapshotsHandler compares the top of the global leaderboard between two snapshots.
+//
+//	@Summary		Compare leaderboard snapshots
+//	@Description	Compare the top of the global leaderboard between the snapshots nearest to two times. Each video of the newer top gets its previous rank and movement; videos that left the top are listed as dropped.
+//	@Tags			Videos
+//	@Produce		json
+//	@Param			from	query		string	false	"Older time, RFC 3339 (default: one movement window before to)"
+//	@Param			to		query		string	false	"Newer time, RFC 3339 (default: now)"
+//	@Param			limit	query		int		false	"Size of the compared top (default: 10)"
+//	@Success		200		{object}	models.SnapshotDiff
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/top/diff [get]
+func (h *RankingHandler) DiffSnapshotsHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		limit := 10
+		if l := c.Query("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		to := time.Now()
+		if v := c.Query("to"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, expected an RFC 3339 time"})
+				return
+			}
+			to = parsed
+		}
+		window := h.cfg.MovementWindow
+		if window <= 0 {
+			window = 24 * time.Hour
+		}
+		from := to.Add(-window)
+		if v := c.Query("from"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, expected an RFC 3339 time"})
+				return
+			}
+			from = parsed
+		}
+		if !from.Before(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+			return
+		}
+
+		var snapshots [2]*models.LeaderboardSnapshot
+		for i, at := range []time.Time{from, to} {
+			snapshot, err := h.postgres.GetNearestSnapshot(models.SnapshotRankingGlobal, at, h.cfg.SnapshotInterval)
+			if errors.Is(err, repository.ErrSnapshotNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "No leaderboard snapshot near " + at.Format(time.RFC3339)})
+				return
+			}
+			if err != nil {
+				slog.Error("DiffSnapshotsHandler: Failed to fetch snapshot", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard snapshots"})
+				return
+			}
+			snapshots[i] = snapshot
+		}
+
+		c.JSON(http.StatusOK, history.Diff(snapshots[0], snapshots[1], limit))
+	}
+}
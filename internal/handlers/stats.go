@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// countCounters are the counters reported as interaction counts.
+var countCounters = []string{
+	scoring.CounterViews,
+	scoring.CounterLikes,
+	scoring.CounterComments,
+	scoring.CounterShares,
+	scoring.CounterReports,
+}
+
+// GetVideoStatsHandler retrieves the interaction counters and score of a video.
+//
+//	@Summary		Retrieve video statistics
+//	@Description	Get the number of interactions per type, the total watch time and the score of a video.
+//	@Tags			Videos
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Success		200			{object}	models.VideoStatsResponse
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id}/stats [get]
+func (h *RankingHandler) GetVideoStatsHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+
+		video, err := h.postgres.GetVideo(videoID)
+		if err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("GetVideoStatsHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+
+		counters, err := h.getCounters(videoID)
+		if err != nil {
+			slog.Error("GetVideoStatsHandler: Failed to fetch counters", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video statistics"})
+			return
+		}
+
+		counts := make(map[string]int64, len(countCounters))
+		for _, name := range countCounters {
+			counts[name] = int64(counters[name])
+		}
+		c.JSON(http.StatusOK, models.VideoStatsResponse{
+			VideoID:        videoID,
+			Counts:         counts,
+			TotalWatchTime: counters[scoring.CounterWatchTime],
+			Score:          video.Score,
+
+			CappedInteractions: int64(counters[scoring.CounterCappedInteractions]),
+			CappedScore:        counters[scoring.CounterCappedScore],
+		})
+	}
+}
+
+// getCounters retrieves the counters of a video from Redis, falling back to
+// PostgreSQL when Redis has none (e.g. after a Redis flush).
+func (h *RankingHandler) getCounters(videoID string) (map[string]float64, error) {
+	counters, err := h.redis.GetCounters(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(counters) > 0 {
+		return counters, nil
+	}
+
+	stats, err := h.postgres.GetVideoStats(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]float64{
+		scoring.CounterViews:     float64(stats.Views),
+		scoring.CounterLikes:     float64(stats.Likes),
+		scoring.CounterComments:  float64(stats.Comments),
+		scoring.CounterShares:    float64(stats.Shares),
+		scoring.CounterReports:   float64(stats.Reports),
+		scoring.CounterWatchTime: stats.WatchTime,
+
+		scoring.CounterCappedInteractions: float64(stats.CappedInteractions),
+		scoring.CounterCappedScore:        stats.CappedScore,
+	}, nil
+}
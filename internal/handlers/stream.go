@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"ranking-service/models"
+)
+
+// streamWriteTimeout bounds how long a WebSocket write may block.
+const streamWriteTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// RunLiveUpdates feeds the ranking updates of every replica to the live leaderboard
+// streams until the context is done.
+func (h *RankingHandler) RunLiveUpdates(ctx context.Context) error {
+	updates, err := h.redis.SubscribeRankingUpdates(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for update := range updates {
+			h.stream.Notify(update)
+		}
+	}()
+	h.stream.Run(ctx)
+	return nil
+}
+
+// publishScoreUpdate notifies the replicas that the score of a video changed to the
+// score already computed by the caller, sparing a Redis lookup per interaction.
+// Hidden videos are not ranked, so their updates are not published.
+func (h *RankingHandler) publishScoreUpdate(video *models.Video, videoID string, score float64) {
+	if video != nil && video.ModerationStatus != "" && video.ModerationStatus != models.ModerationVisible {
+		return
+	}
+	h.publishRankingUpdate(models.RankingUpdate{VideoID: videoID, Score: score})
+}
+
+// publishRankingUpdate notifies the replicas that a ranking changed. Live streams
+// are best effort: failures are logged and do not fail the request.
+func (h *RankingHandler) publishRankingUpdate(update models.RankingUpdate) {
+	if err := h.redis.PublishRankingUpdate(update); err != nil {
+		slog.Error("Failed to publish ranking update", "error", err)
+	}
+}
+
+// streamLimit parses the limit of a stream request, between 1 and StreamMaxLimit.
+func (h *RankingHandler) streamLimit(c *gin.Context) (int, bool) {
+	maxLimit := h.cfg.StreamMaxLimit
+	if maxLimit <= 0 {
+		maxLimit = 100
+	}
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			return 0, false
+		}
+		limit = parsed
+	}
+	return limit, true
+}
+
+// keepAlive returns the interval of keep-alive messages on idle streams.
+func (h *RankingHandler) keepAlive() time.Duration {
+	if h.cfg.StreamKeepAlive <= 0 {
+		return 15 * time.Second
+	}
+	return h.cfg.StreamKeepAlive
+}
+
+// StreamTopVideosHandler streams the changes of the global top over Server-Sent Events.
+//
+//	@Summary		Stream global top videos
+//	@Description	Stream the global top over Server-Sent Events. The first event is a snapshot of the top, then an update event lists the entries, exits and reorderings along with the full top whenever it changes. Updates are coalesced and pushed at most once per coalesce interval.
+//	@Tags			Videos
+//	@Produce		text/event-stream
+//	@Param			limit	query		int	false	"Number of videos in the top (default: 10)"
+//	@Success		200		{object}	models.LeaderboardEvent
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/top/stream [get]
+func (h *RankingHandler) StreamTopVideosHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		limit, ok := h.streamLimit(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+
+		sub, err := h.stream.Subscribe(limit)
+		if err != nil {
+			slog.Error("StreamTopVideosHandler: Failed to subscribe", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching top videos"})
+			return
+		}
+		defer h.stream.Unsubscribe(sub)
+
+		keepAlive := time.NewTicker(h.keepAlive())
+		defer keepAlive.Stop()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event, ok := <-sub.Events():
+				if !ok {
+					return false
+				}
+				c.SSEvent(event.Type, event)
+				return true
+			case <-keepAlive.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			}
+		})
+	}
+}
+
+// StreamTopVideosWebSocketHandler streams the changes of the global top over a WebSocket.
+//
+//	@Summary		Stream global top videos over WebSocket
+//	@Description	Same events as the Server-Sent Events stream, sent as JSON text messages over a WebSocket.
+//	@Tags			Videos
+//	@Param			limit	query		int	false	"Number of videos in the top (default: 10)"
+//	@Success		101		{object}	models.LeaderboardEvent
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/top/ws [get]
+func (h *RankingHandler) StreamTopVideosWebSocketHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		limit, ok := h.streamLimit(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader already replied.
+			return
+		}
+		defer conn.Close()
+
+		sub, err := h.stream.Subscribe(limit)
+		if err != nil {
+			slog.Error("StreamTopVideosWebSocketHandler: Failed to subscribe", "error", err)
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Error fetching top videos"),
+				time.Now().Add(streamWriteTimeout))
+			return
+		}
+		defer h.stream.Unsubscribe(sub)
+
+		// Clients only send control frames; reading processes them and detects closes.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(h.keepAlive())
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+						time.Now().Add(streamWriteTimeout))
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+// ErrVideoNotRanked is returned by VideoRank for videos missing from the global ranking.
+var ErrVideoNotRanked = errors.New("video is not ranked")
+
+// TopVideos builds a page of the global ranking, annotated with the rank movements.
+// The zero time serves the live ranking; otherwise the ranking is rebuilt from the
+// snapshot nearest to at, whose time is returned. Shared by the REST and gRPC APIs.
+func (h *RankingHandler) TopVideos(limit, maxPerOwner, maxPerCategory int, at time.Time) ([]models.RankedVideo, time.Time, error) {
+	if !at.IsZero() {
+		videos, snapshot, err := h.getGlobalTopVideosAt(limit, maxPerOwner, maxPerCategory, at)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return h.applyMovement(videos, snapshot, at), snapshot.TakenAt, nil
+	}
+
+	videos, err := h.buildGlobalTopVideos(limit, maxPerOwner, maxPerCategory, true)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return h.applyMovement(videos, nil, time.Now()), time.Time{}, nil
+}
+
+// UserTopVideos retrieves the top videos of a user. The zero time serves the live
+// ranking; otherwise the user's videos are taken from the global snapshot nearest to
+// at, whose time is returned. Shared by the REST and gRPC APIs.
+func (h *RankingHandler) UserTopVideos(userID string, limit int, at time.Time) ([]models.UserTopVideo, time.Time, error) {
+	if !at.IsZero() {
+		return h.getUserTopVideosAt(userID, limit, at)
+	}
+
+	videos, err := h.postgres.GetUserTopVideosFromDB(userID, limit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return h.applyUserEditorial(userID, videos, limit), time.Time{}, nil
+}
+
+// VideoRank retrieves the rank of a visible video in the global ranking.
+func (h *RankingHandler) VideoRank(videoID string) (*models.RankInfo, error) {
+	rank, err := h.redis.GetVideoRank(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if rank.Rank == 0 {
+		return nil, ErrVideoNotRanked
+	}
+	return rank, nil
+}
+
+// getStreamTopVideos builds the global ranking pushed to live streams. Exploration
+// slots are left out: they are random and would churn every update.
+func (h *RankingHandler) getStreamTopVideos(limit int) ([]models.RankedVideo, error) {
+	return h.buildGlobalTopVideos(limit, h.cfg.DiversityMaxPerOwner, h.cfg.DiversityMaxPerCategory, false)
+}
+
+// buildGlobalTopVideos builds a page of the global ranking: the organic Redis ranking,
+// optionally diversified, re-scored for freshness, with exploration slots, and
+// finally the editorial pins and boosts.
+func (h *RankingHandler) buildGlobalTopVideos(limit, maxPerOwner, maxPerCategory int, explore bool) ([]models.RankedVideo, error) {
+	// Freshness re-scores a deeper window so that new videos can move up.
+	candidates := limit
+	if h.cfg.FreshnessWeight > 0 && h.cfg.FreshnessCandidates > candidates {
+		candidates = h.cfg.FreshnessCandidates
+	}
+
+	var (
+		videos []models.RankedVideo
+		err    error
+	)
+	if maxPerOwner > 0 || maxPerCategory > 0 {
+		videos, err = h.getDiverseTopVideos(candidates, maxPerOwner, maxPerCategory)
+	} else {
+		videos, err = h.redis.GetTopVideos(candidates)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	videos = h.applyFreshness(videos, limit, time.Now())
+	if explore {
+		videos = h.applyExploration(videos)
+	}
+	return h.applyGlobalEditorial(videos, limit), nil
+}
+
+// applyFreshness boosts the videos created shortly before now and truncates the ranking
+// to limit. Like editorial rules it is best effort: on failure the organic ranking is served.
+func (h *RankingHandler) applyFreshness(videos []models.RankedVideo, limit int, now time.Time) []models.RankedVideo {
+	if h.cfg.FreshnessWeight > 0 {
+		ids := make([]string, len(videos))
+		for i, v := range videos {
+			ids[i] = v.VideoID
+		}
+		catalog, err := h.postgres.GetVideos(ids)
+		if err == nil {
+			createdAt := make(map[string]time.Time, len(catalog))
+			for _, v := range catalog {
+				createdAt[v.VideoID] = v.CreatedAt
+			}
+			return rerank.ApplyFreshness(videos, createdAt, now, h.cfg.FreshnessWeight, h.cfg.FreshnessHalfLife, limit)
+		}
+		slog.Error("Failed to fetch videos for freshness boost", "error", err)
+	}
+
+	if len(videos) > limit {
+		return videos[:limit]
+	}
+	return videos
+}
+
+// applyExploration gives a fraction of the slots to recently uploaded, low-data videos.
+// Every exploration choice is logged so that its outcome can be measured.
+func (h *RankingHandler) applyExploration(videos []models.RankedVideo) []models.RankedVideo {
+	if h.cfg.ExplorationRate <= 0 || len(videos) == 0 {
+		return videos
+	}
+
+	pool, err := h.postgres.GetExplorationCandidates(time.Now().Add(-h.cfg.ExplorationMaxAge), h.cfg.ExplorationMaxScore, h.cfg.ExplorationPoolSize)
+	if err != nil {
+		slog.Error("Failed to fetch exploration candidates", "error", err)
+		return videos
+	}
+	candidates := make([]models.RankedVideo, len(pool))
+	for i, v := range pool {
+		candidates[i] = models.RankedVideo{VideoID: v.VideoID, Score: v.Score}
+	}
+
+	page, slots := rerank.Explore(videos, candidates, h.cfg.ExplorationRate, nil)
+	for _, slot := range slots {
+		slog.Info("Exploration slot",
+			"videoID", page[slot].VideoID,
+			"position", slot+1,
+			"strategy", "epsilon_greedy",
+			"epsilon", h.cfg.ExplorationRate,
+			"poolSize", len(candidates))
+	}
+	return page
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// CreateVideoHandler registers a video in the catalog.
+//
+//	@Summary		Register a video
+//	@Description	Register a video in the catalog with its owner and metadata.
+//	@Tags			Catalog
+//	@Accept			json
+//	@Produce		json
+//	@Param			video	body		models.CreateVideoRequest	true	"Video payload"
+//	@Success		201		{object}	models.Video
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos [post]
+func (h *RankingHandler) CreateVideoHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.CreateVideoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if req.VideoID == "" || req.UserID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing video_id or user_id in payload"})
+			return
+		}
+
+		video := models.Video{
+			VideoID:  req.VideoID,
+			UserID:   req.UserID,
+			Title:    req.Title,
+			Category: req.Category,
+			Status:   models.VideoStatusActive,
+		}
+		if req.CreatedAt != nil {
+			video.CreatedAt = *req.CreatedAt
+		} else {
+			video.CreatedAt = time.Now()
+		}
+
+		if err := h.postgres.CreateVideo(&video); err != nil {
+			if errors.Is(err, repository.ErrVideoExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Video already exists"})
+				return
+			}
+			slog.Error("CreateVideoHandler: Failed to create video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create video"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, video)
+	}
+}
+
+// GetVideoHandler retrieves a video from the catalog.
+//
+//	@Summary		Get a video
+//	@Description	Get the catalog entry of a video, including soft-deleted ones.
+//	@Tags			Catalog
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Success		200			{object}	models.Video
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id} [get]
+func (h *RankingHandler) GetVideoHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		video, err := h.postgres.GetVideo(c.Param("video_id"))
+		if err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("GetVideoHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+		c.JSON(http.StatusOK, video)
+	}
+}
+
+// UpdateVideoHandler updates the catalog metadata of a video.
+//
+//	@Summary		Update a video
+//	@Description	Update the title, category or owner of a video.
+//	@Tags			Catalog
+//	@Accept			json
+//	@Produce		json
+//	@Param			video_id	path		string						true	"Video ID"
+//	@Param			video		body		models.UpdateVideoRequest	true	"Fields to update"
+//	@Success		200			{object}	models.Video
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		410			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id} [patch]
+func (h *RankingHandler) UpdateVideoHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.UpdateVideoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+
+		video, err := h.postgres.GetVideo(c.Param("video_id"))
+		if err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("UpdateVideoHandler: Failed to fetch video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+			return
+		}
+		if video.Status == models.VideoStatusDeleted {
+			c.JSON(http.StatusGone, gin.H{"error": "Video has been deleted"})
+			return
+		}
+
+		if req.Title != nil {
+			video.Title = *req.Title
+		}
+		if req.Category != nil {
+			video.Category = *req.Category
+		}
+		previousOwner := video.UserID
+		if req.UserID != nil {
+			if *req.UserID == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "user_id cannot be empty"})
+				return
+			}
+			video.UserID = *req.UserID
+		}
+
+		if err := h.postgres.UpdateVideo(video); err != nil {
+			slog.Error("UpdateVideoHandler: Failed to update video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update video"})
+			return
+		}
+		if video.UserID != previousOwner {
+			if err := h.redis.SetVideoOwner(video.VideoID, previousOwner, video.UserID); err != nil {
+				slog.Error("UpdateVideoHandler: Failed to move video to its owner's leaderboard", "error", err)
+			}
+		}
+		c.JSON(http.StatusOK, video)
+	}
+}
+
+// DeleteVideoHandler deletes a video and removes it from every ranking.
+//
+//	@Summary		Delete a video
+//	@Description	Soft-delete a video (or hard-delete it with hard=true) and remove it from every ranking.
+//	@Tags			Catalog
+//	@Produce		json
+//	@Param			video_id	path		string	true	"Video ID"
+//	@Param			hard		query		bool	false	"Remove the record instead of soft-deleting it"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/videos/{video_id} [delete]
+func (h *RankingHandler) DeleteVideoHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		videoID := c.Param("video_id")
+		hard := c.Query("hard") == "true"
+
+		if err := h.postgres.DeleteVideo(videoID, hard); err != nil {
+			if errors.Is(err, repository.ErrVideoNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			slog.Error("DeleteVideoHandler: Failed to delete video", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete video"})
+			return
+		}
+
+		if err := h.redis.RemoveVideo(videoID); err != nil {
+			slog.Error("DeleteVideoHandler: Failed to remove video from Redis", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove video from rankings"})
+			return
+		}
+		h.publishRankingUpdate(models.RankingUpdate{VideoID: videoID})
+
+		c.JSON(http.StatusOK, gin.H{
+			"videoID": videoID,
+			"hard":    hard,
+			"status":  models.VideoStatusDeleted,
+		})
+	}
+}
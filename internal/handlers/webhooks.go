@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/repository"
+	"ranking-service/internal/webhook"
+	"ranking-service/models"
+)
+
+const (
+	defaultWebhookTopN = 10
+	maxWebhookTopN     = 1000
+	// maxWebhookDeliveries bounds the size of a delivery log response.
+	maxWebhookDeliveries = 500
+)
+
+// RunWebhooks sends the webhook deliveries until the context is done.
+func (h *RankingHandler) RunWebhooks(ctx context.Context) {
+	h.webhooks.Run(ctx)
+}
+
+// CreateWebhookHandler creates a webhook subscription.
+//
+//	@Summary		Create a webhook subscription
+//	@Description	Subscribe a URL to ranking events: top_entered when a video enters the top N of the global leaderboard, or score_threshold when a video's score reaches a threshold. Both can be restricted to a creator's videos. Deliveries are signed with HMAC-SHA256 in the X-Webhook-Signature header; the secret is only returned here.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body		models.WebhookSubscriptionRequest	true	"Webhook subscription payload"
+//	@Success		201		{object}	models.CreatedWebhookSubscription
+//	@Failure		400		{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/webhooks [post]
+func (h *RankingHandler) CreateWebhookHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req models.WebhookSubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+			return
+		}
+		if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an absolute http or https URL"})
+			return
+		}
+
+		subscription := models.WebhookSubscription{
+			URL:    req.URL,
+			Event:  req.Event,
+			UserID: req.UserID,
+			Secret: req.Secret,
+		}
+		switch req.Event {
+		case models.WebhookEventTopEntered:
+			subscription.TopN = defaultWebhookTopN
+			if req.TopN != 0 {
+				subscription.TopN = req.TopN
+			}
+			if subscription.TopN < 1 || subscription.TopN > maxWebhookTopN {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "top_n must be between 1 and 1000"})
+				return
+			}
+		case models.WebhookEventScoreThreshold:
+			if req.Threshold == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "score_threshold subscriptions require a threshold"})
+				return
+			}
+			subscription.Threshold = *req.Threshold
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown webhook event"})
+			return
+		}
+
+		if subscription.Secret == "" {
+			secret, err := webhook.NewSecret()
+			if err != nil {
+				slog.Error("CreateWebhookHandler: Failed to generate secret", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook subscription"})
+				return
+			}
+			subscription.Secret = secret
+		}
+
+		if err := h.postgres.CreateWebhookSubscription(&subscription); err != nil {
+			slog.Error("CreateWebhookHandler: Failed to create webhook subscription", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook subscription"})
+			return
+		}
+		if err := h.webhooks.Reload(); err != nil {
+			slog.Error("Failed to reload webhook subscriptions", "error", err)
+		}
+		c.JSON(http.StatusCreated, models.CreatedWebhookSubscription{WebhookSubscription: subscription, Secret: subscription.Secret})
+	}
+}
+
+// ListWebhooksHandler lists the webhook subscriptions.
+//
+//	@Summary		List webhook subscriptions
+//	@Description	List the webhook subscriptions, without their secrets.
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{array}	models.WebhookSubscription
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/webhooks [get]
+func (h *RankingHandler) ListWebhooksHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		subscriptions, err := h.postgres.ListWebhookSubscriptions()
+		if err != nil {
+			slog.Error("ListWebhooksHandler: Failed to fetch webhook subscriptions", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook subscriptions"})
+			return
+		}
+		c.JSON(http.StatusOK, subscriptions)
+	}
+}
+
+// DeleteWebhookHandler removes a webhook subscription.
+//
+//	@Summary		Delete a webhook subscription
+//	@Description	Remove a webhook subscription. Its delivery log is kept.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Webhook subscription ID"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/webhooks/{id} [delete]
+func (h *RankingHandler) DeleteWebhookHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook subscription ID"})
+			return
+		}
+
+		if err := h.postgres.DeleteWebhookSubscription(uint(id)); err != nil {
+			if errors.Is(err, repository.ErrWebhookNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook subscription not found"})
+				return
+			}
+			slog.Error("DeleteWebhookHandler: Failed to delete webhook subscription", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook subscription"})
+			return
+		}
+		if err := h.webhooks.Reload(); err != nil {
+			slog.Error("Failed to reload webhook subscriptions", "error", err)
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "status": "deleted"})
+	}
+}
+
+// ListWebhookDeliveriesHandler lists the webhook deliveries.
+//
+//	@Summary		List webhook deliveries
+//	@Description	List the latest webhook deliveries, newest first, with the outcome of their last attempt. Filter on status=dead for the dead-letter list.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			subscription_id	query		int		false	"Only list the deliveries of this subscription"
+//	@Param			status			query		string	false	"pending, delivered or dead"
+//	@Param			limit			query		int		false	"Number of deliveries to retrieve (default: 100)"
+//	@Success		200				{array}		models.WebhookDelivery
+//	@Failure		400				{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/webhooks/deliveries [get]
+func (h *RankingHandler) ListWebhookDeliveriesHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var subscriptionID uint64
+		if v := c.Query("subscription_id"); v != "" {
+			parsed, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription_id"})
+				return
+			}
+			subscriptionID = parsed
+		}
+		status := c.Query("status")
+		switch status {
+		case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown delivery status"})
+			return
+		}
+		limit := 100
+		if l := c.Query("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				limit = min(parsed, maxWebhookDeliveries)
+			}
+		}
+
+		deliveries, err := h.postgres.ListWebhookDeliveries(uint(subscriptionID), status, limit)
+		if err != nil {
+			slog.Error("ListWebhookDeliveriesHandler: Failed to fetch webhook deliveries", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+// RetryWebhookDeliveryHandler requeues a dead-lettered delivery.
+//
+//	@Summary		Retry a webhook delivery
+//	@Description	Requeue a dead-lettered delivery for immediate delivery, with a fresh set of attempts.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		int	true	"Webhook delivery ID"
+//	@Success		200	{object}	models.WebhookDelivery
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Security		Bearer || ApiKey
+//	@Router			/admin/webhooks/deliveries/{id}/retry [post]
+func (h *RankingHandler) RetryWebhookDeliveryHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook delivery ID"})
+			return
+		}
+
+		delivery, err := h.postgres.GetWebhookDelivery(uint(id))
+		if err != nil {
+			if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+				return
+			}
+			slog.Error("RetryWebhookDeliveryHandler: Failed to fetch webhook delivery", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook delivery"})
+			return
+		}
+		if delivery.Status != models.WebhookDeliveryDead {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only dead deliveries can be retried"})
+			return
+		}
+
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		if err := h.postgres.UpdateWebhookDelivery(delivery); err != nil {
+			slog.Error("RetryWebhookDeliveryHandler: Failed to requeue webhook delivery", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue webhook delivery"})
+			return
+		}
+		c.JSON(http.StatusOK, delivery)
+	}
+}
+
+// ReloadWebhooks refreshes the webhook subscriptions checked on score updates. The
+// server reloads them periodically; one-off users of the handler call it once.
+func (h *RankingHandler) ReloadWebhooks() error {
+	return h.webhooks.Reload()
+}
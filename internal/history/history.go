@@ -0,0 +1,42 @@
+// Package history turns score samples into time series.
+package history
+
+import (
+	"time"
+
+	"ranking-service/models"
+)
+
+// Bucket aggregates samples into buckets of the given resolution covering [from, to).
+// Samples must be sorted oldest first; samples before from only seed the score
+// carried forward into the first buckets.
+func Bucket(samples []models.ScoreSample, from, to time.Time, resolution time.Duration) []models.ScoreBucket {
+	var buckets []models.ScoreBucket
+	var last *float64
+	i := 0
+	for start := from; start.Before(to); start = start.Add(resolution) {
+		end := start.Add(resolution)
+		if end.After(to) {
+			end = to
+		}
+		for ; i < len(samples) && samples[i].SampledAt.Before(start); i++ {
+			last = &samples[i].Score
+		}
+
+		bucket := models.ScoreBucket{Start: start, Score: last, Min: last, Max: last}
+		for ; i < len(samples) && samples[i].SampledAt.Before(end); i++ {
+			score := samples[i].Score
+			if bucket.Samples == 0 || score < *bucket.Min {
+				bucket.Min = &score
+			}
+			if bucket.Samples == 0 || score > *bucket.Max {
+				bucket.Max = &score
+			}
+			bucket.Score = &score
+			bucket.Samples++
+		}
+		last = bucket.Score
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
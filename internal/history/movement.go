@@ -0,0 +1,96 @@
+package history
+
+import "ranking-service/models"
+
+// movement classifies a change from the previous to the current 1-based rank,
+// where a previous rank of 0 means the video was not ranked.
+func movement(rank, previousRank int) (string, int) {
+	switch {
+	case previousRank == 0:
+		return models.MovementNew, 0
+	case previousRank > rank:
+		return models.MovementUp, previousRank - rank
+	case previousRank < rank:
+		return models.MovementDown, previousRank - rank
+	default:
+		return models.MovementSame, 0
+	}
+}
+
+// ranks indexes the ranks of a snapshot by video.
+func ranks(entries []models.SnapshotEntry) map[string]models.SnapshotEntry {
+	byID := make(map[string]models.SnapshotEntry, len(entries))
+	for _, e := range entries {
+		byID[e.VideoID] = e
+	}
+	return byID
+}
+
+// Annotate sets the movement of each video of a page between its rank in the previous
+// snapshot and its current organic rank, by video. Snapshots hold the organic ranking,
+// so positions on the page, which pins, boosts and re-ranking change, are not
+// compared. Videos without organic rank are not annotated.
+func Annotate(videos []models.RankedVideo, current map[string]int64, previous []models.SnapshotEntry) []models.RankedVideo {
+	before := ranks(previous)
+	annotated := make([]models.RankedVideo, len(videos))
+	for i, v := range videos {
+		if rank, ok := current[v.VideoID]; ok {
+			v.PreviousRank = before[v.VideoID].Rank
+			v.Movement, v.RankChange = movement(int(rank), v.PreviousRank)
+		}
+		annotated[i] = v
+	}
+	return annotated
+}
+
+// Diff compares the top limit videos of two snapshots. Videos of the older top
+// missing from the newer one are reported as dropped, with their new rank if the
+// newer snapshot still holds them.
+func Diff(from, to *models.LeaderboardSnapshot, limit int) models.SnapshotDiff {
+	diff := models.SnapshotDiff{
+		From:    from.TakenAt,
+		To:      to.TakenAt,
+		Entries: []models.SnapshotDiffEntry{},
+		Dropped: []models.SnapshotDiffEntry{},
+	}
+	before, after := ranks(from.Entries), ranks(to.Entries)
+
+	for _, e := range to.Entries {
+		if e.Rank > limit {
+			break
+		}
+		previous := before[e.VideoID]
+		entry := models.SnapshotDiffEntry{
+			VideoID:       e.VideoID,
+			Rank:          e.Rank,
+			PreviousRank:  previous.Rank,
+			Score:         e.Score,
+			PreviousScore: previous.Score,
+		}
+		entry.Movement, entry.RankChange = movement(entry.Rank, entry.PreviousRank)
+		diff.Entries = append(diff.Entries, entry)
+	}
+
+	for _, e := range from.Entries {
+		if e.Rank > limit {
+			break
+		}
+		current, ok := after[e.VideoID]
+		if ok && current.Rank <= limit {
+			continue
+		}
+		entry := models.SnapshotDiffEntry{
+			VideoID:       e.VideoID,
+			Rank:          current.Rank,
+			PreviousRank:  e.Rank,
+			Score:         current.Score,
+			PreviousScore: e.Score,
+			Movement:      models.MovementDropped,
+		}
+		if ok {
+			entry.RankChange = e.Rank - current.Rank
+		}
+		diff.Dropped = append(diff.Dropped, entry)
+	}
+	return diff
+}
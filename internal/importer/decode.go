@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// errMalformed wraps the errors of records that cannot be decoded. Decoding goes on
+// with the next record.
+var errMalformed = errors.New("malformed record")
+
+// record is a record of an import file.
+type record interface {
+	// set sets a field from a CSV column. Empty values leave the field unset.
+	set(column, value string) error
+}
+
+// videoRecord is a catalog entry to import.
+type videoRecord struct {
+	VideoID   string     `json:"video_id"`
+	UserID    string     `json:"user_id"`
+	Title     string     `json:"title"`
+	Category  string     `json:"category"`
+	Score     *float64   `json:"score"`      // Defaults to the catalog's or 0.
+	CreatedAt *time.Time `json:"created_at"` // Defaults to the catalog's or the import time.
+}
+
+func (r *videoRecord) set(column, value string) (err error) {
+	switch column {
+	case "video_id":
+		r.VideoID = value
+	case "user_id":
+		r.UserID = value
+	case "title":
+		r.Title = value
+	case "category":
+		r.Category = value
+	case "score":
+		if value != "" {
+			var score float64
+			score, err = parseFloat(value)
+			r.Score = &score
+		}
+	case "created_at":
+		r.CreatedAt, err = parseTime(value)
+	default:
+		return fmt.Errorf("unknown column %q", column)
+	}
+	return err
+}
+
+// interactionRecord is a historical interaction to import.
+type interactionRecord struct {
+	VideoID string     `json:"video_id"`
+	Type    string     `json:"type"`
+	Weight  float64    `json:"weight"`
+	UserID  string     `json:"user_id"`
+	At      *time.Time `json:"at"` // Defaults to the import time.
+}
+
+func (r *interactionRecord) set(column, value string) (err error) {
+	switch column {
+	case "video_id":
+		r.VideoID = value
+	case "type":
+		r.Type = value
+	case "weight":
+		r.Weight, err = parseFloat(value)
+	case "user_id":
+		r.UserID = value
+	case "at":
+		r.At, err = parseTime(value)
+	default:
+		return fmt.Errorf("unknown column %q", column)
+	}
+	return err
+}
+
+func parseFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// decoder reads the records of an import file.
+type decoder interface {
+	// decode reads the next record into rec. It returns io.EOF at the end of the
+	// input, and an error wrapping errMalformed for a record that cannot be decoded.
+	decode(rec record) error
+}
+
+// newDecoder returns a decoder of the format. newRecord creates an empty record of
+// the imported kind, to check the CSV header.
+func newDecoder(format string, r io.Reader, newRecord func() record) (decoder, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		header, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return &csvDecoder{eof: true}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		columns := append([]string(nil), header...)
+		for _, column := range columns {
+			if err := newRecord().set(column, ""); err != nil {
+				return nil, err
+			}
+		}
+		return &csvDecoder{reader: cr, columns: columns}, nil
+	case FormatNDJSON:
+		return &ndjsonDecoder{reader: bufio.NewReader(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown import format %q, expected csv or ndjson", format)
+}
+
+// csvDecoder decodes CSV files with a header naming the columns.
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+	eof     bool
+}
+
+func (d *csvDecoder) decode(rec record) error {
+	if d.eof {
+		return io.EOF
+	}
+	values, err := d.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", errMalformed, err)
+	}
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if err := rec.set(d.columns[i], value); err != nil {
+			return fmt.Errorf("%w: %s: %v", errMalformed, d.columns[i], err)
+		}
+	}
+	return nil
+}
+
+// ndjsonDecoder decodes files of one JSON object per line. Blank lines are skipped.
+type ndjsonDecoder struct {
+	reader *bufio.Reader
+}
+
+func (d *ndjsonDecoder) decode(rec record) error {
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return fmt.Errorf("%w: %v", errMalformed, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,446 @@
+// Package importer seeds the rankings from bulk files of videos or historical
+// interactions, e.g. when migrating from another system.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"ranking-service/config"
+	"ranking-service/internal/repository"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+// Kinds of imports.
+const (
+	KindVideos       = "videos"
+	KindInteractions = "interactions"
+)
+
+// Import file formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// DefaultBatchSize is the number of records imported per batch.
+const DefaultBatchSize = 5000
+
+// ErrAlreadyImported is returned when the checkpoint of an import says it completed.
+var ErrAlreadyImported = errors.New("import already completed")
+
+// Reasons for rejecting records.
+var (
+	errMissingVideoID     = errors.New("missing video_id")
+	errMissingUserID      = errors.New("missing user_id")
+	errVideoDeleted       = errors.New("video has been deleted")
+	errVideoNotRegistered = errors.New("video is not registered")
+)
+
+// Options configures an import.
+type Options struct {
+	Kind   string // KindVideos or KindInteractions.
+	Format string // FormatCSV or FormatNDJSON.
+	// Checkpoint names the import. A run resumes after the records imported by the
+	// previous runs of the same name.
+	Checkpoint string
+	// Restart ignores the checkpoint and imports the input from the start.
+	Restart   bool
+	BatchSize int
+	// Progress, if set, is called after each batch.
+	Progress func(Progress)
+}
+
+// Progress reports how far an import has gone, including the previous runs.
+type Progress struct {
+	Position int64 // Records consumed, whether imported or rejected.
+	Skipped  int64 // Records skipped on resume, consumed by the previous runs.
+	Imported int64
+	Rejected int64
+}
+
+// Importer writes imported records to PostgreSQL and Redis.
+//
+// Each batch is written to Redis with absolute values, then to PostgreSQL along with
+// the checkpoint in a single transaction. The values are computed from the state in
+// PostgreSQL, so a batch that failed halfway is written again identically on resume.
+// Imports are meant to run while ingestion is paused: live interactions to the same
+// videos during an import may be overwritten.
+type Importer struct {
+	postgres repository.PostgresRepository
+	redis    repository.RedisRepository
+	scorer   scoring.Scorer
+	cfg      config.RankingConfig
+}
+
+// New creates an Importer. Interactions are scored with scorer.
+func New(postgres repository.PostgresRepository, redis repository.RedisRepository, scorer scoring.Scorer, cfg config.RankingConfig) *Importer {
+	return &Importer{postgres: postgres, redis: redis, scorer: scorer, cfg: cfg}
+}
+
+// numbered is a decoded record with its position in the input, from 1.
+type numbered[R any] struct {
+	n   int64
+	rec R
+}
+
+// Run imports the records read from r batch by batch, resuming from the checkpoint.
+// It stops after the current batch when the context is done, and can then be resumed.
+// The returned progress covers the batches written.
+func (i *Importer) Run(ctx context.Context, r io.Reader, opts Options) (Progress, error) {
+	switch opts.Kind {
+	case KindVideos:
+		return run(ctx, i, r, opts, func() *videoRecord { return &videoRecord{} }, i.importVideos)
+	case KindInteractions:
+		return run(ctx, i, r, opts, func() *interactionRecord { return &interactionRecord{} }, i.importInteractions)
+	}
+	return Progress{}, fmt.Errorf("unknown import kind %q, expected videos or interactions", opts.Kind)
+}
+
+// importBatch writes a batch and the checkpoint, and returns the number of records
+// it rejected. The checkpoint counts every record of the batch as imported; the
+// rejected ones are moved to its rejected count.
+type importBatch[R any] func(batch []numbered[R], checkpoint *models.ImportCheckpoint) (int64, error)
+
+func run[R any, PR interface {
+	*R
+	record
+}](ctx context.Context, i *Importer, r io.Reader, opts Options, newRecord func() PR, write importBatch[PR]) (Progress, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	checkpoint, err := i.postgres.GetImportCheckpoint(opts.Checkpoint)
+	if errors.Is(err, repository.ErrImportCheckpointNotFound) {
+		checkpoint, err = nil, nil
+	}
+	if err != nil {
+		return Progress{}, fmt.Errorf("failed to fetch import checkpoint: %w", err)
+	}
+	switch {
+	case checkpoint == nil || opts.Restart:
+		checkpoint = &models.ImportCheckpoint{Name: opts.Checkpoint, Kind: opts.Kind}
+	case checkpoint.Kind != opts.Kind:
+		return Progress{}, fmt.Errorf("checkpoint %q belongs to an import of %s", opts.Checkpoint, checkpoint.Kind)
+	case checkpoint.Done:
+		return Progress{Position: checkpoint.Position, Imported: checkpoint.Imported, Rejected: checkpoint.Rejected}, ErrAlreadyImported
+	}
+
+	dec, err := newDecoder(opts.Format, r, func() record { return newRecord() })
+	if err != nil {
+		return Progress{}, err
+	}
+
+	// Skip the records imported by the previous runs.
+	progress := Progress{Imported: checkpoint.Imported, Rejected: checkpoint.Rejected}
+	for progress.Position < checkpoint.Position {
+		err := dec.decode(newRecord())
+		if errors.Is(err, io.EOF) {
+			return progress, fmt.Errorf("input ended after %d records, before the checkpoint at %d", progress.Position, checkpoint.Position)
+		}
+		if err != nil && !errors.Is(err, errMalformed) {
+			return progress, err
+		}
+		progress.Position++
+		progress.Skipped++
+	}
+
+	for {
+		var (
+			batch    []numbered[PR]
+			position = progress.Position
+			rejected int64
+			done     bool
+		)
+		for len(batch) < opts.BatchSize {
+			rec := newRecord()
+			err := dec.decode(rec)
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil && !errors.Is(err, errMalformed) {
+				return progress, err
+			}
+			position++
+			if err != nil {
+				slog.Warn("Rejected import record", "record", position, "error", err)
+				rejected++
+				continue
+			}
+			batch = append(batch, numbered[PR]{n: position, rec: rec})
+		}
+
+		invalid, err := write(batch, &models.ImportCheckpoint{
+			Name:     checkpoint.Name,
+			Kind:     checkpoint.Kind,
+			Position: position,
+			Imported: progress.Imported + int64(len(batch)),
+			Rejected: progress.Rejected + rejected,
+			Done:     done,
+		})
+		if err != nil {
+			return progress, err
+		}
+		progress.Position = position
+		progress.Imported += int64(len(batch)) - invalid
+		progress.Rejected += rejected + invalid
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+
+		if done {
+			return progress, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+	}
+}
+
+// importVideos upserts a batch of catalog entries.
+func (i *Importer) importVideos(batch []numbered[*videoRecord], checkpoint *models.ImportCheckpoint) (int64, error) {
+	ids := make([]string, len(batch))
+	for j, b := range batch {
+		ids[j] = b.rec.VideoID
+	}
+	existing, err := i.postgres.GetVideosWithDeleted(ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch videos: %w", err)
+	}
+	catalog := make(map[string]models.Video, len(existing))
+	for _, v := range existing {
+		catalog[v.VideoID] = v
+	}
+
+	// The last record of a video in the batch wins.
+	now := time.Now()
+	var (
+		order    []string
+		videos   = make(map[string]models.Video, len(batch))
+		rejected int64
+	)
+	for _, b := range batch {
+		rec := b.rec
+		current, exists := catalog[rec.VideoID]
+		var err error
+		switch {
+		case rec.VideoID == "":
+			err = errMissingVideoID
+		case rec.UserID == "":
+			err = errMissingUserID
+		case exists && (current.Status == models.VideoStatusDeleted || current.DeletedAt.Valid):
+			err = errVideoDeleted
+		}
+		if err != nil {
+			slog.Warn("Rejected import record", "record", b.n, "videoID", rec.VideoID, "error", err)
+			rejected++
+			continue
+		}
+
+		createdAt := now
+		switch {
+		case rec.CreatedAt != nil:
+			createdAt = *rec.CreatedAt
+		case exists:
+			createdAt = current.CreatedAt
+		}
+		score := current.Score
+		if rec.Score != nil {
+			score = *rec.Score
+		}
+		if _, ok := videos[rec.VideoID]; !ok {
+			order = append(order, rec.VideoID)
+		}
+		videos[rec.VideoID] = models.Video{
+			VideoID:   rec.VideoID,
+			UserID:    rec.UserID,
+			Title:     rec.Title,
+			Category:  rec.Category,
+			Score:     score,
+			CreatedAt: createdAt,
+		}
+	}
+
+	rows := make([]models.Video, len(order))
+	scores := make([]models.ImportedScore, len(order))
+	for j, id := range order {
+		v := videos[id]
+		rows[j] = v
+		scores[j] = models.ImportedScore{VideoID: id, OwnerID: v.UserID, PreviousOwnerID: catalog[id].UserID, Score: v.Score}
+	}
+	checkpoint.Imported -= rejected
+	checkpoint.Rejected += rejected
+	if err := i.redis.ImportScores(scores); err != nil {
+		return 0, fmt.Errorf("failed to write scores to Redis: %w", err)
+	}
+	if err := i.postgres.ImportVideos(rows, checkpoint); err != nil {
+		return 0, fmt.Errorf("failed to write videos to PostgreSQL: %w", err)
+	}
+	return rejected, nil
+}
+
+// videoImport is the state of a video while replaying its interactions.
+type videoImport struct {
+	video    models.Video
+	exists   bool
+	counters map[string]float64
+}
+
+// importInteractions replays a batch of interactions with the scorer, in order, and
+// writes the resulting scores and counters of the videos.
+func (i *Importer) importInteractions(batch []numbered[*interactionRecord], checkpoint *models.ImportCheckpoint) (int64, error) {
+	ids := make([]string, len(batch))
+	for j, b := range batch {
+		ids[j] = b.rec.VideoID
+	}
+	existing, err := i.postgres.GetVideosWithDeleted(ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch videos: %w", err)
+	}
+	stats, err := i.postgres.GetVideosStats(ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch stats: %w", err)
+	}
+	states := make(map[string]*videoImport, len(existing))
+	for _, v := range existing {
+		states[v.VideoID] = &videoImport{video: v, exists: true, counters: map[string]float64{}}
+	}
+	for _, s := range stats {
+		if state, ok := states[s.VideoID]; ok {
+			state.counters = statsCounters(s)
+		}
+	}
+
+	now := time.Now()
+	var (
+		order    []string
+		touched  = make(map[string]bool, len(batch))
+		rejected int64
+	)
+	for _, b := range batch {
+		rec := b.rec
+		state := states[rec.VideoID]
+		at := now
+		if rec.At != nil {
+			at = *rec.At
+		}
+		interaction := scoring.Interaction{VideoID: rec.VideoID, UserID: rec.UserID, Type: rec.Type, Weight: rec.Weight, At: at}
+
+		var (
+			increments map[string]float64
+			err        error
+		)
+		switch {
+		case rec.VideoID == "":
+			err = errMissingVideoID
+		case rec.UserID == "":
+			err = errMissingUserID
+		case state != nil && (state.video.Status == models.VideoStatusDeleted || state.video.DeletedAt.Valid):
+			err = errVideoDeleted
+		case state == nil && i.cfg.RequireRegisteredVideos:
+			err = errVideoNotRegistered
+		default:
+			increments, err = scoring.CounterIncrements(interaction)
+		}
+		if err != nil {
+			slog.Warn("Rejected import record", "record", b.n, "videoID", rec.VideoID, "error", err)
+			rejected++
+			continue
+		}
+
+		if state == nil {
+			state = &videoImport{video: models.Video{VideoID: rec.VideoID, UserID: rec.UserID}, counters: map[string]float64{}}
+			states[rec.VideoID] = state
+		}
+		for name, value := range increments {
+			state.counters[name] += value
+		}
+		videoState := scoring.VideoState{
+			Exists:    state.exists,
+			Score:     state.video.Score,
+			CreatedAt: state.video.CreatedAt,
+			Counters:  state.counters,
+		}
+		if state.video.LastInteractionAt != nil {
+			videoState.LastInteractionAt = *state.video.LastInteractionAt
+		}
+		update, err := i.scorer.Score(interaction, videoState)
+		if err != nil {
+			return 0, fmt.Errorf("failed to score record %d: %w", b.n, err)
+		}
+		switch decayScorer, ok := i.scorer.(*scoring.TimeDecayScorer); {
+		case update.Score != nil:
+			state.video.Score = *update.Score
+		case ok:
+			// Scores decay periodically: past interactions have decayed since.
+			state.video.Score += update.Delta * decayScorer.Factor(now.Sub(at))
+		default:
+			state.video.Score += update.Delta
+		}
+		state.exists = true
+		if state.video.LastInteractionAt == nil || at.After(*state.video.LastInteractionAt) {
+			state.video.LastInteractionAt = &at
+		}
+
+		if !touched[rec.VideoID] {
+			touched[rec.VideoID] = true
+			order = append(order, rec.VideoID)
+		}
+	}
+
+	videos := make([]models.Video, len(order))
+	videoStats := make([]models.VideoStats, len(order))
+	scores := make([]models.ImportedScore, len(order))
+	for j, id := range order {
+		state := states[id]
+		videos[j] = state.video
+		videoStats[j] = countersStats(id, state.counters)
+		scores[j] = models.ImportedScore{VideoID: id, OwnerID: state.video.UserID, Score: state.video.Score, Counters: state.counters}
+	}
+	checkpoint.Imported -= rejected
+	checkpoint.Rejected += rejected
+	if err := i.redis.ImportScores(scores); err != nil {
+		return 0, fmt.Errorf("failed to write scores to Redis: %w", err)
+	}
+	if err := i.postgres.ImportScores(videos, videoStats, checkpoint); err != nil {
+		return 0, fmt.Errorf("failed to write scores to PostgreSQL: %w", err)
+	}
+	return rejected, nil
+}
+
+// statsCounters returns the counters held by a stats row.
+func statsCounters(s models.VideoStats) map[string]float64 {
+	return map[string]float64{
+		scoring.CounterViews:     float64(s.Views),
+		scoring.CounterLikes:     float64(s.Likes),
+		scoring.CounterComments:  float64(s.Comments),
+		scoring.CounterShares:    float64(s.Shares),
+		scoring.CounterReports:   float64(s.Reports),
+		scoring.CounterWatchTime: s.WatchTime,
+
+		scoring.CounterCappedInteractions: float64(s.CappedInteractions),
+		scoring.CounterCappedScore:        s.CappedScore,
+	}
+}
+
+// countersStats returns the stats row holding counters.
+func countersStats(videoID string, counters map[string]float64) models.VideoStats {
+	return models.VideoStats{
+		VideoID:   videoID,
+		Views:     int64(counters[scoring.CounterViews]),
+		Likes:     int64(counters[scoring.CounterLikes]),
+		Comments:  int64(counters[scoring.CounterComments]),
+		Shares:    int64(counters[scoring.CounterShares]),
+		Reports:   int64(counters[scoring.CounterReports]),
+		WatchTime: counters[scoring.CounterWatchTime],
+
+		CappedInteractions: int64(counters[scoring.CounterCappedInteractions]),
+		CappedScore:        counters[scoring.CounterCappedScore],
+	}
+}
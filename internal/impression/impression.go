@@ -0,0 +1,120 @@
+// Package impression issues the short-lived signed tokens that bind a viewer to a
+// video served in a top list, and redeems them when the viewer interacts with the
+// video, so that interactions cannot be forged for arbitrary videos.
+package impression
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"ranking-service/config"
+	"ranking-service/internal/repository"
+)
+
+// tokenAudience keeps interaction tokens from being accepted as access tokens, and
+// conversely.
+const tokenAudience = "interactions"
+
+// Errors of Redeem.
+var (
+	ErrMissingToken = errors.New("missing interaction token")
+	ErrInvalidToken = errors.New("invalid interaction token")
+	ErrTokenUsed    = errors.New("interaction token already used")
+)
+
+// Claims are the claims of an interaction token: the viewer is the subject, and the
+// token ID tells its uses apart.
+type Claims struct {
+	jwt.RegisteredClaims
+	VideoID string `json:"vid"`
+}
+
+// Tokens issues and redeems interaction tokens.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	redis  repository.RedisRepository
+	parser *jwt.Parser
+}
+
+// New creates the Tokens of cfg, whose uses are recorded in Redis.
+func New(redis repository.RedisRepository, cfg config.InteractionTokenConfig) (*Tokens, error) {
+	if len(cfg.Secret) < 32 {
+		return nil, errors.New("interaction tokens require a secret of at least 32 bytes")
+	}
+	if cfg.TTL < time.Second {
+		return nil, errors.New("interaction token TTL must be at least 1s")
+	}
+	return &Tokens{
+		secret: []byte(cfg.Secret),
+		ttl:    cfg.TTL,
+		redis:  redis,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithAudience(tokenAudience),
+			jwt.WithExpirationRequired(),
+		),
+	}, nil
+}
+
+// Issue returns a token for an impression of a video by a viewer, valid for the TTL.
+func (t *Tokens) Issue(viewerID, videoID string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        base64.RawURLEncoding.EncodeToString(nonce),
+			Subject:   viewerID,
+			Audience:  jwt.ClaimStrings{tokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+		},
+		VideoID: videoID,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+// Redeem checks that a token was issued for an impression of the video by the viewer
+// and has not expired, and records its use. A token can be used once: replays are
+// rejected with ErrTokenUsed. It returns the ID of the token, to Release it if the
+// interaction fails.
+func (t *Tokens) Redeem(token, viewerID, videoID string) (string, error) {
+	if token == "" {
+		return "", ErrMissingToken
+	}
+	if viewerID == "" {
+		return "", fmt.Errorf("%w: missing viewer ID", ErrInvalidToken)
+	}
+	var claims Claims
+	if _, err := t.parser.ParseWithClaims(token, &claims, t.key); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject != viewerID || claims.VideoID != videoID || claims.ID == "" {
+		return "", fmt.Errorf("%w: issued for another viewer or video", ErrInvalidToken)
+	}
+
+	first, err := t.redis.ConsumeInteractionToken(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return "", fmt.Errorf("failed to record interaction token use in Redis: %w", err)
+	}
+	if !first {
+		return "", ErrTokenUsed
+	}
+	return claims.ID, nil
+}
+
+// Release withdraws the use of a redeemed token, so that it can be used again.
+func (t *Tokens) Release(tokenID string) error {
+	return t.redis.ReleaseInteractionToken(tokenID)
+}
+
+func (t *Tokens) key(*jwt.Token) (any, error) {
+	return t.secret, nil
+}
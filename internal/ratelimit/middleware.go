@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ranking-service/internal/auth"
+	"ranking-service/models"
+)
+
+// Middleware returns a middleware that limits the requests of a route, responding
+// 429 with Retry-After beyond a limit. It runs after authentication, which
+// identifies the client; the video is the video_id path parameter and the viewer the
+// viewer_id of the JSON body. Batches are limited per interaction by their handler.
+func (l *Limiter) Middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := Request{IP: c.ClientIP(), Video: c.Param("video_id"), Viewer: viewerID(c)}
+		if principal, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+			req.Client = principal.ClientID()
+		}
+
+		decision, key := l.Check(route, req)
+		SetHeaders(c.Writer.Header(), decision)
+		if !decision.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded", "limit": key})
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetHeaders reports a decision in X-RateLimit headers, and Retry-After for rejected
+// requests. Decisions of requests without limits are not reported.
+func SetHeaders(header http.Header, decision models.RateLimitDecision) {
+	if decision.Limit == 0 {
+		return
+	}
+	header.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+	if !decision.Allowed {
+		header.Set("Retry-After", strconv.Itoa(RetryAfterSeconds(decision)))
+	}
+}
+
+// RetryAfterSeconds is how long the client of a rejected request has to wait, in
+// whole seconds.
+func RetryAfterSeconds(decision models.RateLimitDecision) int {
+	return max(seconds(decision.RetryAfter), 1)
+}
+
+// seconds rounds a duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// viewerID reads the viewer_id of a JSON body, leaving the body for the handler.
+func viewerID(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var payload struct {
+		ViewerID string `json:"viewer_id"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return payload.ViewerID
+}
@@ -0,0 +1,153 @@
+// Package ratelimit limits the rate of interaction ingestion per client, viewer, IP
+// address and video, with sliding windows counted in Redis so that every replica
+// enforces the same limits.
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ranking-service/config"
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// Routes that can be limited.
+const (
+	RouteInteraction     = "interaction"      // POST /videos/:video_id/interaction
+	RouteBatch           = "batch"            // POST /interactions/batch
+	RouteGRPCInteraction = "grpc_interaction" // RecordInteraction over gRPC
+)
+
+// Keys requests are limited by.
+const (
+	KeyClient = "client" // API key or token subject.
+	KeyViewer = "viewer"
+	KeyIP     = "ip"
+	KeyVideo  = "video"
+)
+
+// routeKeys are the keys known for the requests of each route.
+var routeKeys = map[string][]string{
+	RouteInteraction:     {KeyClient, KeyViewer, KeyIP, KeyVideo},
+	RouteBatch:           {KeyClient, KeyViewer, KeyIP, KeyVideo},
+	RouteGRPCInteraction: {KeyClient, KeyViewer, KeyIP, KeyVideo},
+}
+
+// Rule allows at most Limit requests of a route with the same Key in any Window.
+type Rule struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRules parses comma-separated route:key=limit/window rules, e.g.
+// interaction:ip=120/1m, by route.
+func ParseRules(spec string) (map[string][]Rule, error) {
+	rules := make(map[string][]Rule)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, rest, ok1 := strings.Cut(item, ":")
+		key, rest, ok2 := strings.Cut(rest, "=")
+		limit, window, ok3 := strings.Cut(rest, "/")
+		if !ok1 || !ok2 || !ok3 {
+			return nil, fmt.Errorf("invalid rate limit rule %q, expected route:key=limit/window", item)
+		}
+		keys, ok := routeKeys[route]
+		if !ok {
+			return nil, fmt.Errorf("unknown route %q in rate limit rule %q", route, item)
+		}
+		if !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("route %s cannot be limited by %s", route, key)
+		}
+		rule := Rule{Key: key}
+		var err error
+		if rule.Limit, err = strconv.Atoi(limit); err != nil || rule.Limit < 1 {
+			return nil, fmt.Errorf("invalid limit in rate limit rule %q", item)
+		}
+		if rule.Window, err = time.ParseDuration(window); err != nil || rule.Window < time.Second {
+			return nil, fmt.Errorf("invalid window in rate limit rule %q, must be at least 1s", item)
+		}
+		rules[route] = append(rules[route], rule)
+	}
+	return rules, nil
+}
+
+// Request identifies the sender of a request by each key. Empty values are not limited.
+type Request struct {
+	Client string
+	Viewer string
+	IP     string
+	Video  string
+}
+
+func (r Request) value(key string) string {
+	switch key {
+	case KeyClient:
+		return r.Client
+	case KeyViewer:
+		return r.Viewer
+	case KeyIP:
+		return r.IP
+	case KeyVideo:
+		return r.Video
+	}
+	return ""
+}
+
+// Limiter checks requests against the rules of their route.
+type Limiter struct {
+	redis repository.RedisRepository
+	rules map[string][]Rule
+	now   func() time.Time
+}
+
+// New creates a Limiter of the rules of cfg.
+func New(redis repository.RedisRepository, cfg config.RateLimitConfig) (*Limiter, error) {
+	rules, err := ParseRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Limiter{redis: redis, rules: rules, now: time.Now}, nil
+}
+
+// Check counts a request of a route against the rules it falls under, atomically:
+// rejected requests are not counted. It returns the decision for the most
+// constrained rule, and that rule's key. Requests are allowed while Redis is
+// unavailable.
+func (l *Limiter) Check(route string, req Request) (models.RateLimitDecision, string) {
+	var limits []models.RateLimit
+	var keys []string
+	for _, rule := range l.rules[route] {
+		value := req.value(rule.Key)
+		if value == "" {
+			continue
+		}
+		limits = append(limits, models.RateLimit{
+			Key:    route + ":" + rule.Key + ":" + rule.Window.String() + ":" + value,
+			Limit:  rule.Limit,
+			Window: rule.Window,
+		})
+		keys = append(keys, rule.Key)
+	}
+	if len(limits) == 0 {
+		return models.RateLimitDecision{Allowed: true}, ""
+	}
+
+	now := l.now()
+	requestID := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+	decision, err := l.redis.CheckRateLimits(limits, now, requestID)
+	if err != nil {
+		slog.Error("Failed to check rate limits", "route", route, "error", err)
+		return models.RateLimitDecision{Allowed: true}, ""
+	}
+	return decision, keys[decision.Index]
+}
@@ -0,0 +1,30 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrVideoNotFound is returned when a video does not exist in the catalog.
+	ErrVideoNotFound = errors.New("video not found")
+	// ErrVideoExists is returned when registering a video ID that is already taken.
+	ErrVideoExists = errors.New("video already exists")
+	// ErrEditorialRuleNotFound is returned when an editorial rule does not exist.
+	ErrEditorialRuleNotFound = errors.New("editorial rule not found")
+	// ErrScoringFormulaNotFound is returned when no scoring formula has been saved.
+	ErrScoringFormulaNotFound = errors.New("scoring formula not found")
+	// ErrSnapshotNotFound is returned when no leaderboard snapshot is close enough to the requested time.
+	ErrSnapshotNotFound = errors.New("leaderboard snapshot not found")
+	// ErrSnapshotExists is returned when a snapshot of the ranking was already taken at that time.
+	ErrSnapshotExists = errors.New("leaderboard snapshot already exists")
+	// ErrWebhookNotFound is returned when a webhook subscription does not exist.
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned when a webhook delivery does not exist.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrImportCheckpointNotFound is returned when an import has no checkpoint yet.
+	ErrImportCheckpointNotFound = errors.New("import checkpoint not found")
+	// ErrAPIKeyNotFound is returned when an API key does not exist.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAnomalyNotFound is returned when an anomaly does not exist.
+	ErrAnomalyNotFound = errors.New("anomaly not found")
+	// ErrAnomalyReviewed is returned when reviewing an anomaly that is no longer pending.
+	ErrAnomalyReviewed = errors.New("anomaly already reviewed")
+)
@@ -1,15 +1,108 @@
 package repository
 
-import "ranking-service/models"
+import (
+	"context"
+	"time"
+
+	"ranking-service/models"
+)
 
 // RedisRepository defines the methods required from a Redis implementation.
 type RedisRepository interface {
 	UpdateVideoScore(videoID string, delta float64) error
-	GetTopVideos(limit int) ([]string, error)
+	SetVideoScore(videoID string, score float64) error
+	GetTopVideos(limit int) ([]models.RankedVideo, error)
+	ScanTopVideos(offset, limit int) ([]models.RankedVideo, int, error)
+	ScanLeaderboard(board models.Leaderboard, at time.Time, offset, limit int) ([]models.RankedVideo, int, error)
+	UpdateLeaderboards(videoID, ownerID string, weight float64, windows []time.Duration, at time.Time) error
+	AdvanceDecayClock(now time.Time) (time.Duration, error)
+	ScaleScores(factor float64) error
+	SetVideoOwner(videoID, previousOwnerID, ownerID string) error
+	GetVideoScores(videoIDs []string) (map[string]float64, error)
+	GetVideoRank(videoID string) (*models.RankInfo, error)
+	GetVideoRanks(videoIDs []string) (map[string]int64, error)
+	RemoveVideo(videoID string) error
+	SetVideoHidden(videoID string, hidden bool) error
+	IncrementCounters(videoID string, increments map[string]float64) (map[string]float64, error)
+	GetCounters(videoID string) (map[string]float64, error)
+	ImportScores(scores []models.ImportedScore) error
+
+	PublishRankingUpdate(update models.RankingUpdate) error
+	SubscribeRankingUpdates(ctx context.Context) (<-chan models.RankingUpdate, error)
+
+	AcquireLock(name, owner string, ttl time.Duration) (bool, error)
+
+	ConsumeAPIKeyQuota(keyID uint, perMinute, daily int, at time.Time) (models.APIKeyUsage, bool, error)
+	GetAPIKeyUsage(keyID uint, days int, at time.Time) (models.APIKeyUsage, error)
+	CheckRateLimits(limits []models.RateLimit, at time.Time, requestID string) (models.RateLimitDecision, error)
+	ConsumeViewerCap(videoID, viewerID, interactionType string, contribution, limit float64, period time.Duration, at time.Time) (bool, error)
+	ConsumeInteractionToken(tokenID string, expiresAt time.Time) (bool, error)
+	ReleaseInteractionToken(tokenID string) error
+	ObserveInteraction(videoID, viewerID string, window time.Duration, baselineWeight float64, at time.Time) (models.AnomalyObservation, error)
+	ClaimAnomaly(id uint) (bool, error)
+	ReleaseAnomaly(id uint) error
 }
 
 // PostgresRepository defines the methods required from a PostgreSQL implementation.
 type PostgresRepository interface {
 	UpdateVideoScoreInPostgres(videoID, userID string, delta float64) error
+	SetVideoScoreInPostgres(videoID, userID string, score float64) error
+	ScaleVideoScores(factor float64) error
 	GetUserTopVideosFromDB(userID string, limit int) ([]models.Video, error)
+
+	CreateVideo(video *models.Video) error
+	GetVideo(videoID string) (*models.Video, error)
+	GetVideos(videoIDs []string) ([]models.Video, error)
+	GetVideosWithDeleted(videoIDs []string) ([]models.Video, error)
+	GetExplorationCandidates(createdAfter time.Time, maxScore float64, limit int) ([]models.Video, error)
+	UpdateVideo(video *models.Video) error
+	DeleteVideo(videoID string, hard bool) error
+
+	SetModerationStatus(videoID, status, reason string) error
+	GetModerationHistory(videoID string) ([]models.ModerationEvent, error)
+
+	CreateEditorialRule(rule *models.EditorialRule) error
+	ListEditorialRules() ([]models.EditorialRule, error)
+	GetActiveEditorialRules(at time.Time) ([]models.EditorialRule, error)
+	DeleteEditorialRule(id uint) error
+
+	IncrementVideoStats(videoID string, increments map[string]float64) error
+	GetVideoStats(videoID string) (*models.VideoStats, error)
+	GetVideosStats(videoIDs []string) ([]models.VideoStats, error)
+
+	SaveScoringFormula(formula *models.ScoringFormula) error
+	GetActiveScoringFormula() (*models.ScoringFormula, error)
+
+	RecordScoreSamples(interactedSince, at time.Time) (int64, error)
+	GetScoreSamples(videoID string, from, to time.Time) ([]models.ScoreSample, error)
+	DeleteScoreSamplesBefore(before time.Time) (int64, error)
+
+	SaveSnapshot(snapshot *models.LeaderboardSnapshot) error
+	GetNearestSnapshot(ranking string, at time.Time, within time.Duration) (*models.LeaderboardSnapshot, error)
+	DeleteSnapshotsBefore(before time.Time) (int64, error)
+
+	CreateWebhookSubscription(subscription *models.WebhookSubscription) error
+	ListWebhookSubscriptions() ([]models.WebhookSubscription, error)
+	GetWebhookSubscription(id uint) (*models.WebhookSubscription, error)
+	DeleteWebhookSubscription(id uint) error
+	CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error
+	ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	GetWebhookDelivery(id uint) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error)
+
+	GetImportCheckpoint(name string) (*models.ImportCheckpoint, error)
+	ImportVideos(videos []models.Video, checkpoint *models.ImportCheckpoint) error
+	ImportScores(videos []models.Video, stats []models.VideoStats, checkpoint *models.ImportCheckpoint) error
+
+	CreateAPIKey(key *models.APIKey) error
+	ListAPIKeys() ([]models.APIKey, error)
+	GetAPIKey(id uint) (*models.APIKey, error)
+	GetAPIKeyByHash(hash string) (*models.APIKey, error)
+	UpdateAPIKey(key *models.APIKey) error
+
+	QuarantineInteraction(anomaly models.Anomaly, increments map[string]float64, delta float64) error
+	ListAnomalies(status string, limit int) ([]models.Anomaly, error)
+	GetAnomaly(id uint) (*models.Anomaly, error)
+	ReviewAnomaly(id uint, status string, at time.Time, apply func(*models.Anomaly) error) (*models.Anomaly, error)
 }
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 
 	"ranking-service/config"
@@ -33,7 +37,8 @@ func NewPostgresDB(conf config.PostgresConfig) (*PostgresDB, error) {
 		return nil, err
 	}
 
-	if err := db.AutoMigrate(&models.Video{}); err != nil {
+	if err := db.AutoMigrate(&models.Video{}, &models.ModerationEvent{}, &models.EditorialRule{}, &models.ScoringFormula{}, &models.VideoStats{}, &models.ScoreSample{},
+		&models.LeaderboardSnapshot{}, &models.SnapshotEntry{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.ImportCheckpoint{}, &models.APIKey{}, &models.Anomaly{}); err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate: %v", err)
 	}
 
@@ -43,28 +48,761 @@ func NewPostgresDB(conf config.PostgresConfig) (*PostgresDB, error) {
 // UpdateVideoScoreInPostgres upserts a video record in PostgreSQL using GORM.
 // If the video record does not exist, it creates one; otherwise, it updates the score.
 func (p *PostgresDB) UpdateVideoScoreInPostgres(videoID, userID string, delta float64) error {
-	var video models.Video
-	result := p.db.First(&video, "video_id = ?", videoID)
-	if result.Error != nil {
-		// If the video is not found, create a new record.
-		if result.Error == gorm.ErrRecordNotFound {
-			video = models.Video{
-				VideoID: videoID,
-				UserID:  userID,
-				Score:   delta,
-			}
-			return p.db.Create(&video).Error
-		}
-		return result.Error
+	return p.upsertVideoScore(videoID, userID, delta, gorm.Expr("videos.score + ?", delta))
+}
+
+// SetVideoScoreInPostgres upserts a video record in PostgreSQL, replacing its score.
+func (p *PostgresDB) SetVideoScoreInPostgres(videoID, userID string, score float64) error {
+	return p.upsertVideoScore(videoID, userID, score, score)
+}
+
+// ScaleVideoScores multiplies the score of every video by factor, in a single statement
+// that leaves their update times alone.
+func (p *PostgresDB) ScaleVideoScores(factor float64) error {
+	return p.db.Model(&models.Video{}).Where("score <> 0").UpdateColumn("score", gorm.Expr("score * ?", factor)).Error
+}
+
+// upsertVideoScore creates the video record with the initial score if it does not
+// exist, or sets the score of the existing one along with the interaction time. Only
+// these columns are written, so that concurrent changes such as moderation are kept.
+func (p *PostgresDB) upsertVideoScore(videoID, userID string, initial float64, score interface{}) error {
+	now := time.Now()
+	video := models.Video{
+		VideoID:           videoID,
+		UserID:            userID,
+		Score:             initial,
+		LastInteractionAt: &now,
 	}
-	// Update the existing video's score.
-	video.Score += delta
-	return p.db.Save(&video).Error
+	return p.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "video_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"score":               score,
+			"last_interaction_at": now,
+			"updated_at":          now,
+		}),
+	}).Create(&video).Error
 }
 
 // GetUserTopVideosFromDB retrieves the top videos for a given user from PostgreSQL.
 func (p *PostgresDB) GetUserTopVideosFromDB(userID string, limit int) ([]models.Video, error) {
 	var videos []models.Video
-	err := p.db.Where("user_id = ?", userID).Order("score desc").Limit(limit).Find(&videos).Error
+	err := p.db.Where("user_id = ? AND moderation_status = ?", userID, models.ModerationVisible).
+		Order("score desc").Limit(limit).Find(&videos).Error
+	return videos, err
+}
+
+// CreateVideo registers a new video in the catalog.
+// Soft-deleted videos still hold their ID, so they are checked as well.
+func (p *PostgresDB) CreateVideo(video *models.Video) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Video{}).Where("video_id = ?", video.VideoID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVideoExists
+		}
+		return tx.Create(video).Error
+	})
+}
+
+// GetVideo retrieves a video from the catalog, including soft-deleted ones.
+func (p *PostgresDB) GetVideo(videoID string) (*models.Video, error) {
+	var video models.Video
+	err := p.db.Unscoped().First(&video, "video_id = ?", videoID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVideoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// GetVideos retrieves the catalog entries of the given videos.
+// Unknown and soft-deleted videos are left out.
+func (p *PostgresDB) GetVideos(videoIDs []string) ([]models.Video, error) {
+	var videos []models.Video
+	if len(videoIDs) == 0 {
+		return videos, nil
+	}
+	err := p.db.Where("video_id IN ?", videoIDs).Find(&videos).Error
+	return videos, err
+}
+
+// GetVideosWithDeleted retrieves the catalog entries of the given videos, including
+// the soft-deleted ones. Unknown videos are left out.
+func (p *PostgresDB) GetVideosWithDeleted(videoIDs []string) ([]models.Video, error) {
+	var videos []models.Video
+	if len(videoIDs) == 0 {
+		return videos, nil
+	}
+	err := p.db.Unscoped().Where("video_id IN ?", videoIDs).Find(&videos).Error
+	return videos, err
+}
+
+// GetExplorationCandidates retrieves recently created, visible videos that have not
+// collected much score yet, newest first.
+func (p *PostgresDB) GetExplorationCandidates(createdAfter time.Time, maxScore float64, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := p.db.Where("created_at >= ? AND score <= ? AND moderation_status = ?", createdAfter, maxScore, models.ModerationVisible).
+		Order("created_at desc").Limit(limit).Find(&videos).Error
 	return videos, err
 }
+
+// UpdateVideo saves the catalog metadata of an existing video.
+func (p *PostgresDB) UpdateVideo(video *models.Video) error {
+	return p.db.Save(video).Error
+}
+
+// DeleteVideo removes a video from the catalog.
+// A soft delete keeps the row (and its score) for auditing, a hard delete removes it.
+func (p *PostgresDB) DeleteVideo(videoID string, hard bool) error {
+	if hard {
+		result := p.db.Unscoped().Delete(&models.Video{}, "video_id = ?", videoID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVideoNotFound
+		}
+		return nil
+	}
+
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Video{}).Where("video_id = ?", videoID).Update("status", models.VideoStatusDeleted)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVideoNotFound
+		}
+		return tx.Delete(&models.Video{}, "video_id = ?", videoID).Error
+	})
+}
+
+// SetModerationStatus changes the moderation status of a video and records the decision.
+func (p *PostgresDB) SetModerationStatus(videoID, status, reason string) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Video{}).Where("video_id = ?", videoID).Updates(map[string]interface{}{
+			"moderation_status": status,
+			"moderation_reason": reason,
+			"moderated_at":      now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVideoNotFound
+		}
+		return tx.Create(&models.ModerationEvent{
+			VideoID:   videoID,
+			Status:    status,
+			Reason:    reason,
+			CreatedAt: now,
+		}).Error
+	})
+}
+
+// GetModerationHistory retrieves the moderation decisions of a video, newest first.
+func (p *PostgresDB) GetModerationHistory(videoID string) ([]models.ModerationEvent, error) {
+	var events []models.ModerationEvent
+	err := p.db.Where("video_id = ?", videoID).Order("created_at desc").Find(&events).Error
+	return events, err
+}
+
+// CreateEditorialRule stores a new pin or boost.
+func (p *PostgresDB) CreateEditorialRule(rule *models.EditorialRule) error {
+	return p.db.Create(rule).Error
+}
+
+// ListEditorialRules retrieves every editorial rule, newest first.
+func (p *PostgresDB) ListEditorialRules() ([]models.EditorialRule, error) {
+	var rules []models.EditorialRule
+	err := p.db.Order("created_at desc").Find(&rules).Error
+	return rules, err
+}
+
+// GetActiveEditorialRules retrieves the editorial rules in effect at the given time.
+func (p *PostgresDB) GetActiveEditorialRules(at time.Time) ([]models.EditorialRule, error) {
+	var rules []models.EditorialRule
+	err := p.db.Where("starts_at <= ? AND ends_at > ?", at, at).Order("id").Find(&rules).Error
+	return rules, err
+}
+
+// DeleteEditorialRule removes an editorial rule.
+func (p *PostgresDB) DeleteEditorialRule(id uint) error {
+	result := p.db.Delete(&models.EditorialRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEditorialRuleNotFound
+	}
+	return nil
+}
+
+// SaveScoringFormula stores a scoring formula, making it the active one.
+func (p *PostgresDB) SaveScoringFormula(formula *models.ScoringFormula) error {
+	return p.db.Create(formula).Error
+}
+
+// GetActiveScoringFormula retrieves the latest saved scoring formula.
+func (p *PostgresDB) GetActiveScoringFormula() (*models.ScoringFormula, error) {
+	var formula models.ScoringFormula
+	err := p.db.Order("id desc").First(&formula).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScoringFormulaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &formula, nil
+}
+
+// statsColumns are the video_stats columns, named after the counters they hold.
+// Only watch_time and capped_score are fractional.
+var statsColumns = map[string]bool{
+	"views":      true,
+	"likes":      true,
+	"comments":   true,
+	"shares":     true,
+	"reports":    true,
+	"watch_time": false,
+
+	"capped_interactions": true,
+	"capped_score":        false,
+}
+
+// IncrementVideoStats adds to the interaction counters of a video, creating its stats row if needed.
+func (p *PostgresDB) IncrementVideoStats(videoID string, increments map[string]float64) error {
+	row := map[string]interface{}{"video_id": videoID, "updated_at": time.Now()}
+	assignments := map[string]interface{}{"updated_at": gorm.Expr("excluded.updated_at")}
+	for name, value := range increments {
+		integer, ok := statsColumns[name]
+		if !ok {
+			return fmt.Errorf("unknown counter %q", name)
+		}
+		if integer {
+			row[name] = int64(value)
+		} else {
+			row[name] = value
+		}
+		assignments[name] = gorm.Expr(fmt.Sprintf("video_stats.%s + excluded.%s", name, name))
+	}
+
+	return p.db.Model(&models.VideoStats{}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_id"}},
+		DoUpdates: clause.Assignments(assignments),
+	}).Create(row).Error
+}
+
+// GetVideoStats retrieves the interaction counters of a video.
+// Videos without interactions get zero counters.
+func (p *PostgresDB) GetVideoStats(videoID string) (*models.VideoStats, error) {
+	stats := models.VideoStats{VideoID: videoID}
+	err := p.db.First(&stats, "video_id = ?", videoID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetVideosStats retrieves the interaction counters of the given videos.
+// Videos without interactions are left out.
+func (p *PostgresDB) GetVideosStats(videoIDs []string) ([]models.VideoStats, error) {
+	var stats []models.VideoStats
+	if len(videoIDs) == 0 {
+		return stats, nil
+	}
+	err := p.db.Where("video_id IN ?", videoIDs).Find(&stats).Error
+	return stats, err
+}
+
+// RecordScoreSamples samples the score of the videos that received interactions
+// since the given time. Samples are keyed by video and time, so recording the same
+// time twice (e.g. from several replicas) keeps a single sample.
+func (p *PostgresDB) RecordScoreSamples(interactedSince, at time.Time) (int64, error) {
+	result := p.db.Exec(`INSERT INTO score_samples (video_id, sampled_at, score)
+		SELECT video_id, ?, score FROM videos
+		WHERE last_interaction_at >= ? AND deleted_at IS NULL
+		ON CONFLICT (video_id, sampled_at) DO UPDATE SET score = excluded.score`, at, interactedSince)
+	return result.RowsAffected, result.Error
+}
+
+// GetScoreSamples retrieves the score samples of a video in [from, to), oldest first,
+// preceded by the latest sample before from if there is one.
+func (p *PostgresDB) GetScoreSamples(videoID string, from, to time.Time) ([]models.ScoreSample, error) {
+	var samples []models.ScoreSample
+	err := p.db.Where("video_id = ? AND sampled_at < ?", videoID, from).
+		Order("sampled_at desc").Limit(1).Find(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var inRange []models.ScoreSample
+	err = p.db.Where("video_id = ? AND sampled_at >= ? AND sampled_at < ?", videoID, from, to).
+		Order("sampled_at").Find(&inRange).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(samples, inRange...), nil
+}
+
+// DeleteScoreSamplesBefore removes the score samples older than the given time.
+func (p *PostgresDB) DeleteScoreSamplesBefore(before time.Time) (int64, error) {
+	result := p.db.Where("sampled_at < ?", before).Delete(&models.ScoreSample{})
+	return result.RowsAffected, result.Error
+}
+
+// SaveSnapshot stores a leaderboard snapshot with its entries. Only one snapshot
+// of a ranking is kept per time, others get ErrSnapshotExists.
+func (p *PostgresDB) SaveSnapshot(snapshot *models.LeaderboardSnapshot) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		entries := snapshot.Entries
+		result := tx.Omit("Entries").Clauses(clause.OnConflict{DoNothing: true}).Create(snapshot)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSnapshotExists
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		for i := range entries {
+			entries[i].SnapshotID = snapshot.ID
+		}
+		return tx.CreateInBatches(entries, 1000).Error
+	})
+}
+
+// GetNearestSnapshot retrieves the snapshot of a ranking taken closest to the given
+// time, with its entries by rank. A within of 0 accepts snapshots of any age.
+func (p *PostgresDB) GetNearestSnapshot(ranking string, at time.Time, within time.Duration) (*models.LeaderboardSnapshot, error) {
+	var before, after []models.LeaderboardSnapshot
+	if err := p.db.Where("ranking = ? AND taken_at <= ?", ranking, at).Order("taken_at desc").Limit(1).Find(&before).Error; err != nil {
+		return nil, err
+	}
+	if err := p.db.Where("ranking = ? AND taken_at > ?", ranking, at).Order("taken_at").Limit(1).Find(&after).Error; err != nil {
+		return nil, err
+	}
+
+	var nearest *models.LeaderboardSnapshot
+	if len(before) > 0 {
+		nearest = &before[0]
+	}
+	if len(after) > 0 && (nearest == nil || after[0].TakenAt.Sub(at) < at.Sub(nearest.TakenAt)) {
+		nearest = &after[0]
+	}
+	if nearest == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	if distance := nearest.TakenAt.Sub(at).Abs(); within > 0 && distance > within {
+		return nil, ErrSnapshotNotFound
+	}
+
+	if err := p.db.Where("snapshot_id = ?", nearest.ID).Order("rank").Find(&nearest.Entries).Error; err != nil {
+		return nil, err
+	}
+	return nearest, nil
+}
+
+// DeleteSnapshotsBefore removes the snapshots taken before the given time, with their entries.
+func (p *PostgresDB) DeleteSnapshotsBefore(before time.Time) (int64, error) {
+	var deleted int64
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.LeaderboardSnapshot{}).Select("id").Where("taken_at < ?", before)
+		if err := tx.Where("snapshot_id IN (?)", expired).Delete(&models.SnapshotEntry{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("taken_at < ?", before).Delete(&models.LeaderboardSnapshot{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// CreateWebhookSubscription stores a new webhook subscription.
+func (p *PostgresDB) CreateWebhookSubscription(subscription *models.WebhookSubscription) error {
+	return p.db.Create(subscription).Error
+}
+
+// ListWebhookSubscriptions retrieves every webhook subscription, oldest first.
+func (p *PostgresDB) ListWebhookSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := p.db.Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// GetWebhookSubscription retrieves a webhook subscription.
+func (p *PostgresDB) GetWebhookSubscription(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := p.db.First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// DeleteWebhookSubscription removes a webhook subscription. Its deliveries are kept
+// in the delivery log; the pending ones are dead-lettered when they come due.
+func (p *PostgresDB) DeleteWebhookSubscription(id uint) error {
+	result := p.db.Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// CreateWebhookDeliveries queues webhook deliveries.
+func (p *PostgresDB) CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return p.db.Create(&deliveries).Error
+}
+
+// ClaimDueWebhookDeliveries retrieves up to limit pending deliveries that are due and
+// postpones them by the lease, so that other replicas do not send them concurrently.
+func (p *PostgresDB) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+// GetWebhookDelivery retrieves a webhook delivery.
+func (p *PostgresDB) GetWebhookDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := p.db.First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateWebhookDelivery saves the outcome of a delivery attempt.
+func (p *PostgresDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return p.db.Save(delivery).Error
+}
+
+// ListWebhookDeliveries retrieves the latest deliveries, newest first. A subscription
+// ID of 0 or an empty status does not filter.
+func (p *PostgresDB) ListWebhookDeliveries(subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	query := p.db.Order("id desc").Limit(limit)
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetImportCheckpoint retrieves the checkpoint of a bulk import.
+func (p *PostgresDB) GetImportCheckpoint(name string) (*models.ImportCheckpoint, error) {
+	var checkpoint models.ImportCheckpoint
+	err := p.db.First(&checkpoint, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrImportCheckpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// ImportVideos upserts a batch of catalog entries with COPY and saves the import
+// checkpoint in the same transaction. The status and moderation of existing videos
+// are kept.
+func (p *PostgresDB) ImportVideos(videos []models.Video, checkpoint *models.ImportCheckpoint) error {
+	return p.importBatch(checkpoint, func(tx pgx.Tx) error {
+		if len(videos) == 0 {
+			return nil
+		}
+		rows := make([][]any, len(videos))
+		for i, v := range videos {
+			rows[i] = []any{v.VideoID, v.UserID, v.Title, v.Category, v.Score, v.CreatedAt}
+		}
+		err := copyInto(tx, "import_videos",
+			"video_id text, user_id text, title text, category text, score double precision, created_at timestamptz",
+			[]string{"video_id", "user_id", "title", "category", "score", "created_at"}, rows)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO videos (video_id, user_id, title, category, score, created_at, updated_at)
+			SELECT video_id, user_id, title, category, score, created_at, now() FROM import_videos
+			ON CONFLICT (video_id) DO UPDATE SET user_id = excluded.user_id, title = excluded.title,
+				category = excluded.category, score = excluded.score, created_at = excluded.created_at,
+				updated_at = excluded.updated_at`)
+		return err
+	})
+}
+
+// ImportScores replaces the scores, last interaction times and counters of a batch of
+// videos with COPY, creating the missing videos, and saves the import checkpoint in the
+// same transaction.
+func (p *PostgresDB) ImportScores(videos []models.Video, stats []models.VideoStats, checkpoint *models.ImportCheckpoint) error {
+	return p.importBatch(checkpoint, func(tx pgx.Tx) error {
+		if len(videos) > 0 {
+			rows := make([][]any, len(videos))
+			for i, v := range videos {
+				rows[i] = []any{v.VideoID, v.UserID, v.Score, v.LastInteractionAt}
+			}
+			err := copyInto(tx, "import_scores",
+				"video_id text, user_id text, score double precision, last_interaction_at timestamptz",
+				[]string{"video_id", "user_id", "score", "last_interaction_at"}, rows)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `INSERT INTO videos (video_id, user_id, score, last_interaction_at, created_at, updated_at)
+				SELECT video_id, user_id, score, last_interaction_at, now(), now() FROM import_scores
+				ON CONFLICT (video_id) DO UPDATE SET score = excluded.score,
+					last_interaction_at = excluded.last_interaction_at, updated_at = excluded.updated_at`)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(stats) > 0 {
+			rows := make([][]any, len(stats))
+			for i, s := range stats {
+				rows[i] = []any{s.VideoID, s.Views, s.Likes, s.Comments, s.Shares, s.Reports, s.WatchTime}
+			}
+			err := copyInto(tx, "import_stats",
+				"video_id text, views bigint, likes bigint, comments bigint, shares bigint, reports bigint, watch_time double precision",
+				[]string{"video_id", "views", "likes", "comments", "shares", "reports", "watch_time"}, rows)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `INSERT INTO video_stats (video_id, views, likes, comments, shares, reports, watch_time, updated_at)
+				SELECT video_id, views, likes, comments, shares, reports, watch_time, now() FROM import_stats
+				ON CONFLICT (video_id) DO UPDATE SET views = excluded.views, likes = excluded.likes,
+					comments = excluded.comments, shares = excluded.shares, reports = excluded.reports,
+					watch_time = excluded.watch_time, updated_at = excluded.updated_at`)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// importBatch runs fn and saves the import checkpoint in a single transaction, on the
+// pgx connection underlying GORM so that fn can use COPY.
+func (p *PostgresDB) importBatch(checkpoint *models.ImportCheckpoint, fn func(tx pgx.Tx) error) error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	checkpoint.UpdatedAt = time.Now()
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected PostgreSQL driver %T", driverConn)
+		}
+		return pgx.BeginFunc(ctx, pgxConn.Conn(), func(tx pgx.Tx) error {
+			if err := fn(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO import_checkpoints (name, kind, position, imported, rejected, done, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (name) DO UPDATE SET kind = excluded.kind, position = excluded.position,
+					imported = excluded.imported, rejected = excluded.rejected, done = excluded.done,
+					updated_at = excluded.updated_at`,
+				checkpoint.Name, checkpoint.Kind, checkpoint.Position, checkpoint.Imported, checkpoint.Rejected, checkpoint.Done, checkpoint.UpdatedAt)
+			return err
+		})
+	})
+}
+
+// copyInto creates a temporary table dropped at the end of the transaction and fills
+// it with COPY.
+func copyInto(tx pgx.Tx, table, definition string, columns []string, rows [][]any) error {
+	if _, err := tx.Exec(ctx, "CREATE TEMP TABLE "+table+" ("+definition+") ON COMMIT DROP"); err != nil {
+		return err
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// CreateAPIKey saves a new API key.
+func (p *PostgresDB) CreateAPIKey(key *models.APIKey) error {
+	return p.db.Create(key).Error
+}
+
+// ListAPIKeys retrieves every API key, including the revoked ones.
+func (p *PostgresDB) ListAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := p.db.Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// GetAPIKey retrieves an API key.
+func (p *PostgresDB) GetAPIKey(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := p.db.First(&key, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAPIKeyByHash retrieves the API key whose current or previous secret has the hash.
+func (p *PostgresDB) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := p.db.Where("hash = ? OR previous_hash = ?", hash, hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// UpdateAPIKey saves a rotated or revoked API key.
+func (p *PostgresDB) UpdateAPIKey(key *models.APIKey) error {
+	return p.db.Save(key).Error
+}
+
+// QuarantineInteraction adds an anomalous interaction, its counter increments and score
+// delta to the pending anomaly of its video, creating the anomaly if needed. The
+// reason and observation of the anomaly are replaced by the given ones.
+func (p *PostgresDB) QuarantineInteraction(anomaly models.Anomaly, increments map[string]float64, delta float64) error {
+	now := time.Now()
+	row := map[string]interface{}{
+		"video_id":            anomaly.VideoID,
+		"user_id":             anomaly.UserID,
+		"status":              models.AnomalyPending,
+		"reason":              anomaly.Reason,
+		"window_interactions": anomaly.WindowInteractions,
+		"baseline":            anomaly.Baseline,
+		"diversity":           anomaly.Diversity,
+		"interactions":        1,
+		"delta":               delta,
+		"detected_at":         now,
+		"updated_at":          now,
+	}
+	assignments := map[string]interface{}{
+		"user_id":             gorm.Expr("excluded.user_id"),
+		"reason":              gorm.Expr("excluded.reason"),
+		"window_interactions": gorm.Expr("excluded.window_interactions"),
+		"baseline":            gorm.Expr("excluded.baseline"),
+		"diversity":           gorm.Expr("excluded.diversity"),
+		"interactions":        gorm.Expr("anomalies.interactions + 1"),
+		"delta":               gorm.Expr("anomalies.delta + excluded.delta"),
+		"updated_at":          gorm.Expr("excluded.updated_at"),
+	}
+	for name, value := range increments {
+		integer, ok := statsColumns[name]
+		if !ok || name == "capped_interactions" || name == "capped_score" {
+			return fmt.Errorf("unknown counter %q", name)
+		}
+		if integer {
+			row[name] = int64(value)
+		} else {
+			row[name] = value
+		}
+		assignments[name] = gorm.Expr(fmt.Sprintf("anomalies.%s + excluded.%s", name, name))
+	}
+
+	// The pending anomaly of the video is the one of the partial unique index.
+	return p.db.Model(&models.Anomaly{}).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "video_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'pending'"}}},
+		DoUpdates:   clause.Assignments(assignments),
+	}).Create(row).Error
+}
+
+// ListAnomalies retrieves the anomalies with a status, oldest first, or the latest
+// anomalies when the status is empty.
+func (p *PostgresDB) ListAnomalies(status string, limit int) ([]models.Anomaly, error) {
+	query := p.db.Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status).Order("detected_at")
+	} else {
+		query = query.Order("id desc")
+	}
+	var anomalies []models.Anomaly
+	err := query.Find(&anomalies).Error
+	return anomalies, err
+}
+
+// GetAnomaly retrieves an anomaly.
+func (p *PostgresDB) GetAnomaly(id uint) (*models.Anomaly, error) {
+	var anomaly models.Anomaly
+	err := p.db.First(&anomaly, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAnomalyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
+
+// ReviewAnomaly moves a pending anomaly to the approved or discarded status and
+// returns it. Interactions quarantined later go to a new anomaly, so the returned
+// one holds everything that was reviewed. Only one review of an anomaly succeeds.
+// The review runs apply, if not nil, while the anomaly is locked: the anomaly stays
+// pending when it fails.
+func (p *PostgresDB) ReviewAnomaly(id uint, status string, at time.Time, apply func(*models.Anomaly) error) (*models.Anomaly, error) {
+	var anomaly models.Anomaly
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&anomaly, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAnomalyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if anomaly.Status != models.AnomalyPending {
+			return ErrAnomalyReviewed
+		}
+		if apply != nil {
+			if err := apply(&anomaly); err != nil {
+				return err
+			}
+		}
+		anomaly.Status, anomaly.ReviewedAt = status, &at
+		return tx.Model(&anomaly).Updates(map[string]interface{}{"status": status, "reviewed_at": at}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
@@ -2,14 +2,54 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"ranking-service/config"
+	"ranking-service/models"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-const redisKey = "video_ranking"
+const (
+	redisKey = "video_ranking"
+	// hiddenKey holds the videos pulled from rankings by moderation.
+	hiddenKey = redisKey + ":hidden"
+	// countersKeyPrefix prefixes the hash of interaction counters of each video.
+	countersKeyPrefix = redisKey + ":counters:"
+	// updatesChannel fans ranking updates out to every replica.
+	updatesChannel = redisKey + ":updates"
+	// ownerKeyPrefix prefixes the leaderboard of each owner's videos.
+	ownerKeyPrefix = redisKey + ":owner:"
+	// windowKeyPrefix prefixes the windowed leaderboards, one per window and bucket.
+	windowKeyPrefix = redisKey + ":window:"
+	// boardsKeyPrefix prefixes the set of leaderboards each video was added to, so
+	// that it can be removed from all of them.
+	boardsKeyPrefix = redisKey + ":boards:"
+	// quotaKeyPrefix prefixes the per-minute and daily request counters of API keys.
+	quotaKeyPrefix = redisKey + ":quota:"
+	// rateLimitKeyPrefix prefixes the sliding window logs of rate limited keys.
+	rateLimitKeyPrefix = redisKey + ":ratelimit:"
+	// viewerCapKeyPrefix prefixes the score contributed by a viewer to a video with an
+	// interaction type during a period.
+	viewerCapKeyPrefix = redisKey + ":cap:"
+	// anomalyKeyPrefix prefixes the anomaly detection state of each video, and the
+	// distinct viewers of its current window.
+	anomalyKeyPrefix = redisKey + ":anomaly:"
+	// interactionTokenKeyPrefix prefixes the uses of interaction tokens.
+	interactionTokenKeyPrefix = redisKey + ":itoken:"
+	// appliedAnomaliesKey holds the IDs of the anomalies whose interactions were applied.
+	appliedAnomaliesKey = redisKey + ":applied_anomalies"
+	// decayClockKey holds the time, in Unix milliseconds, the scores were last decayed to.
+	decayClockKey = redisKey + ":decayed_at"
+)
+
+// dailyQuotaRetention is how long daily API key counters are kept for usage reports.
+const dailyQuotaRetention = 32 * 24 * time.Hour
+
+// anomalyBaselineRetention is how long the interaction baseline of an idle video is kept.
+const anomalyBaselineRetention = 7 * 24 * time.Hour
 
 var ctx = context.Background()
 
@@ -38,7 +78,767 @@ func (r *RedisDB) UpdateVideoScore(videoID string, delta float64) error {
 	return err
 }
 
+// SetVideoScore replaces the score of a video in the Redis sorted set.
+func (r *RedisDB) SetVideoScore(videoID string, score float64) error {
+	return r.redisClient.ZAdd(ctx, redisKey, &redis.Z{Score: score, Member: videoID}).Err()
+}
+
 // GetTopVideos retrieves the top videos based on their score.
-func (r *RedisDB) GetTopVideos(limit int) ([]string, error) {
-	return r.redisClient.ZRevRange(ctx, redisKey, 0, int64(limit-1)).Result()
+func (r *RedisDB) GetTopVideos(limit int) ([]models.RankedVideo, error) {
+	videos, _, err := r.ScanTopVideos(0, limit)
+	return videos, err
+}
+
+// ScanTopVideos retrieves up to limit videos of the ranking starting at offset.
+// Videos hidden by moderation are skipped, so the ranking is read page by page
+// until enough visible videos are collected. The returned offset is where the
+// next scan should start, or 0 once the ranking is exhausted.
+func (r *RedisDB) ScanTopVideos(offset, limit int) ([]models.RankedVideo, int, error) {
+	return r.scan(redisKey, offset, limit)
+}
+
+// ScanLeaderboard retrieves up to limit videos of a leaderboard starting at offset,
+// like ScanTopVideos. Windowed leaderboards are read for the window containing at.
+func (r *RedisDB) ScanLeaderboard(board models.Leaderboard, at time.Time, offset, limit int) ([]models.RankedVideo, int, error) {
+	return r.scan(leaderboardKey(board, at), offset, limit)
+}
+
+// leaderboardKey returns the sorted set of a leaderboard.
+func leaderboardKey(board models.Leaderboard, at time.Time) string {
+	switch {
+	case board.Owner != "":
+		return ownerKeyPrefix + board.Owner
+	case board.Window > 0:
+		return windowKey(board.Window, at)
+	}
+	return redisKey
+}
+
+// windowKey returns the sorted set of the window containing at.
+func windowKey(window time.Duration, at time.Time) string {
+	return windowKeyPrefix + window.String() + ":" + strconv.FormatInt(at.Truncate(window).Unix(), 10)
+}
+
+// scan reads a sorted set page by page, skipping the hidden videos.
+func (r *RedisDB) scan(key string, offset, limit int) ([]models.RankedVideo, int, error) {
+	if limit <= 0 {
+		return nil, 0, nil
+	}
+	videos := make([]models.RankedVideo, 0, limit)
+	pageSize := int64(limit * 2)
+	for start := int64(offset); len(videos) < limit; start += pageSize {
+		page, err := r.redisClient.ZRevRangeWithScores(ctx, key, start, start+pageSize-1).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(page) == 0 {
+			return videos, 0, nil
+		}
+
+		members := make([]interface{}, len(page))
+		for i, z := range page {
+			members[i] = z.Member
+		}
+		hidden, err := r.redisClient.SMIsMember(ctx, hiddenKey, members...).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for i, z := range page {
+			if hidden[i] {
+				continue
+			}
+			videos = append(videos, models.RankedVideo{VideoID: z.Member.(string), Score: z.Score})
+			if len(videos) == limit {
+				return videos, int(start) + i + 1, nil
+			}
+		}
+
+		if int64(len(page)) < pageSize {
+			return videos, 0, nil
+		}
+	}
+	return videos, 0, nil
+}
+
+// updateLeaderboardsScript copies the global score of a video (ARGV[1]) to its owner's
+// leaderboard (KEYS[2]) and adds the interaction weight (ARGV[2]) to the windowed
+// leaderboards (KEYS[4..]), which expire at ARGV[3..]. Every leaderboard is
+// recorded in the video's set of leaderboards (KEYS[3]).
+var updateLeaderboardsScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if score then
+	redis.call("ZADD", KEYS[2], score, ARGV[1])
+	redis.call("SADD", KEYS[3], KEYS[2])
+end
+for i = 4, #KEYS do
+	redis.call("ZINCRBY", KEYS[i], ARGV[2], ARGV[1])
+	redis.call("PEXPIREAT", KEYS[i], ARGV[i - 1])
+	redis.call("SADD", KEYS[3], KEYS[i])
+end
+return 0`)
+
+// UpdateLeaderboards copies the new global score of a video to its owner's leaderboard
+// and adds the weight of the interactions that changed it to the windowed leaderboards
+// at the given time, which rank the score gained during the window. Call it after
+// updating the global score. A window's leaderboard is kept until the end of the
+// following window.
+func (r *RedisDB) UpdateLeaderboards(videoID, ownerID string, weight float64, windows []time.Duration, at time.Time) error {
+	keys := []string{redisKey, ownerKeyPrefix + ownerID, boardsKeyPrefix + videoID}
+	args := []interface{}{videoID, weight}
+	for _, window := range windows {
+		keys = append(keys, windowKey(window, at))
+		args = append(args, at.Truncate(window).Add(2*window).UnixMilli())
+	}
+	return updateLeaderboardsScript.Run(ctx, r.redisClient, keys, args...).Err()
+}
+
+// moveOwnerScript moves a video (ARGV[1]) from the leaderboard of an owner (KEYS[2])
+// to another (KEYS[3]) with its global score (KEYS[1]), and updates the video's set
+// of leaderboards (KEYS[4]).
+var moveOwnerScript = redis.NewScript(`
+redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("SREM", KEYS[4], KEYS[2])
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if score then
+	redis.call("ZADD", KEYS[3], score, ARGV[1])
+	redis.call("SADD", KEYS[4], KEYS[3])
+end
+return 0`)
+
+// SetVideoOwner moves a video from the leaderboard of its previous owner to the
+// leaderboard of its new owner.
+func (r *RedisDB) SetVideoOwner(videoID, previousOwnerID, ownerID string) error {
+	keys := []string{redisKey, ownerKeyPrefix + previousOwnerID, ownerKeyPrefix + ownerID, boardsKeyPrefix + videoID}
+	return moveOwnerScript.Run(ctx, r.redisClient, keys, videoID).Err()
+}
+
+// advanceDecayClockScript moves the decay clock (KEYS[1]) forward to ARGV[1] and
+// returns the milliseconds it moved, 0 when it is started or already ahead.
+var advanceDecayClockScript = redis.NewScript(`
+local previous = tonumber(redis.call("GET", KEYS[1]))
+local now = tonumber(ARGV[1])
+if previous and now <= previous then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1])
+if not previous then
+	return 0
+end
+return now - previous`)
+
+// AdvanceDecayClock moves the time the scores were decayed to forward to now, and
+// returns the time elapsed since the previous decay, so that every replica sharing the
+// ranking decays each period once. It returns 0 on the first call.
+func (r *RedisDB) AdvanceDecayClock(now time.Time) (time.Duration, error) {
+	elapsed, err := advanceDecayClockScript.Run(ctx, r.redisClient, []string{decayClockKey}, now.UnixMilli()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(elapsed) * time.Millisecond, nil
+}
+
+// ScaleScores multiplies the scores of the global ranking and of the owners'
+// leaderboards by factor. Each leaderboard is scaled atomically, so that concurrent
+// increments are kept. Windowed leaderboards rank the weight gained during their
+// window and are left untouched.
+func (r *RedisDB) ScaleScores(factor float64) error {
+	scale := func(key string) error {
+		return r.redisClient.ZUnionStore(ctx, key, &redis.ZStore{Keys: []string{key}, Weights: []float64{factor}}).Err()
+	}
+	if err := scale(redisKey); err != nil {
+		return err
+	}
+	iter := r.redisClient.Scan(ctx, 0, ownerKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := scale(iter.Val()); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// RemoveVideo removes a video from the ranking and from the leaderboards it was added to.
+func (r *RedisDB) RemoveVideo(videoID string) error {
+	boards, err := r.redisClient.SMembers(ctx, boardsKeyPrefix+videoID).Result()
+	if err != nil {
+		return err
+	}
+	_, err = r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, redisKey, videoID)
+		pipe.SRem(ctx, hiddenKey, videoID)
+		for _, board := range boards {
+			pipe.ZRem(ctx, board, videoID)
+		}
+		pipe.Del(ctx, boardsKeyPrefix+videoID)
+		return nil
+	})
+	return err
+}
+
+// ImportScores writes the scores of imported videos to the global ranking and to their
+// owners' leaderboards, and replaces their counters when set, in a single pipeline.
+// Windowed leaderboards are left untouched. Writes are absolute, so importing the same
+// scores again is harmless.
+func (r *RedisDB) ImportScores(scores []models.ImportedScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	_, err := r.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, s := range scores {
+			boards := boardsKeyPrefix + s.VideoID
+			pipe.ZAdd(ctx, redisKey, &redis.Z{Score: s.Score, Member: s.VideoID})
+			if s.PreviousOwnerID != "" && s.PreviousOwnerID != s.OwnerID {
+				pipe.ZRem(ctx, ownerKeyPrefix+s.PreviousOwnerID, s.VideoID)
+				pipe.SRem(ctx, boards, ownerKeyPrefix+s.PreviousOwnerID)
+			}
+			pipe.ZAdd(ctx, ownerKeyPrefix+s.OwnerID, &redis.Z{Score: s.Score, Member: s.VideoID})
+			pipe.SAdd(ctx, boards, ownerKeyPrefix+s.OwnerID)
+			if len(s.Counters) > 0 {
+				values := make(map[string]interface{}, len(s.Counters))
+				for name, value := range s.Counters {
+					values[name] = value
+				}
+				pipe.HSet(ctx, countersKeyPrefix+s.VideoID, values)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// GetVideoScores retrieves the scores of the given videos.
+// Videos missing from the ranking or hidden by moderation are left out.
+func (r *RedisDB) GetVideoScores(videoIDs []string) (map[string]float64, error) {
+	scores := make(map[string]float64, len(videoIDs))
+	if len(videoIDs) == 0 {
+		return scores, nil
+	}
+
+	members := make([]interface{}, len(videoIDs))
+	for i, videoID := range videoIDs {
+		members[i] = videoID
+	}
+	pipe := r.redisClient.Pipeline()
+	// ZSCORE (unlike ZMSCORE in go-redis) tells missing members apart from a zero score.
+	scoreCmds := make([]*redis.FloatCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		scoreCmds[i] = pipe.ZScore(ctx, redisKey, videoID)
+	}
+	hiddenCmd := pipe.SMIsMember(ctx, hiddenKey, members...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	hidden := hiddenCmd.Val()
+	for i, videoID := range videoIDs {
+		score, err := scoreCmds[i].Result()
+		if err == redis.Nil || hidden[i] {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scores[videoID] = score
+	}
+	return scores, nil
+}
+
+// GetVideoRank retrieves the position of a video in the ranking and the visible
+// video right above it. Hidden videos are not ranked and do not count.
+func (r *RedisDB) GetVideoRank(videoID string) (*models.RankInfo, error) {
+	info := &models.RankInfo{VideoID: videoID}
+
+	pipe := r.redisClient.Pipeline()
+	rankCmd := pipe.ZRevRank(ctx, redisKey, videoID)
+	scoreCmd := pipe.ZScore(ctx, redisKey, videoID)
+	hiddenCmd := pipe.SMembers(ctx, hiddenKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	rank, err := rankCmd.Result()
+	if err == redis.Nil {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Score = scoreCmd.Val()
+
+	hidden := make(map[string]bool, len(hiddenCmd.Val()))
+	for _, id := range hiddenCmd.Val() {
+		hidden[id] = true
+	}
+	if hidden[videoID] {
+		return info, nil
+	}
+
+	// Hidden videos ranked higher do not count.
+	hiddenRanks, err := r.hiddenRanks(hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk up the ranking to the nearest visible video.
+	const pageSize = 20
+	for end := rank - 1; end >= 0 && info.Above == nil; end -= pageSize {
+		start := end - pageSize + 1
+		if start < 0 {
+			start = 0
+		}
+		page, err := r.redisClient.ZRevRangeWithScores(ctx, redisKey, start, end).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := len(page) - 1; i >= 0; i-- {
+			if member := page[i].Member.(string); !hidden[member] {
+				info.Above = &models.RankedVideo{VideoID: member, Score: page[i].Score}
+				info.Gap = page[i].Score - info.Score
+				break
+			}
+		}
+	}
+	info.Rank = visibleRank(rank, hiddenRanks)
+	return info, nil
+}
+
+// GetVideoRanks retrieves the 1-based positions of videos in the organic ranking,
+// where hidden videos do not count. Videos missing from the ranking or hidden are
+// left out.
+func (r *RedisDB) GetVideoRanks(videoIDs []string) (map[string]int64, error) {
+	ranks := make(map[string]int64, len(videoIDs))
+	if len(videoIDs) == 0 {
+		return ranks, nil
+	}
+
+	pipe := r.redisClient.Pipeline()
+	rankCmds := make([]*redis.IntCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		rankCmds[i] = pipe.ZRevRank(ctx, redisKey, videoID)
+	}
+	hiddenCmd := pipe.SMembers(ctx, hiddenKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool, len(hiddenCmd.Val()))
+	for _, id := range hiddenCmd.Val() {
+		hidden[id] = true
+	}
+	hiddenRanks, err := r.hiddenRanks(hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, videoID := range videoIDs {
+		rank, err := rankCmds[i].Result()
+		if err == redis.Nil || hidden[videoID] {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ranks[videoID] = visibleRank(rank, hiddenRanks)
+	}
+	return ranks, nil
+}
+
+// hiddenRanks retrieves the 0-based positions of the hidden videos in the ranking.
+func (r *RedisDB) hiddenRanks(hidden map[string]bool) ([]int64, error) {
+	if len(hidden) == 0 {
+		return nil, nil
+	}
+	pipe := r.redisClient.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(hidden))
+	for id := range hidden {
+		cmds = append(cmds, pipe.ZRevRank(ctx, redisKey, id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	ranks := make([]int64, 0, len(cmds))
+	for _, cmd := range cmds {
+		if rank, err := cmd.Result(); err == nil {
+			ranks = append(ranks, rank)
+		}
+	}
+	return ranks, nil
+}
+
+// visibleRank converts a 0-based position in the ranking to a 1-based rank among
+// the visible videos, given the positions of the hidden ones.
+func visibleRank(rank int64, hiddenRanks []int64) int64 {
+	above := int64(0)
+	for _, hiddenRank := range hiddenRanks {
+		if hiddenRank < rank {
+			above++
+		}
+	}
+	return rank - above + 1
+}
+
+// SetVideoHidden adds or removes a video from the moderation hidden set.
+// The video keeps its score in the ranking either way.
+func (r *RedisDB) SetVideoHidden(videoID string, hidden bool) error {
+	if hidden {
+		return r.redisClient.SAdd(ctx, hiddenKey, videoID).Err()
+	}
+	return r.redisClient.SRem(ctx, hiddenKey, videoID).Err()
+}
+
+// IncrementCounters increments the interaction counters of a video and returns all of its counters.
+func (r *RedisDB) IncrementCounters(videoID string, increments map[string]float64) (map[string]float64, error) {
+	key := countersKeyPrefix + videoID
+	var all *redis.StringStringMapCmd
+	_, err := r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for name, value := range increments {
+			pipe.HIncrByFloat(ctx, key, name, value)
+		}
+		all = pipe.HGetAll(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCounters(all.Val())
+}
+
+// GetCounters retrieves the interaction counters of a video.
+func (r *RedisDB) GetCounters(videoID string) (map[string]float64, error) {
+	all, err := r.redisClient.HGetAll(ctx, countersKeyPrefix+videoID).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseCounters(all)
+}
+
+func parseCounters(values map[string]string) (map[string]float64, error) {
+	counters := make(map[string]float64, len(values))
+	for name, value := range values {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		counters[name] = parsed
+	}
+	return counters, nil
+}
+
+// PublishRankingUpdate notifies every replica that a ranking changed.
+func (r *RedisDB) PublishRankingUpdate(update models.RankingUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	return r.redisClient.Publish(ctx, updatesChannel, payload).Err()
+}
+
+// SubscribeRankingUpdates delivers the ranking updates published by every replica
+// until the context is done.
+func (r *RedisDB) SubscribeRankingUpdates(subscriptionCtx context.Context) (<-chan models.RankingUpdate, error) {
+	pubsub := r.redisClient.Subscribe(subscriptionCtx, updatesChannel)
+	// Wait for the subscription to be confirmed so that no update is missed afterwards.
+	if _, err := pubsub.Receive(subscriptionCtx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	updates := make(chan models.RankingUpdate)
+	go func() {
+		defer close(updates)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-subscriptionCtx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var update models.RankingUpdate
+				if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+					slog.Error("Invalid ranking update", "payload", msg.Payload, "error", err)
+					continue
+				}
+				select {
+				case updates <- update:
+				case <-subscriptionCtx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// acquireLockScript takes a lock, or extends it when the owner already holds it.
+var acquireLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+// AcquireLock takes the named lock for ttl, or extends it if the owner already holds
+// it. It reports whether the owner holds the lock, e.g. to elect a single replica
+// for a periodic job.
+func (r *RedisDB) AcquireLock(name, owner string, ttl time.Duration) (bool, error) {
+	held, err := acquireLockScript.Run(ctx, r.redisClient, []string{redisKey + ":lock:" + name}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// consumeQuotaScript counts a request against the per-minute and daily counters of an
+// API key, unless one of them has reached its quota (0 is unlimited). It returns
+// whether the request was counted and the counters after it.
+var consumeQuotaScript = redis.NewScript(`
+local minute = tonumber(redis.call("GET", KEYS[1]) or "0")
+local day = tonumber(redis.call("GET", KEYS[2]) or "0")
+local perMinute = tonumber(ARGV[1])
+local daily = tonumber(ARGV[2])
+if (perMinute > 0 and minute >= perMinute) or (daily > 0 and day >= daily) then
+	return {0, minute, day}
+end
+minute = redis.call("INCR", KEYS[1])
+if minute == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+day = redis.call("INCR", KEYS[2])
+if day == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[4])
+end
+return {1, minute, day}`)
+
+func quotaMinuteKey(keyID uint, at time.Time) string {
+	return quotaKeyPrefix + strconv.FormatUint(uint64(keyID), 10) + ":minute:" + strconv.FormatInt(at.Unix()/60, 10)
+}
+
+func quotaDayKey(keyID uint, day time.Time) string {
+	return quotaKeyPrefix + strconv.FormatUint(uint64(keyID), 10) + ":day:" + day.UTC().Format(time.DateOnly)
+}
+
+// ConsumeAPIKeyQuota counts a request of an API key at the given time, atomically
+// checking its per-minute and daily quotas (0 is unlimited). It reports whether the
+// request is allowed; rejected requests are not counted. Days are UTC days.
+func (r *RedisDB) ConsumeAPIKeyQuota(keyID uint, perMinute, daily int, at time.Time) (models.APIKeyUsage, bool, error) {
+	keys := []string{quotaMinuteKey(keyID, at), quotaDayKey(keyID, at)}
+	res, err := consumeQuotaScript.Run(ctx, r.redisClient, keys, perMinute, daily, (2 * time.Minute).Milliseconds(), dailyQuotaRetention.Milliseconds()).Int64Slice()
+	if err != nil {
+		return models.APIKeyUsage{}, false, err
+	}
+	usage := models.APIKeyUsage{Minute: res[1], Today: res[2], PerMinuteQuota: perMinute, DailyQuota: daily}
+	return usage, res[0] == 1, nil
+}
+
+// GetAPIKeyUsage returns the requests of an API key in the current minute and in each
+// of the last days, today included.
+func (r *RedisDB) GetAPIKeyUsage(keyID uint, days int, at time.Time) (models.APIKeyUsage, error) {
+	keys := []string{quotaMinuteKey(keyID, at)}
+	for i := 0; i < days; i++ {
+		keys = append(keys, quotaDayKey(keyID, at.AddDate(0, 0, -i)))
+	}
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return models.APIKeyUsage{}, err
+	}
+
+	counts := make([]int64, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			counts[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	usage := models.APIKeyUsage{Minute: counts[0]}
+	for i := 0; i < days; i++ {
+		usage.Daily = append(usage.Daily, models.APIKeyDailyUsage{
+			Date:     at.AddDate(0, 0, -i).UTC().Format(time.DateOnly),
+			Requests: counts[i+1],
+		})
+	}
+	if days > 0 {
+		usage.Today = counts[1]
+	}
+	return usage, nil
+}
+
+// rateLimitScript checks a request against sliding window logs, one sorted set of
+// request times per key, and records it in all of them only if every limit allows
+// it. ARGV holds the time in milliseconds, a unique member for the request, then the
+// limit and window in milliseconds of each key. It returns whether the request is
+// allowed, the index of the most constrained key, its remaining requests, the time
+// until the request would be allowed and the time until the key's oldest request
+// leaves its window.
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local counts = {}
+local allowed, limiting, retry = 1, 0, 0
+for i, key in ipairs(KEYS) do
+	local limit, window = tonumber(ARGV[1 + 2 * i]), tonumber(ARGV[2 + 2 * i])
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	counts[i] = redis.call("ZCARD", key)
+	if counts[i] >= limit then
+		allowed = 0
+		-- A slot frees when the request limit positions before the end leaves the window.
+		local freed = redis.call("ZRANGE", key, counts[i] - limit, counts[i] - limit, "WITHSCORES")
+		local wait = tonumber(freed[2]) + window - now
+		if limiting == 0 or wait > retry then
+			limiting, retry = i, wait
+		end
+	end
+end
+if allowed == 1 then
+	for i, key in ipairs(KEYS) do
+		redis.call("ZADD", key, now, ARGV[2])
+		redis.call("PEXPIRE", key, ARGV[2 + 2 * i])
+		counts[i] = counts[i] + 1
+	end
+	local remaining
+	for i = 1, #KEYS do
+		local left = tonumber(ARGV[1 + 2 * i]) - counts[i]
+		if limiting == 0 or left < remaining then
+			limiting, remaining = i, left
+		end
+	end
+end
+local reset = 0
+local oldest = redis.call("ZRANGE", KEYS[limiting], 0, 0, "WITHSCORES")
+if #oldest > 0 then
+	reset = tonumber(oldest[2]) + tonumber(ARGV[2 + 2 * limiting]) - now
+end
+return {allowed, limiting - 1, math.max(tonumber(ARGV[1 + 2 * limiting]) - counts[limiting], 0), retry, reset}`)
+
+// CheckRateLimits atomically checks a request at the given time against sliding
+// window limits, and counts it against all of them only if every limit allows it.
+// The request ID must be unique: it is the member recording the request.
+func (r *RedisDB) CheckRateLimits(limits []models.RateLimit, at time.Time, requestID string) (models.RateLimitDecision, error) {
+	if len(limits) == 0 {
+		return models.RateLimitDecision{Allowed: true}, nil
+	}
+	keys := make([]string, len(limits))
+	args := []any{at.UnixMilli(), requestID}
+	for i, limit := range limits {
+		keys[i] = rateLimitKeyPrefix + limit.Key
+		args = append(args, limit.Limit, limit.Window.Milliseconds())
+	}
+	res, err := rateLimitScript.Run(ctx, r.redisClient, keys, args...).Int64Slice()
+	if err != nil {
+		return models.RateLimitDecision{}, err
+	}
+	return models.RateLimitDecision{
+		Allowed:    res[0] == 1,
+		Index:      int(res[1]),
+		Limit:      limits[res[1]].Limit,
+		Remaining:  int(res[2]),
+		RetryAfter: time.Duration(res[3]) * time.Millisecond,
+		Reset:      time.Duration(res[4]) * time.Millisecond,
+	}, nil
+}
+
+// viewerCapScript adds a score contribution to the total of a viewer, unless it
+// would exceed the cap. ARGV holds the contribution, the cap and the key's time to
+// live in milliseconds. It returns 1 when the contribution is within the cap.
+var viewerCapScript = redis.NewScript(`
+local total = tonumber(redis.call("GET", KEYS[1]) or "0")
+if total + tonumber(ARGV[1]) > tonumber(ARGV[2]) + 1e-9 then
+	return 0
+end
+redis.call("INCRBYFLOAT", KEYS[1], ARGV[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return 1`)
+
+// ConsumeViewerCap atomically adds a viewer's score contribution to a video with an
+// interaction type to their total of the current period, unless the total would
+// exceed the cap. It reports whether the contribution is within the cap. Periods are
+// aligned on the Unix epoch, so that daily periods are UTC days.
+func (r *RedisDB) ConsumeViewerCap(videoID, viewerID, interactionType string, contribution, limit float64, period time.Duration, at time.Time) (bool, error) {
+	start := at.Truncate(period)
+	key := viewerCapKeyPrefix + strconv.FormatInt(start.Unix(), 10) + ":" + interactionType + ":" + videoID + ":" + viewerID
+	ttl := start.Add(period).Sub(at) + time.Minute
+	within, err := viewerCapScript.Run(ctx, r.redisClient, []string{key}, contribution, limit, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return within == 1, nil
+}
+
+// observeInteractionScript counts an interaction in the current anomaly detection
+// window of a video. The interactions of the previous windows, empty ones included,
+// are folded into an exponential moving average when a window starts. ARGV holds the
+// window start and length in milliseconds, the weight of a window in the average,
+// the viewer ID (possibly empty) and the retention of the state in milliseconds. It
+// returns the interactions, the interactions with a viewer ID, the distinct viewers
+// of the window, and the baseline in thousandths or -1 when unknown.
+var observeInteractionScript = redis.NewScript(`
+local start, window, weight = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "window", "count", "viewed", "baseline")
+local current, count, viewed, baseline = tonumber(state[1]), tonumber(state[2]) or 0, tonumber(state[3]) or 0, tonumber(state[4])
+if current == nil or start > current then
+	if current then
+		baseline = baseline and baseline + weight * (count - baseline) or count
+		local empty = math.floor((start - current) / window) - 1
+		if empty > 0 then
+			baseline = baseline * (1 - weight) ^ empty
+		end
+	end
+	current, count, viewed = start, 0, 0
+end
+count = count + 1
+if ARGV[4] ~= "" then
+	viewed = viewed + 1
+	redis.call("PFADD", KEYS[2], ARGV[4])
+	redis.call("PEXPIRE", KEYS[2], window * 2)
+end
+local distinct = redis.call("PFCOUNT", KEYS[2])
+redis.call("HSET", KEYS[1], "window", current, "count", count, "viewed", viewed)
+if baseline then
+	redis.call("HSET", KEYS[1], "baseline", tostring(baseline))
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return {count, viewed, distinct, baseline and math.floor(baseline * 1000 + 0.5) or -1}`)
+
+// ObserveInteraction atomically counts an interaction of a video, and of a viewer if
+// the ID is not empty, in the current anomaly detection window, and returns what is
+// known about the window and the baseline of the video. Windows are aligned on the
+// Unix epoch.
+func (r *RedisDB) ObserveInteraction(videoID, viewerID string, window time.Duration, baselineWeight float64, at time.Time) (models.AnomalyObservation, error) {
+	start := at.Truncate(window)
+	keys := []string{anomalyKeyPrefix + videoID, anomalyKeyPrefix + videoID + ":viewers:" + strconv.FormatInt(start.Unix(), 10)}
+	res, err := observeInteractionScript.Run(ctx, r.redisClient, keys,
+		start.UnixMilli(), window.Milliseconds(), baselineWeight, viewerID, anomalyBaselineRetention.Milliseconds()).Int64Slice()
+	if err != nil {
+		return models.AnomalyObservation{}, err
+	}
+	observation := models.AnomalyObservation{Interactions: res[0], ViewerInteractions: res[1], DistinctViewers: res[2]}
+	if res[3] >= 0 {
+		observation.Baseline, observation.HasBaseline = float64(res[3])/1000, true
+	}
+	return observation, nil
+}
+
+// ClaimAnomaly records that the interactions of an anomaly are being applied, and
+// reports whether they were not already.
+func (r *RedisDB) ClaimAnomaly(id uint) (bool, error) {
+	added, err := r.redisClient.SAdd(ctx, appliedAnomaliesKey, id).Result()
+	return added == 1, err
+}
+
+// ReleaseAnomaly withdraws the claim of an anomaly whose interactions failed to apply.
+func (r *RedisDB) ReleaseAnomaly(id uint) error {
+	return r.redisClient.SRem(ctx, appliedAnomaliesKey, id).Err()
+}
+
+// ConsumeInteractionToken records the use of an interaction token, and reports whether
+// it is the first one. Uses are kept until the token expires.
+func (r *RedisDB) ConsumeInteractionToken(tokenID string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt) + time.Minute
+	return r.redisClient.SetNX(ctx, interactionTokenKeyPrefix+tokenID, 1, max(ttl, time.Minute)).Result()
+}
+
+// ReleaseInteractionToken withdraws the use of an interaction token.
+func (r *RedisDB) ReleaseInteractionToken(tokenID string) error {
+	return r.redisClient.Del(ctx, interactionTokenKeyPrefix+tokenID).Err()
 }
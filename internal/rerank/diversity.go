@@ -0,0 +1,54 @@
+package rerank
+
+import "ranking-service/models"
+
+// Diversifier caps how many videos of the same owner or category appear in a page.
+// Candidates are offered in ranking order and accepted while the caps allow.
+type Diversifier struct {
+	limit          int
+	maxPerOwner    int
+	maxPerCategory int
+	owners         map[string]int
+	categories     map[string]int
+	videos         []models.RankedVideo
+}
+
+// NewDiversifier creates a Diversifier filling a page of limit videos.
+// A cap of 0 disables it; videos without an owner or category are never capped.
+func NewDiversifier(limit, maxPerOwner, maxPerCategory int) *Diversifier {
+	return &Diversifier{
+		limit:          limit,
+		maxPerOwner:    maxPerOwner,
+		maxPerCategory: maxPerCategory,
+		owners:         make(map[string]int),
+		categories:     make(map[string]int),
+		videos:         make([]models.RankedVideo, 0, limit),
+	}
+}
+
+// Offer adds the video to the page unless the page is full or a cap is reached.
+func (d *Diversifier) Offer(video models.RankedVideo, owner, category string) bool {
+	if d.Full() {
+		return false
+	}
+	if d.maxPerOwner > 0 && owner != "" && d.owners[owner] >= d.maxPerOwner {
+		return false
+	}
+	if d.maxPerCategory > 0 && category != "" && d.categories[category] >= d.maxPerCategory {
+		return false
+	}
+	d.owners[owner]++
+	d.categories[category]++
+	d.videos = append(d.videos, video)
+	return true
+}
+
+// Full reports whether the page holds limit videos.
+func (d *Diversifier) Full() bool {
+	return len(d.videos) >= d.limit
+}
+
+// Videos returns the accepted videos in ranking order.
+func (d *Diversifier) Videos() []models.RankedVideo {
+	return d.videos
+}
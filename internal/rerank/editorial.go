@@ -0,0 +1,116 @@
+// Package rerank applies read-time adjustments on top of a ranking.
+package rerank
+
+import (
+	"sort"
+
+	"ranking-service/models"
+)
+
+// EditorialVideoIDs returns the videos referenced by the rules that are not already in videos.
+func EditorialVideoIDs(videos []models.RankedVideo, rules []models.EditorialRule) []string {
+	seen := make(map[string]bool, len(videos))
+	for _, v := range videos {
+		seen[v.VideoID] = true
+	}
+	var ids []string
+	for _, rule := range rules {
+		if !seen[rule.VideoID] {
+			seen[rule.VideoID] = true
+			ids = append(ids, rule.VideoID)
+		}
+	}
+	return ids
+}
+
+// ApplyEditorial applies the active pins and boosts to a ranking and returns at most limit videos.
+//
+// Boosted videos have their score multiplied and the ranking is re-sorted; scores
+// holds the current score of boosted or pinned videos that are not in videos yet.
+// Pinned videos are then moved to their position. Videos referenced by a rule but
+// absent from both videos and scores (deleted or hidden ones) are ignored.
+func ApplyEditorial(videos []models.RankedVideo, rules []models.EditorialRule, scores map[string]float64, limit int) []models.RankedVideo {
+	multipliers := make(map[string]float64)
+	var pins []models.EditorialRule
+	for _, rule := range rules {
+		switch rule.Kind {
+		case models.EditorialBoost:
+			if m, ok := multipliers[rule.VideoID]; ok {
+				multipliers[rule.VideoID] = m * rule.Multiplier
+			} else {
+				multipliers[rule.VideoID] = rule.Multiplier
+			}
+		case models.EditorialPin:
+			pins = append(pins, rule)
+		}
+	}
+
+	ranked := make([]models.RankedVideo, 0, len(videos)+len(scores))
+	present := make(map[string]models.RankedVideo, len(videos)+len(scores))
+	for _, v := range videos {
+		ranked = append(ranked, v)
+		present[v.VideoID] = v
+	}
+	for videoID, score := range scores {
+		if _, ok := present[videoID]; ok {
+			continue
+		}
+		v := models.RankedVideo{VideoID: videoID, Score: score}
+		present[videoID] = v
+		// Only boosted videos compete on score, pinned ones are placed below.
+		if _, ok := multipliers[videoID]; ok {
+			ranked = append(ranked, v)
+		}
+	}
+
+	// Boosts.
+	if len(multipliers) > 0 {
+		for i := range ranked {
+			if m, ok := multipliers[ranked[i].VideoID]; ok {
+				ranked[i].Score *= m
+				ranked[i].Editorial = models.EditorialBoost
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Score > ranked[j].Score
+		})
+	}
+
+	// Pins, lowest position first so later pins do not shift earlier ones.
+	sort.SliceStable(pins, func(i, j int) bool {
+		return pins[i].Position < pins[j].Position
+	})
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		if _, ok := present[pin.VideoID]; !ok || pinned[pin.VideoID] {
+			continue
+		}
+		pinned[pin.VideoID] = true
+
+		v := present[pin.VideoID]
+		for i := range ranked {
+			if ranked[i].VideoID == pin.VideoID {
+				v = ranked[i]
+				ranked = append(ranked[:i], ranked[i+1:]...)
+				break
+			}
+		}
+		v.Editorial = models.EditorialPin
+
+		pos := pin.Position - 1
+		if pos < 0 {
+			pos = 0
+		}
+		if pos > len(ranked) {
+			pos = len(ranked)
+		}
+		ranked = append(ranked, models.RankedVideo{})
+		copy(ranked[pos+1:], ranked[pos:])
+		ranked[pos] = v
+	}
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,47 @@
+package rerank
+
+import (
+	"math/rand/v2"
+
+	"ranking-service/models"
+)
+
+// Explore implements epsilon-greedy exploration: every slot of the page is given,
+// with probability epsilon, to a random video of the pool instead of the organic
+// one, which moves down. The page keeps its length. It returns the new page and
+// the 0-based positions of the exploration slots. A nil rnd uses the global source.
+func Explore(videos []models.RankedVideo, pool []models.RankedVideo, epsilon float64, rnd *rand.Rand) ([]models.RankedVideo, []int) {
+	float64n, intn := rand.Float64, rand.IntN
+	if rnd != nil {
+		float64n, intn = rnd.Float64, rnd.IntN
+	}
+
+	inPage := make(map[string]bool, len(videos))
+	for _, v := range videos {
+		inPage[v.VideoID] = true
+	}
+	candidates := make([]models.RankedVideo, 0, len(pool))
+	for _, v := range pool {
+		if !inPage[v.VideoID] {
+			candidates = append(candidates, v)
+		}
+	}
+
+	page := make([]models.RankedVideo, 0, len(videos))
+	var slots []int
+	organic := 0
+	for len(page) < len(videos) {
+		if len(candidates) > 0 && float64n() < epsilon {
+			i := intn(len(candidates))
+			v := candidates[i]
+			candidates = append(candidates[:i], candidates[i+1:]...)
+			v.Exploration = true
+			slots = append(slots, len(page))
+			page = append(page, v)
+			continue
+		}
+		page = append(page, videos[organic])
+		organic++
+	}
+	return page, slots
+}
@@ -0,0 +1,41 @@
+package rerank
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"ranking-service/models"
+)
+
+// FreshnessBoost returns the score multiplier of a video of the given age.
+// New videos get a multiplier of 1+weight, halving towards 1 every halfLife.
+func FreshnessBoost(age time.Duration, weight float64, halfLife time.Duration) float64 {
+	if weight <= 0 || halfLife <= 0 {
+		return 1
+	}
+	if age < 0 {
+		age = 0
+	}
+	return 1 + weight*math.Exp2(-float64(age)/float64(halfLife))
+}
+
+// ApplyFreshness boosts the score of recently created videos, re-sorts the ranking
+// and returns at most limit videos. Videos without a creation time are not boosted.
+func ApplyFreshness(videos []models.RankedVideo, createdAt map[string]time.Time, now time.Time, weight float64, halfLife time.Duration, limit int) []models.RankedVideo {
+	ranked := make([]models.RankedVideo, len(videos))
+	copy(ranked, videos)
+	for i := range ranked {
+		if t, ok := createdAt[ranked[i].VideoID]; ok {
+			ranked[i].Score *= FreshnessBoost(now.Sub(t), weight, halfLife)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,54 @@
+package scoring
+
+import "fmt"
+
+// Counter names. Counters are tracked per video and are the variables of formulas.
+const (
+	CounterViews     = "views"
+	CounterLikes     = "likes"
+	CounterComments  = "comments"
+	CounterShares    = "shares"
+	CounterReports   = "reports"
+	CounterWatchTime = "watch_time" // Total watch time.
+
+	// Interactions beyond the per-viewer caps, and the score they were not given.
+	CounterCappedInteractions = "capped_interactions"
+	CounterCappedScore        = "capped_score"
+)
+
+// counterByType maps each interaction type to the counter it increments.
+var counterByType = map[string]string{
+	InteractionView:      CounterViews,
+	InteractionLike:      CounterLikes,
+	InteractionComment:   CounterComments,
+	InteractionShare:     CounterShares,
+	InteractionReport:    CounterReports,
+	InteractionWatchTime: CounterWatchTime,
+}
+
+// CounterIncrements returns how the interaction changes the video's counters:
+// one more of its type, or its weight added to the total watch time.
+func CounterIncrements(interaction Interaction) (map[string]float64, error) {
+	counter, ok := counterByType[interaction.Type]
+	if !ok {
+		return nil, ErrUnknownInteraction
+	}
+	if interaction.Type == InteractionWatchTime {
+		return map[string]float64{counter: interaction.Weight}, nil
+	}
+	return map[string]float64{counter: 1}, nil
+}
+
+// ValidateViewerCaps checks that per-viewer caps are set on known interaction types
+// and are not negative.
+func ValidateViewerCaps(caps map[string]float64) error {
+	for interactionType, limit := range caps {
+		if _, ok := counterByType[interactionType]; !ok {
+			return fmt.Errorf("unknown interaction type %q", interactionType)
+		}
+		if limit < 0 {
+			return fmt.Errorf("negative cap of %s interactions", interactionType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package scoring
+
+import (
+	"math"
+	"time"
+)
+
+// StrategyTimeDecay is the name of the TimeDecayScorer strategy.
+const StrategyTimeDecay = "time_decay"
+
+// TimeDecayScorer halves every video's score each half-life, so recent engagement
+// outweighs old engagement. Interactions add their points like the linear strategy;
+// the scores of all videos are decayed together, periodically, by the factor of
+// Factor, so that idle videos decay as well.
+type TimeDecayScorer struct {
+	linear   *LinearScorer
+	halfLife time.Duration
+}
+
+// NewTimeDecayScorer creates a TimeDecayScorer on top of the weights of linear.
+func NewTimeDecayScorer(linear *LinearScorer, halfLife time.Duration) *TimeDecayScorer {
+	return &TimeDecayScorer{linear: linear, halfLife: halfLife}
+}
+
+func (s *TimeDecayScorer) Name() string {
+	return StrategyTimeDecay
+}
+
+// Score adds the interaction weight to the score, which decays separately.
+func (s *TimeDecayScorer) Score(interaction Interaction, state VideoState) (ScoreUpdate, error) {
+	weight, err := s.linear.Weight(interaction)
+	if err != nil {
+		return ScoreUpdate{}, err
+	}
+	return ScoreUpdate{Delta: weight, Weight: weight}, nil
+}
+
+// ScoreBulk adds the weight of the interactions to the score, which decays separately.
+func (s *TimeDecayScorer) ScoreBulk(increments map[string]float64, weight float64, at time.Time, state VideoState) (ScoreUpdate, error) {
+	return ScoreUpdate{Delta: weight, Weight: weight}, nil
+}
+
+// Factor returns what a score is worth, per point, after elapsed time.
+func (s *TimeDecayScorer) Factor(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(elapsed) / float64(s.halfLife))
+}
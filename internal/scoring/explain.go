@@ -0,0 +1,83 @@
+package scoring
+
+import (
+	"fmt"
+	"time"
+
+	"ranking-service/models"
+)
+
+// Explainer is implemented by scorers that can break a video's score down.
+type Explainer interface {
+	// Explain returns the components of the video's score at the given time.
+	Explain(state VideoState, at time.Time) []models.ScoreContribution
+}
+
+// explainOrder is the order in which counters are explained.
+var explainOrder = []string{
+	InteractionView,
+	InteractionLike,
+	InteractionComment,
+	InteractionShare,
+	InteractionReport,
+	InteractionWatchTime,
+}
+
+// Explain returns the points earned per interaction type.
+func (s *LinearScorer) Explain(state VideoState, at time.Time) []models.ScoreContribution {
+	var contributions []models.ScoreContribution
+	for _, interactionType := range explainOrder {
+		count := state.Counters[counterByType[interactionType]]
+		if count == 0 {
+			continue
+		}
+		if interactionType == InteractionWatchTime {
+			contributions = append(contributions, models.ScoreContribution{
+				Component: interactionType,
+				Value:     count,
+				Detail:    fmt.Sprintf("%g total watch time", count),
+			})
+			continue
+		}
+		weight := s.weights[interactionType]
+		contributions = append(contributions, models.ScoreContribution{
+			Component: interactionType,
+			Value:     count * weight,
+			Detail:    fmt.Sprintf("%g × %g", count, weight),
+		})
+	}
+	return contributions
+}
+
+// Explain returns the points earned per interaction type before decay, and the decay itself.
+func (s *TimeDecayScorer) Explain(state VideoState, at time.Time) []models.ScoreContribution {
+	contributions := s.linear.Explain(state, at)
+	undecayed := 0.0
+	for _, c := range contributions {
+		undecayed += c.Value
+	}
+
+	return append(contributions, models.ScoreContribution{
+		Component: "decay",
+		Value:     state.Score - undecayed,
+		Detail:    fmt.Sprintf("half-life %s", s.halfLife),
+	})
+}
+
+// Explain returns the value of each top-level term of the formula.
+func (s *FormulaScorer) Explain(state VideoState, at time.Time) []models.ScoreContribution {
+	vars := make(map[string]float64, len(state.Counters)+1)
+	for name, value := range state.Counters {
+		vars[name] = value
+	}
+	if !state.CreatedAt.IsZero() {
+		vars[VariableAgeHours] = at.Sub(state.CreatedAt).Hours()
+	}
+
+	terms := s.Formula().Terms(vars)
+	contributions := make([]models.ScoreContribution, len(terms))
+	for i, term := range terms {
+		contributions[i] = models.ScoreContribution{Component: "term", Value: term.Value, Detail: term.Expression}
+	}
+	return contributions
+}
@@ -0,0 +1,108 @@
+package scoring
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"ranking-service/internal/formula"
+)
+
+// StrategyFormula is the name of the FormulaScorer strategy.
+const StrategyFormula = "formula"
+
+// VariableAgeHours is the age of the video in hours, available to formulas.
+const VariableAgeHours = "age_hours"
+
+// FormulaVariables are the variables formulas may use.
+var FormulaVariables = []string{
+	CounterViews,
+	CounterLikes,
+	CounterComments,
+	CounterShares,
+	CounterReports,
+	CounterWatchTime,
+	VariableAgeHours,
+}
+
+// CompileFormula validates a scoring formula.
+func CompileFormula(expression string) (*formula.Formula, error) {
+	return formula.Compile(expression, FormulaVariables)
+}
+
+// FormulaScorer recomputes a video's score from its counters with a formula
+// that can be swapped at runtime. Videos are re-scored on their next interaction.
+type FormulaScorer struct {
+	current atomic.Pointer[formula.Formula]
+}
+
+// NewFormulaScorer creates a FormulaScorer with the given formula.
+func NewFormulaScorer(expression string) (*FormulaScorer, error) {
+	f, err := CompileFormula(expression)
+	if err != nil {
+		return nil, err
+	}
+	s := &FormulaScorer{}
+	s.Swap(f)
+	return s, nil
+}
+
+func (s *FormulaScorer) Name() string {
+	return StrategyFormula
+}
+
+// Formula returns the active formula.
+func (s *FormulaScorer) Formula() *formula.Formula {
+	return s.current.Load()
+}
+
+// Swap replaces the active formula.
+func (s *FormulaScorer) Swap(f *formula.Formula) {
+	s.current.Store(f)
+}
+
+// Score evaluates the formula over the video's counters, which already include the interaction.
+// When the formula has no finite value for the video, its score is left unchanged.
+func (s *FormulaScorer) Score(interaction Interaction, state VideoState) (ScoreUpdate, error) {
+	increments, err := CounterIncrements(interaction)
+	if err != nil {
+		return ScoreUpdate{}, err
+	}
+	return s.evaluate(increments, interaction.At, state)
+}
+
+// ScoreBulk evaluates the formula over the video's counters, which already include the
+// interactions. The weight of the interactions is not used: it follows from the formula.
+func (s *FormulaScorer) ScoreBulk(increments map[string]float64, weight float64, at time.Time, state VideoState) (ScoreUpdate, error) {
+	return s.evaluate(increments, at, state)
+}
+
+// evaluate scores the video's counters at the given time. The weight of the update is
+// what the increments add to the score of the counters without them.
+func (s *FormulaScorer) evaluate(increments map[string]float64, at time.Time, state VideoState) (ScoreUpdate, error) {
+	vars := make(map[string]float64, len(state.Counters)+1)
+	for name, value := range state.Counters {
+		vars[name] = value
+	}
+	if !state.CreatedAt.IsZero() {
+		vars[VariableAgeHours] = at.Sub(state.CreatedAt).Hours()
+	}
+
+	f := s.Formula()
+	score, err := f.Eval(vars)
+	if errors.Is(err, formula.ErrNotFinite) {
+		return ScoreUpdate{}, nil
+	}
+	if err != nil {
+		return ScoreUpdate{}, err
+	}
+
+	weight := score
+	for name, value := range increments {
+		vars[name] -= value
+	}
+	if previous, err := f.Eval(vars); err == nil {
+		weight = score - previous
+	}
+	return ScoreUpdate{Delta: score - state.Score, Score: &score, Weight: weight}, nil
+}
@@ -0,0 +1,57 @@
+package scoring
+
+import "time"
+
+// StrategyLinear is the name of the LinearScorer strategy.
+const StrategyLinear = "linear"
+
+// DefaultWeights are the points added to a video's score per interaction type.
+// watch_time is weighted by the interaction itself. Reports are only counted,
+// formulas can penalize them.
+var DefaultWeights = map[string]float64{
+	InteractionView:    0.1,
+	InteractionLike:    1.0,
+	InteractionComment: 1.5,
+	InteractionShare:   2.0,
+	InteractionReport:  0,
+}
+
+// LinearScorer adds a fixed weight per interaction type to the score.
+type LinearScorer struct {
+	weights map[string]float64
+}
+
+// NewLinearScorer creates a LinearScorer with the given weights per interaction type.
+func NewLinearScorer(weights map[string]float64) *LinearScorer {
+	return &LinearScorer{weights: weights}
+}
+
+func (s *LinearScorer) Name() string {
+	return StrategyLinear
+}
+
+// Score returns the weight of the interaction type as a delta.
+func (s *LinearScorer) Score(interaction Interaction, state VideoState) (ScoreUpdate, error) {
+	delta, err := s.Weight(interaction)
+	if err != nil {
+		return ScoreUpdate{}, err
+	}
+	return ScoreUpdate{Delta: delta, Weight: delta}, nil
+}
+
+// ScoreBulk adds the weight of the interactions to the score.
+func (s *LinearScorer) ScoreBulk(increments map[string]float64, weight float64, at time.Time, state VideoState) (ScoreUpdate, error) {
+	return ScoreUpdate{Delta: weight, Weight: weight}, nil
+}
+
+// Weight returns the points an interaction is worth.
+func (s *LinearScorer) Weight(interaction Interaction) (float64, error) {
+	if interaction.Type == InteractionWatchTime {
+		return interaction.Weight, nil // Weight provided dynamically.
+	}
+	weight, ok := s.weights[interaction.Type]
+	if !ok {
+		return 0, ErrUnknownInteraction
+	}
+	return weight, nil
+}
@@ -0,0 +1,93 @@
+// Package scoring turns interactions into score updates.
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ranking-service/config"
+)
+
+// Interaction types.
+const (
+	InteractionView      = "view"
+	InteractionLike      = "like"
+	InteractionComment   = "comment"
+	InteractionShare     = "share"
+	InteractionWatchTime = "watch_time"
+	InteractionReport    = "report"
+)
+
+// ErrUnknownInteraction is returned by a Scorer for interaction types it does not support.
+var ErrUnknownInteraction = errors.New("unknown interaction type")
+
+// Interaction is a single interaction with a video.
+type Interaction struct {
+	VideoID string
+	UserID  string // Owner of the video.
+	Type    string
+	Weight  float64 // Used for interactions like watch_time.
+	At      time.Time
+}
+
+// VideoState is what is known about a video before the interaction is applied.
+type VideoState struct {
+	Exists            bool // False for videos receiving their first interaction.
+	Score             float64
+	CreatedAt         time.Time
+	LastInteractionAt time.Time
+	// Counters of the video per counter name, including the interaction being scored.
+	Counters map[string]float64
+}
+
+// ScoreUpdate is the change a Scorer wants applied to a video's score.
+type ScoreUpdate struct {
+	// Delta is the change of the score.
+	Delta float64
+	// Score, when set, replaces the stored score instead of adding Delta to it.
+	Score *float64
+	// Weight is what the interaction itself is worth, without the re-scoring of
+	// the previous score that Delta may include.
+	Weight float64
+}
+
+// Scorer computes how an interaction changes the score of a video.
+type Scorer interface {
+	// Name returns the strategy name used in the configuration.
+	Name() string
+	Score(interaction Interaction, state VideoState) (ScoreUpdate, error)
+}
+
+// BulkScorer is implemented by scorers that can score several interactions of a video
+// at once, such as those released from quarantine.
+type BulkScorer interface {
+	// ScoreBulk returns the update of the score for interactions worth weight in total,
+	// which incremented the counters by increments. The counters of the state already
+	// include the increments.
+	ScoreBulk(increments map[string]float64, weight float64, at time.Time, state VideoState) (ScoreUpdate, error)
+}
+
+// New returns the Scorer selected by cfg.Strategy.
+func New(cfg config.RankingConfig) (Scorer, error) {
+	switch cfg.Strategy {
+	case "", StrategyLinear:
+		return NewLinearScorer(DefaultWeights), nil
+	case StrategyTimeDecay:
+		if cfg.DecayHalfLife <= 0 {
+			return nil, fmt.Errorf("%s strategy requires a positive half-life", StrategyTimeDecay)
+		}
+		return NewTimeDecayScorer(NewLinearScorer(DefaultWeights), cfg.DecayHalfLife), nil
+	case StrategyFormula:
+		if cfg.Formula == "" {
+			return nil, fmt.Errorf("%s strategy requires a formula", StrategyFormula)
+		}
+		scorer, err := NewFormulaScorer(cfg.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("invalid formula: %w", err)
+		}
+		return scorer, nil
+	default:
+		return nil, fmt.Errorf("unknown ranking strategy %q", cfg.Strategy)
+	}
+}
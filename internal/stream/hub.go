@@ -0,0 +1,235 @@
+// Package stream pushes live leaderboard changes to subscribers.
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ranking-service/models"
+)
+
+// subscriberBuffer is how many events a slow subscriber may lag behind. Beyond it
+// the oldest pending event is dropped: every event carries the full top, so the
+// subscriber catches up with the next one.
+const subscriberBuffer = 8
+
+// FetchFunc retrieves the current top limit videos of the leaderboard.
+type FetchFunc func(limit int) ([]models.RankedVideo, error)
+
+// Hub tracks one leaderboard per top size with its subscribers. Ranking updates
+// mark the leaderboards they may affect as dirty, and dirty leaderboards are
+// refreshed at most once per interval, so bursts of updates are coalesced.
+type Hub struct {
+	fetch    FetchFunc
+	interval time.Duration
+
+	mu     sync.Mutex
+	boards map[int]*board
+}
+
+type board struct {
+	limit       int
+	top         []models.RankedVideo
+	dirty       bool
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of a leaderboard.
+type Subscription struct {
+	limit  int
+	events chan models.LeaderboardEvent
+}
+
+// Events returns the channel of events, closed when the subscription ends.
+func (s *Subscription) Events() <-chan models.LeaderboardEvent {
+	return s.events
+}
+
+// NewHub creates a Hub refreshing dirty leaderboards every interval.
+func NewHub(fetch FetchFunc, interval time.Duration) *Hub {
+	return &Hub{fetch: fetch, interval: interval, boards: make(map[int]*board)}
+}
+
+// Subscribe subscribes to the top limit videos. The first event is a snapshot of
+// the current top.
+func (h *Hub) Subscribe(limit int) (*Subscription, error) {
+	s := &Subscription{limit: limit, events: make(chan models.LeaderboardEvent, subscriberBuffer)}
+
+	h.mu.Lock()
+	_, ok := h.boards[limit]
+	h.mu.Unlock()
+	var top []models.RankedVideo
+	if !ok {
+		// Fetch outside the lock, the leaderboard may have been created meanwhile.
+		var err error
+		if top, err = h.fetch(limit); err != nil {
+			return nil, err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	b, ok := h.boards[limit]
+	if !ok {
+		b = &board{limit: limit, top: top, subscribers: make(map[*Subscription]struct{})}
+		h.boards[limit] = b
+	}
+	b.subscribers[s] = struct{}{}
+	s.events <- models.LeaderboardEvent{Type: models.LeaderboardEventSnapshot, Top: b.top}
+	return s, nil
+}
+
+// Unsubscribe ends a subscription. Leaderboards without subscribers are dropped.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	b, ok := h.boards[s.limit]
+	if !ok {
+		return
+	}
+	if _, ok := b.subscribers[s]; !ok {
+		return
+	}
+	delete(b.subscribers, s)
+	close(s.events)
+	if len(b.subscribers) == 0 {
+		delete(h.boards, s.limit)
+	}
+}
+
+// Notify marks the leaderboards the update may affect as dirty: those holding the
+// video, not full yet, or whose last score it reaches.
+func (h *Hub) Notify(update models.RankingUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, b := range h.boards {
+		if b.dirty || update.VideoID == "" || len(b.top) < b.limit || update.Score >= b.top[len(b.top)-1].Score {
+			b.dirty = true
+			continue
+		}
+		for _, v := range b.top {
+			if v.VideoID == update.VideoID {
+				b.dirty = true
+				break
+			}
+		}
+	}
+}
+
+// Run refreshes the dirty leaderboards every interval until the context is done,
+// then ends every subscription.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-ticker.C:
+			h.refresh()
+		}
+	}
+}
+
+// refresh fetches every dirty leaderboard and sends its changes to its subscribers.
+func (h *Hub) refresh() {
+	h.mu.Lock()
+	var dirty []int
+	for limit, b := range h.boards {
+		if b.dirty {
+			b.dirty = false
+			dirty = append(dirty, limit)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, limit := range dirty {
+		top, err := h.fetch(limit)
+		if err != nil {
+			slog.Error("Failed to refresh live leaderboard", "limit", limit, "error", err)
+			h.mu.Lock()
+			if b, ok := h.boards[limit]; ok {
+				b.dirty = true
+			}
+			h.mu.Unlock()
+			continue
+		}
+
+		h.mu.Lock()
+		b, ok := h.boards[limit]
+		if !ok {
+			h.mu.Unlock()
+			continue
+		}
+		event, changed := Diff(b.top, top)
+		b.top = top
+		if changed {
+			for s := range b.subscribers {
+				send(s, event)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// send delivers an event without blocking, dropping the oldest pending event of a slow subscriber.
+func send(s *Subscription, event models.LeaderboardEvent) {
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+		select {
+		case <-s.events:
+		default:
+		}
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for limit, b := range h.boards {
+		for s := range b.subscribers {
+			close(s.events)
+		}
+		delete(h.boards, limit)
+	}
+}
+
+// Diff returns the update event turning the previous top into the next one, and
+// whether anything changed, scores included.
+func Diff(previous, next []models.RankedVideo) (models.LeaderboardEvent, bool) {
+	event := models.LeaderboardEvent{Type: models.LeaderboardEventUpdate, Top: next}
+
+	before := make(map[string]int, len(previous))
+	for i, v := range previous {
+		before[v.VideoID] = i + 1
+	}
+	after := make(map[string]bool, len(next))
+	for i, v := range next {
+		after[v.VideoID] = true
+		from, ok := before[v.VideoID]
+		switch {
+		case !ok:
+			event.Entered = append(event.Entered, v)
+		case from != i+1:
+			event.Moved = append(event.Moved, models.LeaderboardMove{VideoID: v.VideoID, From: from, To: i + 1})
+		}
+	}
+	for _, v := range previous {
+		if !after[v.VideoID] {
+			event.Exited = append(event.Exited, v.VideoID)
+		}
+	}
+
+	changed := len(event.Entered) > 0 || len(event.Exited) > 0 || len(event.Moved) > 0
+	for i := 0; !changed && i < len(next); i++ {
+		changed = next[i] != previous[i]
+	}
+	return event, changed
+}
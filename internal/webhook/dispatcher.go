@@ -0,0 +1,303 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"ranking-service/config"
+	"ranking-service/internal/repository"
+	"ranking-service/models"
+)
+
+// leaderLock elects the replica that watches the top for new entries.
+const leaderLock = "webhooks"
+
+// TopFunc retrieves the current top limit videos of the global leaderboard.
+type TopFunc func(limit int) ([]models.RankedVideo, error)
+
+// Dispatcher turns ranking changes into webhook deliveries and sends them.
+//
+// Score thresholds are checked on every score update by the replica handling it.
+// New top entries are detected by comparing the top between ticks, on a single
+// replica elected through a Redis lock. Deliveries are queued in PostgreSQL and
+// sent by every replica, each claiming its own batch.
+type Dispatcher struct {
+	postgres repository.PostgresRepository
+	redis    repository.RedisRepository
+	top      TopFunc
+	cfg      config.RankingConfig
+	client   *http.Client
+	owner    string
+
+	mu            sync.RWMutex
+	subscriptions []models.WebhookSubscription
+	// previousTop holds the videos of each watched top size at the previous tick.
+	previousTop map[int]map[string]bool
+}
+
+// NewDispatcher creates a Dispatcher. Call Run to send deliveries.
+func NewDispatcher(postgres repository.PostgresRepository, redis repository.RedisRepository, top TopFunc, cfg config.RankingConfig) *Dispatcher {
+	if cfg.WebhookInterval <= 0 {
+		cfg.WebhookInterval = 5 * time.Second
+	}
+	if cfg.WebhookTimeout <= 0 {
+		cfg.WebhookTimeout = 10 * time.Second
+	}
+	if cfg.WebhookMaxAttempts <= 0 {
+		cfg.WebhookMaxAttempts = 8
+	}
+	if cfg.WebhookBackoff <= 0 {
+		cfg.WebhookBackoff = 30 * time.Second
+	}
+	if cfg.WebhookMaxBackoff < cfg.WebhookBackoff {
+		cfg.WebhookMaxBackoff = max(time.Hour, cfg.WebhookBackoff)
+	}
+	if cfg.WebhookBatchSize <= 0 {
+		cfg.WebhookBatchSize = 50
+	}
+
+	owner, err := NewSecret()
+	if err != nil {
+		owner = time.Now().String()
+	}
+	return &Dispatcher{
+		postgres: postgres,
+		redis:    redis,
+		top:      top,
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.WebhookTimeout},
+		owner:    owner,
+	}
+}
+
+// Run reloads the subscriptions, watches the top and sends the due deliveries every
+// interval until the context is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.WebhookInterval)
+	defer ticker.Stop()
+	for {
+		if err := d.Reload(); err != nil {
+			slog.Error("Failed to load webhook subscriptions", "error", err)
+		}
+		if err := d.DetectTopEntries(); err != nil {
+			slog.Error("Failed to detect top entries for webhooks", "error", err)
+		}
+		if _, err := d.DeliverDue(ctx); err != nil {
+			slog.Error("Failed to deliver webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload refreshes the cached subscriptions.
+func (d *Dispatcher) Reload() error {
+	subscriptions, err := d.postgres.ListWebhookSubscriptions()
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.subscriptions = subscriptions
+	d.mu.Unlock()
+	return nil
+}
+
+// ScoreChanged queues the score_threshold events of a video whose score went from
+// before to after. Webhooks are best effort: failures are logged.
+func (d *Dispatcher) ScoreChanged(videoID, userID string, before, after float64) {
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	d.mu.RLock()
+	for _, s := range d.subscriptions {
+		if s.Event != models.WebhookEventScoreThreshold || (s.UserID != "" && s.UserID != userID) {
+			continue
+		}
+		if before < s.Threshold && after >= s.Threshold {
+			deliveries = append(deliveries, newDelivery(s, models.WebhookEvent{
+				Type:       models.WebhookEventScoreThreshold,
+				VideoID:    videoID,
+				UserID:     userID,
+				Score:      after,
+				Threshold:  s.Threshold,
+				OccurredAt: now,
+			}))
+		}
+	}
+	d.mu.RUnlock()
+
+	if err := d.postgres.CreateWebhookDeliveries(deliveries); err != nil {
+		slog.Error("Failed to queue webhook deliveries", "error", err)
+	}
+}
+
+// DetectTopEntries queues the top_entered events of the videos that entered a
+// watched top since the previous tick. Only the elected replica watches the top;
+// the first tick after an election sets the baseline.
+func (d *Dispatcher) DetectTopEntries() error {
+	d.mu.RLock()
+	var watching []models.WebhookSubscription
+	maxN := 0
+	for _, s := range d.subscriptions {
+		if s.Event == models.WebhookEventTopEntered {
+			watching = append(watching, s)
+			maxN = max(maxN, s.TopN)
+		}
+	}
+	d.mu.RUnlock()
+	if len(watching) == 0 {
+		d.previousTop = nil
+		return nil
+	}
+
+	leader, err := d.redis.AcquireLock(leaderLock, d.owner, 3*d.cfg.WebhookInterval)
+	if err != nil {
+		return err
+	}
+	if !leader {
+		d.previousTop = nil
+		return nil
+	}
+
+	top, err := d.top(maxN)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(top))
+	for i, v := range top {
+		ids[i] = v.VideoID
+	}
+	catalog, err := d.postgres.GetVideos(ids)
+	if err != nil {
+		return err
+	}
+	owners := make(map[string]string, len(catalog))
+	for _, v := range catalog {
+		owners[v.VideoID] = v.UserID
+	}
+
+	now := time.Now()
+	current := make(map[int]map[string]bool)
+	var deliveries []models.WebhookDelivery
+	for _, s := range watching {
+		n := min(s.TopN, len(top))
+		if current[s.TopN] == nil {
+			current[s.TopN] = make(map[string]bool, n)
+			for _, v := range top[:n] {
+				current[s.TopN][v.VideoID] = true
+			}
+		}
+		previous, ok := d.previousTop[s.TopN]
+		if !ok {
+			continue
+		}
+		for i, v := range top[:n] {
+			if previous[v.VideoID] || (s.UserID != "" && s.UserID != owners[v.VideoID]) {
+				continue
+			}
+			deliveries = append(deliveries, newDelivery(s, models.WebhookEvent{
+				Type:       models.WebhookEventTopEntered,
+				VideoID:    v.VideoID,
+				UserID:     owners[v.VideoID],
+				Score:      v.Score,
+				Rank:       i + 1,
+				TopN:       s.TopN,
+				OccurredAt: now,
+			}))
+		}
+	}
+	d.previousTop = current
+	return d.postgres.CreateWebhookDeliveries(deliveries)
+}
+
+// newDelivery queues an event for a subscription.
+func newDelivery(s models.WebhookSubscription, event models.WebhookEvent) models.WebhookDelivery {
+	payload, _ := json.Marshal(event)
+	return models.WebhookDelivery{
+		SubscriptionID: s.ID,
+		Event:          event.Type,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  event.OccurredAt,
+	}
+}
+
+// DeliverDue sends a batch of due deliveries and returns how many were attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := d.postgres.ClaimDueWebhookDeliveries(time.Now(), 2*d.cfg.WebhookTimeout, d.cfg.WebhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range deliveries {
+		if err := d.deliver(ctx, &deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt at a delivery and records its outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Attempts++
+
+	subscription, err := d.postgres.GetWebhookSubscription(delivery.SubscriptionID)
+	switch {
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "subscription deleted"
+		return d.postgres.UpdateWebhookDelivery(delivery)
+	case err != nil:
+		return err
+	}
+
+	status, err := d.post(ctx, subscription, delivery, now)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.cfg.WebhookMaxAttempts {
+			delivery.Status = models.WebhookDeliveryDead
+			slog.Warn("Webhook delivery dead-lettered", "deliveryID", delivery.ID, "subscriptionID", subscription.ID, "error", err)
+		} else {
+			delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts, d.cfg.WebhookBackoff, d.cfg.WebhookMaxBackoff))
+		}
+	}
+	return d.postgres.UpdateWebhookDelivery(delivery)
+}
+
+// post sends a delivery to the subscription URL. Any 2xx response acknowledges it.
+func (d *Dispatcher) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, payload, now))
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, fmt.Sprint(delivery.ID))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
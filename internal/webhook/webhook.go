@@ -0,0 +1,52 @@
+// Package webhook notifies partners of ranking events over signed HTTP callbacks.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Headers of a delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign returns the signature header of a payload sent at the given time:
+// "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<payload>">". Receivers
+// recompute it with the subscription secret and reject old timestamps to
+// prevent replays.
+func Sign(secret string, payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Backoff returns the delay before retrying after the given number of failed
+// attempts: base, doubling with each attempt, up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -1,17 +1,610 @@
 package models
 
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Video lifecycle statuses.
+const (
+	VideoStatusActive  = "active"
+	VideoStatusDeleted = "deleted"
+)
+
+// Moderation statuses. Every status other than visible pulls the video out of
+// all rankings while its score keeps accumulating.
+const (
+	ModerationVisible     = "visible"
+	ModerationHidden      = "hidden"
+	ModerationBanned      = "banned"
+	ModerationQuarantined = "quarantined"
+)
+
+// IsValidModerationStatus reports whether status is a known moderation status.
+func IsValidModerationStatus(status string) bool {
+	switch status {
+	case ModerationVisible, ModerationHidden, ModerationBanned, ModerationQuarantined:
+		return true
+	}
+	return false
+}
+
 // Video represents a video record in the database.
 type Video struct {
-	VideoID string `gorm:"primaryKey"`
-	UserID  string `gorm:"index"` // Index this field to optimize queries by user_id.
-	Score   float64
+	VideoID  string `gorm:"primaryKey"`
+	UserID   string `gorm:"index"` // Index this field to optimize queries by user_id.
+	Title    string
+	Category string `gorm:"index"`
+	Status   string `gorm:"index;default:active"`
+	Score    float64
+
+	ModerationStatus string `gorm:"index;default:visible"`
+	ModerationReason string
+	ModeratedAt      *time.Time
+
+	LastInteractionAt *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index" swaggertype:"string"` // Set when the video is soft-deleted.
 }
 
 // InteractionRequest represents the payload for updating video score.
 // Note: UserID here represents the owner of the video.
 type InteractionRequest struct {
 	VideoID string  `uri:"video_id" json:"video_id" validate:"required"`
-	Type    string  `json:"type" validate:"required"`    // e.g., view, like, comment, share, watch_time
+	Type    string  `json:"type" validate:"required"`    // e.g., view, like, comment, share, watch_time, report
 	Weight  float64 `json:"weight" validate:"required"`  // Used for interactions like watch_time.
 	UserID  string  `json:"user_id" validate:"required"` // Required: Owner of the video.
+	// ViewerID identifies the viewer who interacted, for per-viewer rate limits and score caps.
+	ViewerID string `json:"viewer_id"`
+	// Token is the interaction token of the video served to the viewer in a top list,
+	// required when interaction tokens are enabled.
+	Token string `json:"token"`
+}
+
+// BatchInteractionRequest represents the payload for ingesting interactions in bulk.
+type BatchInteractionRequest struct {
+	Interactions []InteractionRequest `json:"interactions" validate:"required"`
+}
+
+// RejectedInteraction is an interaction of a batch that was not recorded.
+type RejectedInteraction struct {
+	Index   int    `json:"index"` // Position in the batch, from 0.
+	VideoID string `json:"videoID"`
+	Error   string `json:"error"`
+}
+
+// BatchInteractionResponse summarizes the ingestion of a batch of interactions.
+type BatchInteractionResponse struct {
+	Accepted int                   `json:"accepted"`
+	Rejected []RejectedInteraction `json:"rejected"`
+}
+
+// CreateVideoRequest represents the payload for registering a video in the catalog.
+type CreateVideoRequest struct {
+	VideoID   string     `json:"video_id" validate:"required"`
+	UserID    string     `json:"user_id" validate:"required"` // Owner of the video.
+	Title     string     `json:"title"`
+	Category  string     `json:"category"`
+	CreatedAt *time.Time `json:"created_at"` // Optional upload time, defaults to now.
+}
+
+// UpdateVideoRequest represents the payload for updating catalog metadata.
+// Only non-nil fields are applied.
+type UpdateVideoRequest struct {
+	Title    *string `json:"title"`
+	Category *string `json:"category"`
+	UserID   *string `json:"user_id"`
+}
+
+// ModerationEvent is an audit record of a moderation decision.
+type ModerationEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	VideoID   string `gorm:"index"`
+	Status    string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// ModerationRequest represents the payload for changing a video's moderation status.
+type ModerationRequest struct {
+	Status string `json:"status" validate:"required"` // visible, hidden, banned or quarantined
+	Reason string `json:"reason"`
+}
+
+// Editorial rule kinds.
+const (
+	EditorialPin   = "pin"
+	EditorialBoost = "boost"
+)
+
+// EditorialRule pins a video at a fixed position or boosts its score in the
+// leaderboards between StartsAt and EndsAt.
+type EditorialRule struct {
+	ID         uint    `gorm:"primaryKey"`
+	VideoID    string  `gorm:"index"`
+	Kind       string  // pin or boost
+	Position   int     // 1-based position, for pins.
+	Multiplier float64 // Score multiplier, for boosts.
+	Note       string
+	StartsAt   time.Time `gorm:"index"`
+	EndsAt     time.Time `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+// EditorialRuleRequest represents the payload for creating an editorial rule.
+type EditorialRuleRequest struct {
+	VideoID    string     `json:"video_id" validate:"required"`
+	Kind       string     `json:"kind" validate:"required"` // pin or boost
+	Position   int        `json:"position"`                 // Required for pins.
+	Multiplier float64    `json:"multiplier"`               // Required for boosts.
+	Note       string     `json:"note"`
+	StartsAt   *time.Time `json:"starts_at"` // Defaults to now.
+	EndsAt     time.Time  `json:"ends_at" validate:"required"`
+}
+
+// RankedVideo is an entry of a leaderboard response.
+type RankedVideo struct {
+	VideoID     string  `json:"videoID"`
+	Score       float64 `json:"score"`
+	Editorial   string  `json:"editorial,omitempty"`   // pin or boost when placed by an editor.
+	Exploration bool    `json:"exploration,omitempty"` // Set on slots given to cold-start exploration.
+	// Movement of the organic rank since a previous leaderboard snapshot, set when both
+	// are available.
+	PreviousRank int    `json:"previousRank,omitempty"` // 0 when the video was not ranked.
+	Movement     string `json:"movement,omitempty"`
+	RankChange   int    `json:"rankChange,omitempty"` // Positive when the video moved up.
+	// InteractionToken is the token of this impression, set when a viewer ID is given.
+	InteractionToken string `json:"interactionToken,omitempty"`
+}
+
+// Leaderboard identifies a Redis leaderboard: the global ranking by default, the
+// videos of an owner, or the score gained during the current window.
+type Leaderboard struct {
+	Owner  string
+	Window time.Duration
+}
+
+// Name describes the leaderboard, e.g. global, owner-u1 or window-24h.
+func (l Leaderboard) Name() string {
+	switch {
+	case l.Owner != "":
+		return "owner-" + l.Owner
+	case l.Window > 0:
+		return "window-" + strings.TrimSuffix(strings.TrimSuffix(l.Window.String(), "0s"), "0m")
+	}
+	return "global"
+}
+
+// ExportedVideo is a row of a leaderboard export.
+type ExportedVideo struct {
+	Rank     int     `json:"rank"`
+	VideoID  string  `json:"videoID"`
+	Score    float64 `json:"score"`
+	UserID   string  `json:"userID,omitempty"`
+	Category string  `json:"category,omitempty"`
+}
+
+// Rank movements since a previous leaderboard snapshot.
+const (
+	MovementUp   = "up"
+	MovementDown = "down"
+	MovementSame = "same"
+	MovementNew  = "new"
+)
+
+// UserTopVideo is an entry of a user's top videos response.
+type UserTopVideo struct {
+	Video
+	Editorial string `json:"editorial,omitempty"` // pin or boost when placed by an editor.
+	// InteractionToken is the token of this impression, set when a viewer ID is given.
+	InteractionToken string `json:"interactionToken,omitempty"`
+}
+
+// ScoringFormula is a saved scoring formula. The latest one is active.
+type ScoringFormula struct {
+	ID         uint `gorm:"primaryKey"`
+	Expression string
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// ScoringFormulaRequest represents the payload for replacing the scoring formula.
+type ScoringFormulaRequest struct {
+	Expression string `json:"expression" validate:"required"` // e.g. likes*1 + shares*2 + log1p(views)*0.5 - reports*5
+}
+
+// VideoStats holds the interaction counters of a video.
+type VideoStats struct {
+	VideoID   string `gorm:"primaryKey"`
+	Views     int64
+	Likes     int64
+	Comments  int64
+	Shares    int64
+	Reports   int64
+	WatchTime float64 // Total watch time.
+	// Interactions beyond the per-viewer caps, and the score they were not given.
+	CappedInteractions int64
+	CappedScore        float64
+	UpdatedAt          time.Time
+}
+
+// VideoStatsResponse represents the statistics of a video.
+type VideoStatsResponse struct {
+	VideoID        string           `json:"videoID"`
+	Counts         map[string]int64 `json:"counts"` // Interactions per counter: views, likes, comments, shares, reports.
+	TotalWatchTime float64          `json:"totalWatchTime"`
+	Score          float64          `json:"score"`
+	// Interactions beyond the per-viewer caps, counted but not scored, and the score they were not given.
+	CappedInteractions int64   `json:"cappedInteractions"`
+	CappedScore        float64 `json:"cappedScore"`
+}
+
+// RankInfo is the position of a video in the global ranking.
+type RankInfo struct {
+	VideoID string  `json:"videoID"`
+	Rank    int64   `json:"rank"` // 1-based, 0 when the video is not ranked or hidden.
+	Score   float64 `json:"score"`
+	// Above is the video at the next-higher rank, Gap the score it takes to overtake it.
+	Above *RankedVideo `json:"above,omitempty"`
+	Gap   float64      `json:"gap"`
+}
+
+// ScoreContribution is a component of a video's score.
+type ScoreContribution struct {
+	Component string  `json:"component"`
+	Value     float64 `json:"value"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// ScoreExplanation breaks a video's score down.
+type ScoreExplanation struct {
+	VideoID  string `json:"videoID"`
+	Strategy string `json:"strategy"`
+	Formula  string `json:"formula,omitempty"`
+	// Score is the stored score and Contributions its breakdown under the active strategy.
+	Score         float64             `json:"score"`
+	Contributions []ScoreContribution `json:"contributions"`
+	// Adjustments are applied when leaderboards are read: freshness, editorial boosts and moderation.
+	Adjustments    []ScoreContribution `json:"adjustments"`
+	EffectiveScore float64             `json:"effectiveScore"`
+	Rank           *RankInfo           `json:"rank"`
+}
+
+// ScoreSample is the score of a video at a point in time, recorded periodically
+// for the videos that received interactions.
+type ScoreSample struct {
+	VideoID   string    `gorm:"primaryKey" json:"videoID"`
+	SampledAt time.Time `gorm:"primaryKey;index" json:"sampledAt"`
+	Score     float64   `json:"score"`
+}
+
+// ScoreBucket aggregates the score samples of a time bucket. Buckets without samples
+// carry the last known score forward; all values are null before the first sample.
+type ScoreBucket struct {
+	Start   time.Time `json:"start"`
+	Score   *float64  `json:"score"` // Score at the end of the bucket.
+	Min     *float64  `json:"min"`
+	Max     *float64  `json:"max"`
+	Samples int       `json:"samples"`
+}
+
+// ScoreHistoryResponse is the score time series of a video.
+type ScoreHistoryResponse struct {
+	VideoID    string        `json:"videoID"`
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Resolution string        `json:"resolution"`
+	Buckets    []ScoreBucket `json:"buckets"`
+}
+
+// SnapshotRankingGlobal is the ranking of the global leaderboard snapshots.
+const SnapshotRankingGlobal = "global"
+
+// LeaderboardSnapshot is a copy of a ranking taken at a point in time.
+type LeaderboardSnapshot struct {
+	ID      uint            `gorm:"primaryKey" json:"id"`
+	Ranking string          `gorm:"uniqueIndex:idx_snapshot_ranking_time" json:"ranking"`
+	TakenAt time.Time       `gorm:"uniqueIndex:idx_snapshot_ranking_time" json:"takenAt"`
+	Entries []SnapshotEntry `gorm:"foreignKey:SnapshotID;constraint:OnDelete:CASCADE" json:"entries,omitempty"`
+}
+
+// SnapshotEntry is a video of a leaderboard snapshot. The owner and category are
+// copied from the catalog so that the snapshot can be re-ranked and filtered later.
+type SnapshotEntry struct {
+	SnapshotID uint    `gorm:"primaryKey" json:"-"`
+	Rank       int     `gorm:"primaryKey" json:"rank"` // 1-based.
+	VideoID    string  `json:"videoID"`
+	UserID     string  `gorm:"index" json:"userID"`
+	Category   string  `json:"category"`
+	Score      float64 `json:"score"`
+}
+
+// SnapshotDiffEntry is the movement of a video between two leaderboard snapshots.
+type SnapshotDiffEntry struct {
+	VideoID       string  `json:"videoID"`
+	Rank          int     `json:"rank"`         // 0 when the video dropped out.
+	PreviousRank  int     `json:"previousRank"` // 0 when the video is new.
+	Score         float64 `json:"score"`
+	PreviousScore float64 `json:"previousScore"`
+	Movement      string  `json:"movement"` // up, down, same, new or dropped.
+	RankChange    int     `json:"rankChange"`
+}
+
+// MovementDropped marks the videos that left the compared top of a snapshot diff.
+const MovementDropped = "dropped"
+
+// SnapshotDiff compares the top of the leaderboard between two snapshots.
+type SnapshotDiff struct {
+	From    time.Time           `json:"from"` // Time of the older snapshot.
+	To      time.Time           `json:"to"`   // Time of the newer snapshot.
+	Entries []SnapshotDiffEntry `json:"entries"`
+	Dropped []SnapshotDiffEntry `json:"dropped"`
+}
+
+// RankingUpdate notifies the replicas that a ranking changed. Updates without a
+// video, e.g. after an editorial change, may have reordered any video.
+type RankingUpdate struct {
+	VideoID string  `json:"videoID,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// Leaderboard stream event types.
+const (
+	LeaderboardEventSnapshot = "snapshot"
+	LeaderboardEventUpdate   = "update"
+)
+
+// LeaderboardEvent is a message of the live leaderboard stream. The first event of
+// a stream is a snapshot; updates list the changes since the previous event along
+// with the full top, so that clients can resync after missed events.
+type LeaderboardEvent struct {
+	Type    string            `json:"type"`
+	Top     []RankedVideo     `json:"top"`
+	Entered []RankedVideo     `json:"entered,omitempty"`
+	Exited  []string          `json:"exited,omitempty"`
+	Moved   []LeaderboardMove `json:"moved,omitempty"`
+}
+
+// LeaderboardMove is a video that changed position within the top, 1-based.
+type LeaderboardMove struct {
+	VideoID string `json:"videoID"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+}
+
+// Webhook event types.
+const (
+	// WebhookEventTopEntered fires when a video enters the top N of the global leaderboard.
+	WebhookEventTopEntered = "top_entered"
+	// WebhookEventScoreThreshold fires when a video's score reaches a threshold.
+	WebhookEventScoreThreshold = "score_threshold"
+)
+
+// Webhook delivery statuses. Dead deliveries exhausted their attempts and form the dead-letter list.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription sends the events of a type matching its filters to a URL.
+type WebhookSubscription struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"-"` // Signs the deliveries, only returned on creation.
+	Event  string `json:"event"`
+	// Filters: TopN for top_entered, Threshold for score_threshold, and
+	// optionally the owner of the videos for both.
+	TopN      int       `json:"topN,omitempty"`
+	Threshold float64   `json:"threshold,omitempty"`
+	UserID    string    `json:"userID,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreatedWebhookSubscription is a new subscription along with its signing secret.
+type CreatedWebhookSubscription struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookSubscriptionRequest represents the payload for creating a webhook subscription.
+type WebhookSubscriptionRequest struct {
+	URL       string   `json:"url" validate:"required"`
+	Event     string   `json:"event" validate:"required"` // top_entered or score_threshold
+	TopN      int      `json:"top_n"`                     // For top_entered, defaults to 10.
+	Threshold *float64 `json:"threshold"`                 // Required for score_threshold.
+	UserID    string   `json:"user_id"`                   // Only notify about this creator's videos.
+	Secret    string   `json:"secret"`                    // Generated when empty.
+}
+
+// WebhookEvent is the payload of a webhook delivery.
+type WebhookEvent struct {
+	Type       string    `json:"type"`
+	VideoID    string    `json:"videoID"`
+	UserID     string    `json:"userID,omitempty"`
+	Score      float64   `json:"score"`
+	Rank       int       `json:"rank,omitempty"`      // For top_entered.
+	TopN       int       `json:"topN,omitempty"`      // For top_entered.
+	Threshold  float64   `json:"threshold,omitempty"` // For score_threshold.
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// WebhookDelivery is a webhook event queued for, or delivered to, a subscription.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"index" json:"subscriptionID"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `gorm:"index" json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"nextAttemptAt"`
+	LastError      string     `json:"lastError,omitempty"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// ImportCheckpoint records how far a bulk import has gone, so that it can resume
+// after a failure. It is saved with each imported batch.
+type ImportCheckpoint struct {
+	Name      string `gorm:"primaryKey"`
+	Kind      string // videos or interactions.
+	Position  int64  // Records of the input consumed, whether imported or rejected.
+	Imported  int64
+	Rejected  int64
+	Done      bool
+	UpdatedAt time.Time
+}
+
+// ImportedScore is the state of a video written to Redis by a bulk import.
+type ImportedScore struct {
+	VideoID         string
+	OwnerID         string
+	PreviousOwnerID string // Owner whose leaderboard the video leaves, if it changed owner.
+	Score           float64
+	// Counters replace the video's interaction counters when set.
+	Counters map[string]float64
+}
+
+// APIKey authenticates a client application. Only a hash of its secret is stored.
+type APIKey struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"` // Start of the secret, to recognize the key.
+	Hash   string `gorm:"uniqueIndex" json:"-"`
+	// PreviousHash keeps the secret replaced by the last rotation valid until PreviousExpiresAt.
+	PreviousHash      string     `gorm:"index" json:"-"`
+	PreviousExpiresAt *time.Time `json:"previousExpiresAt,omitempty"`
+	Scopes            string     `json:"scopes"`         // Space-separated, e.g. "interactions:write rankings:read".
+	PerMinuteQuota    int        `json:"perMinuteQuota"` // Requests per minute, 0 is unlimited.
+	DailyQuota        int        `json:"dailyQuota"`     // Requests per UTC day, 0 is unlimited.
+	CreatedAt         time.Time  `json:"createdAt"`
+	RotatedAt         *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKey is an API key with its secret, only returned on creation and rotation.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRequest represents the payload for creating an API key.
+type APIKeyRequest struct {
+	Name           string `json:"name" validate:"required"`
+	Scopes         string `json:"scopes" validate:"required"` // Space-separated scopes.
+	PerMinuteQuota int    `json:"per_minute_quota"`
+	DailyQuota     int    `json:"daily_quota"`
+}
+
+// RotateAPIKeyRequest represents the payload for rotating an API key.
+type RotateAPIKeyRequest struct {
+	GracePeriod string `json:"grace_period"` // How long the previous secret stays valid, e.g. 1h. Defaults to 0.
+}
+
+// APIKeyUsage counts the requests of an API key against its quotas.
+type APIKeyUsage struct {
+	Minute         int64              `json:"minute"` // Requests during the current minute.
+	Today          int64              `json:"today"`  // Requests during the current UTC day.
+	PerMinuteQuota int                `json:"perMinuteQuota"`
+	DailyQuota     int                `json:"dailyQuota"`
+	Daily          []APIKeyDailyUsage `json:"daily,omitempty"` // Requests per day up to today, newest first.
+}
+
+// APIKeyDailyUsage is the number of requests of an API key during a UTC day.
+type APIKeyDailyUsage struct {
+	Date     string `json:"date"` // YYYY-MM-DD.
+	Requests int64  `json:"requests"`
+}
+
+// RateLimit allows at most Limit requests of a key in any sliding Window.
+type RateLimit struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitDecision is the outcome of checking a request against rate limits,
+// reported for the most constrained one.
+type RateLimitDecision struct {
+	Allowed    bool
+	Index      int // Index of the most constrained limit.
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the oldest request of the limit leaves its window.
+	RetryAfter time.Duration // Until the request would be allowed, when it is not.
+}
+
+// Anomaly statuses.
+const (
+	AnomalyPending   = "pending"
+	AnomalyApproved  = "approved"
+	AnomalyDiscarded = "discarded"
+)
+
+// Anomaly reasons.
+const (
+	AnomalyReasonRate      = "rate"      // Interactions far above the baseline.
+	AnomalyReasonDiversity = "diversity" // Too few distinct viewers.
+)
+
+// AnomalyObservation is what is known about the interactions of a video during the
+// current anomaly detection window, the interaction being observed included.
+type AnomalyObservation struct {
+	Interactions       int64
+	ViewerInteractions int64 // Interactions with a viewer ID.
+	DistinctViewers    int64 // Approximate.
+	// Baseline is the moving average of interactions per window of the previous
+	// windows, unknown during the first window of a video.
+	Baseline    float64
+	HasBaseline bool
+}
+
+// Anomaly is a suspicious spike of interactions of a video. While it is pending,
+// the anomalous interactions of the video are quarantined in it: their weight and
+// counters are held until an admin approves them, which re-scores the video with
+// them, or discards them. A video has at most one pending anomaly.
+type Anomaly struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	VideoID string `gorm:"uniqueIndex:idx_anomalies_pending,where:status = 'pending'" json:"videoID"`
+	UserID  string `json:"userID"` // Owner of the video.
+	Status  string `gorm:"index" json:"status"`
+	// Reason and observation of the last anomalous interaction.
+	Reason             string  `json:"reason"`
+	WindowInteractions int64   `json:"windowInteractions"`
+	Baseline           float64 `json:"baseline"`
+	Diversity          float64 `json:"diversity"` // Distinct viewers per interaction with a viewer ID.
+	// Quarantined interactions, their summed weight and counters. The score they add
+	// is computed by the ranking strategy on approval.
+	Interactions int64      `json:"interactions"`
+	Delta        float64    `json:"delta"`
+	Views        int64      `json:"views"`
+	Likes        int64      `json:"likes"`
+	Comments     int64      `json:"comments"`
+	Shares       int64      `json:"shares"`
+	Reports      int64      `json:"reports"`
+	WatchTime    float64    `json:"watchTime"`
+	DetectedAt   time.Time  `json:"detectedAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+}
+
+// Counters returns the quarantined counter increments by counter name.
+func (a *Anomaly) Counters() map[string]float64 {
+	counters := map[string]float64{
+		"views":      float64(a.Views),
+		"likes":      float64(a.Likes),
+		"comments":   float64(a.Comments),
+		"shares":     float64(a.Shares),
+		"reports":    float64(a.Reports),
+		"watch_time": a.WatchTime,
+	}
+	for name, value := range counters {
+		if value == 0 {
+			delete(counters, name)
+		}
+	}
+	return counters
 }
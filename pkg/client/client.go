@@ -0,0 +1,406 @@
+// Package client is a Go client for the ranking service REST API.
+//
+//	c, err := client.New("http://ranking-service:8080")
+//	if err != nil {
+//		return err
+//	}
+//	videos, err := c.TopVideos(ctx, client.TopOptions{Limit: 10})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ranking-service/models"
+)
+
+// Client calls the ranking service. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	token      string
+	apiKey     string
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, http.DefaultClient by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed request is retried, 3 by default, and the
+// backoff between attempts: it starts at backoff and doubles up to maxBackoff.
+func WithRetries(maxRetries int, backoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithToken authenticates the requests with a bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithAPIKey authenticates the requests with an API key, in the X-API-Key header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// New creates a Client for the service at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: expected an http or https URL", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// InteractionResult is the outcome of a recorded interaction.
+type InteractionResult struct {
+	VideoID string  `json:"videoID"`
+	Delta   float64 `json:"delta"`
+	Capped  float64 `json:"capped,omitempty"` // Score withheld by the viewer's cap, when the status is capped.
+	// Score held for review, when the status is quarantined.
+	Quarantined float64 `json:"quarantined,omitempty"`
+	Status      string  `json:"status"`
+}
+
+// RecordInteraction updates a video's score based on an interaction. The video ID of
+// the interaction is ignored in favour of videoID.
+//
+// Interactions are not idempotent: they are only retried when the service rejected
+// them without recording them (429 and 503 responses).
+func (c *Client) RecordInteraction(ctx context.Context, videoID string, interaction models.InteractionRequest) (*InteractionResult, error) {
+	var result InteractionResult
+	path := "/videos/" + url.PathEscape(videoID) + "/interaction"
+	if err := c.do(ctx, http.MethodPost, path, nil, interaction, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RecordInteractions records a batch of up to 1000 interactions, in order. Invalid
+// interactions are reported in the response without failing the batch. Batches are
+// retried like RecordInteraction.
+func (c *Client) RecordInteractions(ctx context.Context, interactions []models.InteractionRequest) (*models.BatchInteractionResponse, error) {
+	var result models.BatchInteractionResponse
+	body := models.BatchInteractionRequest{Interactions: interactions}
+	if err := c.do(ctx, http.MethodPost, "/interactions/batch", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TopOptions selects a page of the global ranking.
+type TopOptions struct {
+	// Limit is the number of videos, 10 when 0.
+	Limit int
+	// MaxPerOwner and MaxPerCategory override the service's diversity caps when set;
+	// 0 disables a cap.
+	MaxPerOwner    *int
+	MaxPerCategory *int
+	// At serves the ranking as of this time, from the nearest leaderboard snapshot,
+	// when set.
+	At time.Time
+	// ViewerID is the viewer served the ranking, who gets an interaction token per
+	// video when the service issues them.
+	ViewerID string
+}
+
+// TopVideos retrieves the top-ranked videos globally.
+func (c *Client) TopVideos(ctx context.Context, opts TopOptions) ([]models.RankedVideo, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.MaxPerOwner != nil {
+		query.Set("max_per_owner", strconv.Itoa(*opts.MaxPerOwner))
+	}
+	if opts.MaxPerCategory != nil {
+		query.Set("max_per_category", strconv.Itoa(*opts.MaxPerCategory))
+	}
+	if !opts.At.IsZero() {
+		query.Set("at", opts.At.Format(time.RFC3339))
+	}
+	if opts.ViewerID != "" {
+		query.Set("viewer_id", opts.ViewerID)
+	}
+
+	query.Set("detail", "true")
+	var videos []models.RankedVideo
+	if err := c.do(ctx, http.MethodGet, "/videos/top", query, nil, &videos); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// UserTopOptions selects the top videos of a user.
+type UserTopOptions struct {
+	// Limit is the number of videos, 10 when 0.
+	Limit int
+	// At serves the user's videos of the nearest global leaderboard snapshot to this
+	// time, when set.
+	At time.Time
+	// ViewerID is the viewer served the videos, who gets an interaction token per
+	// video when the service issues them.
+	ViewerID string
+}
+
+// UserTopVideos retrieves the top videos of a user.
+func (c *Client) UserTopVideos(ctx context.Context, userID string, opts UserTopOptions) ([]models.UserTopVideo, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if !opts.At.IsZero() {
+		query.Set("at", opts.At.Format(time.RFC3339))
+	}
+	if opts.ViewerID != "" {
+		query.Set("viewer_id", opts.ViewerID)
+	}
+
+	var resp struct {
+		UserID string                `json:"userID"`
+		Videos []models.UserTopVideo `json:"videos"`
+	}
+	path := "/users/" + url.PathEscape(userID) + "/videos/top"
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Videos, nil
+}
+
+// VideoRank retrieves the rank of a video in the global ranking. It returns a 404
+// Error for videos that are not ranked.
+func (c *Client) VideoRank(ctx context.Context, videoID string) (*models.RankInfo, error) {
+	var rank models.RankInfo
+	if err := c.do(ctx, http.MethodGet, "/videos/"+url.PathEscape(videoID)+"/rank", nil, nil, &rank); err != nil {
+		return nil, err
+	}
+	return &rank, nil
+}
+
+// ExportOptions selects the leaderboard to export: the global one by default.
+type ExportOptions struct {
+	// Owner exports the leaderboard of this owner's videos.
+	Owner string
+	// Window exports the windowed leaderboard of this configured window.
+	Window time.Duration
+}
+
+// ExportLeaderboard streams every visible video of a leaderboard to w, as CSV
+// ("text/csv") or NDJSON ("application/x-ndjson") depending on contentType. Failures
+// are retried until the export starts.
+func (c *Client) ExportLeaderboard(ctx context.Context, opts ExportOptions, contentType string, w io.Writer) error {
+	query := url.Values{}
+	if opts.Owner != "" {
+		query.Set("owner", opts.Owner)
+	}
+	if opts.Window > 0 {
+		query.Set("window", opts.Window.String())
+	}
+	u := c.baseURL.JoinPath("/videos/top/export")
+	u.RawQuery = query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.open(ctx, u.String(), contentType)
+		if err == nil {
+			defer resp.Body.Close()
+			_, err = io.Copy(w, resp.Body)
+			return err
+		}
+		delay, retry := c.retryDelay(http.MethodGet, err, attempt)
+		if !retry {
+			return err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// open sends a GET request and returns the successful response.
+func (c *Client) open(ctx context.Context, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	c.authenticate(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, newError(resp, data)
+	}
+	return resp, nil
+}
+
+// do sends a request, retrying it with backoff, and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, u.String(), payload, out)
+		if err == nil {
+			return nil
+		}
+		delay, retry := c.retryDelay(method, err, attempt)
+		if !retry {
+			return err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for the delay, or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// send makes one attempt at a request.
+func (c *Client) send(ctx context.Context, method, u string, payload []byte, out any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authenticate(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp, data)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// retryDelay reports whether a failed attempt is retried, and after how long.
+func (c *Client) retryDelay(method string, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.maxRetries || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// Transport failures may happen after the service recorded an interaction.
+		return c.backoffDelay(attempt), method == http.MethodGet
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		if method != http.MethodGet {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return c.backoffDelay(attempt), true
+}
+
+// backoffDelay returns the delay before the retry of an attempt: the backoff doubled
+// with each attempt up to the maximum, with jitter.
+func (c *Client) backoffDelay(attempt int) time.Duration {
+	delay := c.backoff
+	for i := 0; i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, c.maxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+	// Between half and all of the delay, so that clients do not retry in lockstep.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// authenticate sets the API key and bearer token of a request, if any.
+func (c *Client) authenticate(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
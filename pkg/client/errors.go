@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error is an error response of the ranking service.
+type Error struct {
+	StatusCode int
+	// Message is the error reported by the service, or the response status.
+	Message string
+	// Details holds additional details reported by some endpoints.
+	Details string
+	// RetryAfter is the delay requested by the service before retrying, if any.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("ranking service: %d %s: %s", e.StatusCode, e.Message, e.Details)
+	}
+	return fmt.Sprintf("ranking service: %d %s", e.StatusCode, e.Message)
+}
+
+// newError builds the Error of an unsuccessful response.
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var payload struct {
+		Error   string `json:"error"`
+		Details string `json:"err_details"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		e.Message = payload.Error
+		e.Details = payload.Details
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// StatusCode returns the HTTP status of an Error, or 0 for other errors.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether the service answered 404 Not Found.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
@@ -0,0 +1,1055 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: proto/ranking.proto
+
+package rankingpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Interaction struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	VideoId string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	// view, like, comment, share, watch_time or report.
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Used for interactions like watch_time.
+	Weight float64 `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	// Owner of the video.
+	UserId string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Viewer who interacted, for per-viewer rate limits and score caps.
+	ViewerId string `protobuf:"bytes,5,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+	// Interaction token of the video served to the viewer in a top list, required when
+	// interaction tokens are enabled.
+	Token         string `protobuf:"bytes,6,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Interaction) Reset() {
+	*x = Interaction{}
+	mi := &file_proto_ranking_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Interaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Interaction) ProtoMessage() {}
+
+func (x *Interaction) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Interaction.ProtoReflect.Descriptor instead.
+func (*Interaction) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Interaction) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *Interaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Interaction) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Interaction) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Interaction) GetViewerId() string {
+	if x != nil {
+		return x.ViewerId
+	}
+	return ""
+}
+
+func (x *Interaction) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type InteractionResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	VideoId string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Delta   float64                `protobuf:"fixed64,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	// Score withheld because the interaction exceeds the cap of its viewer, in which
+	// case it is counted but not scored.
+	Capped float64 `protobuf:"fixed64,3,opt,name=capped,proto3" json:"capped,omitempty"`
+	// Score held because the interaction is anomalous, in which case it is quarantined
+	// until an admin approves or discards it.
+	Quarantined   float64 `protobuf:"fixed64,4,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InteractionResult) Reset() {
+	*x = InteractionResult{}
+	mi := &file_proto_ranking_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InteractionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InteractionResult) ProtoMessage() {}
+
+func (x *InteractionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InteractionResult.ProtoReflect.Descriptor instead.
+func (*InteractionResult) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InteractionResult) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *InteractionResult) GetDelta() float64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *InteractionResult) GetCapped() float64 {
+	if x != nil {
+		return x.Capped
+	}
+	return 0
+}
+
+func (x *InteractionResult) GetQuarantined() float64 {
+	if x != nil {
+		return x.Quarantined
+	}
+	return 0
+}
+
+type StreamInteractionsSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      int64                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      []*RejectedInteraction `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamInteractionsSummary) Reset() {
+	*x = StreamInteractionsSummary{}
+	mi := &file_proto_ranking_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamInteractionsSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamInteractionsSummary) ProtoMessage() {}
+
+func (x *StreamInteractionsSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamInteractionsSummary.ProtoReflect.Descriptor instead.
+func (*StreamInteractionsSummary) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamInteractionsSummary) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *StreamInteractionsSummary) GetRejected() []*RejectedInteraction {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+type RejectedInteraction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Position of the interaction in the stream, from 0.
+	Index         int64  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	VideoId       string `protobuf:"bytes,2,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectedInteraction) Reset() {
+	*x = RejectedInteraction{}
+	mi := &file_proto_ranking_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectedInteraction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectedInteraction) ProtoMessage() {}
+
+func (x *RejectedInteraction) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectedInteraction.ProtoReflect.Descriptor instead.
+func (*RejectedInteraction) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RejectedInteraction) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RejectedInteraction) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *RejectedInteraction) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetTopVideosRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of videos to retrieve, 10 when unset.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Maximum number of videos per owner or category, 0 disables a cap. The
+	// configured caps apply when unset.
+	MaxPerOwner    *int32 `protobuf:"varint,2,opt,name=max_per_owner,json=maxPerOwner,proto3,oneof" json:"max_per_owner,omitempty"`
+	MaxPerCategory *int32 `protobuf:"varint,3,opt,name=max_per_category,json=maxPerCategory,proto3,oneof" json:"max_per_category,omitempty"`
+	// Serve the ranking as of this time, from the nearest leaderboard snapshot.
+	At *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=at,proto3" json:"at,omitempty"`
+	// Viewer served the ranking on behalf of an API key client, who gets an interaction
+	// token for each of the first 100 videos when tokens are enabled. Bearer tokens
+	// get them for their subject.
+	ViewerId      string `protobuf:"bytes,5,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTopVideosRequest) Reset() {
+	*x = GetTopVideosRequest{}
+	mi := &file_proto_ranking_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTopVideosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTopVideosRequest) ProtoMessage() {}
+
+func (x *GetTopVideosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTopVideosRequest.ProtoReflect.Descriptor instead.
+func (*GetTopVideosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTopVideosRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetTopVideosRequest) GetMaxPerOwner() int32 {
+	if x != nil && x.MaxPerOwner != nil {
+		return *x.MaxPerOwner
+	}
+	return 0
+}
+
+func (x *GetTopVideosRequest) GetMaxPerCategory() int32 {
+	if x != nil && x.MaxPerCategory != nil {
+		return *x.MaxPerCategory
+	}
+	return 0
+}
+
+func (x *GetTopVideosRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *GetTopVideosRequest) GetViewerId() string {
+	if x != nil {
+		return x.ViewerId
+	}
+	return ""
+}
+
+type GetTopVideosResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Videos []*RankedVideo         `protobuf:"bytes,1,rep,name=videos,proto3" json:"videos,omitempty"`
+	// Time of the snapshot that served an at query.
+	SnapshotTakenAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=snapshot_taken_at,json=snapshotTakenAt,proto3" json:"snapshot_taken_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetTopVideosResponse) Reset() {
+	*x = GetTopVideosResponse{}
+	mi := &file_proto_ranking_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTopVideosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTopVideosResponse) ProtoMessage() {}
+
+func (x *GetTopVideosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTopVideosResponse.ProtoReflect.Descriptor instead.
+func (*GetTopVideosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTopVideosResponse) GetVideos() []*RankedVideo {
+	if x != nil {
+		return x.Videos
+	}
+	return nil
+}
+
+func (x *GetTopVideosResponse) GetSnapshotTakenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SnapshotTakenAt
+	}
+	return nil
+}
+
+type RankedVideo struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	VideoId string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Score   float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	// pin or boost when placed by an editor.
+	Editorial   string `protobuf:"bytes,3,opt,name=editorial,proto3" json:"editorial,omitempty"`
+	Exploration bool   `protobuf:"varint,4,opt,name=exploration,proto3" json:"exploration,omitempty"`
+	// Movement since the previous leaderboard snapshot, when one is available.
+	PreviousRank int32  `protobuf:"varint,5,opt,name=previous_rank,json=previousRank,proto3" json:"previous_rank,omitempty"`
+	Movement     string `protobuf:"bytes,6,opt,name=movement,proto3" json:"movement,omitempty"`
+	RankChange   int32  `protobuf:"varint,7,opt,name=rank_change,json=rankChange,proto3" json:"rank_change,omitempty"`
+	// Token of this impression, set when a viewer ID is given.
+	InteractionToken string `protobuf:"bytes,8,opt,name=interaction_token,json=interactionToken,proto3" json:"interaction_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RankedVideo) Reset() {
+	*x = RankedVideo{}
+	mi := &file_proto_ranking_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RankedVideo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RankedVideo) ProtoMessage() {}
+
+func (x *RankedVideo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RankedVideo.ProtoReflect.Descriptor instead.
+func (*RankedVideo) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RankedVideo) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *RankedVideo) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *RankedVideo) GetEditorial() string {
+	if x != nil {
+		return x.Editorial
+	}
+	return ""
+}
+
+func (x *RankedVideo) GetExploration() bool {
+	if x != nil {
+		return x.Exploration
+	}
+	return false
+}
+
+func (x *RankedVideo) GetPreviousRank() int32 {
+	if x != nil {
+		return x.PreviousRank
+	}
+	return 0
+}
+
+func (x *RankedVideo) GetMovement() string {
+	if x != nil {
+		return x.Movement
+	}
+	return ""
+}
+
+func (x *RankedVideo) GetRankChange() int32 {
+	if x != nil {
+		return x.RankChange
+	}
+	return 0
+}
+
+func (x *RankedVideo) GetInteractionToken() string {
+	if x != nil {
+		return x.InteractionToken
+	}
+	return ""
+}
+
+type GetUserTopVideosRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Number of videos to retrieve, 10 when unset.
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Serve the user's videos of the nearest global leaderboard snapshot to this time.
+	At *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	// Viewer served the videos on behalf of an API key client, who gets an interaction
+	// token for each of the first 100 videos when tokens are enabled. Bearer tokens
+	// get them for their subject.
+	ViewerId      string `protobuf:"bytes,4,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserTopVideosRequest) Reset() {
+	*x = GetUserTopVideosRequest{}
+	mi := &file_proto_ranking_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserTopVideosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserTopVideosRequest) ProtoMessage() {}
+
+func (x *GetUserTopVideosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserTopVideosRequest.ProtoReflect.Descriptor instead.
+func (*GetUserTopVideosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetUserTopVideosRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetUserTopVideosRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetUserTopVideosRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *GetUserTopVideosRequest) GetViewerId() string {
+	if x != nil {
+		return x.ViewerId
+	}
+	return ""
+}
+
+type GetUserTopVideosResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Videos []*UserVideo           `protobuf:"bytes,2,rep,name=videos,proto3" json:"videos,omitempty"`
+	// Time of the snapshot that served an at query.
+	SnapshotTakenAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=snapshot_taken_at,json=snapshotTakenAt,proto3" json:"snapshot_taken_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetUserTopVideosResponse) Reset() {
+	*x = GetUserTopVideosResponse{}
+	mi := &file_proto_ranking_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserTopVideosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserTopVideosResponse) ProtoMessage() {}
+
+func (x *GetUserTopVideosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserTopVideosResponse.ProtoReflect.Descriptor instead.
+func (*GetUserTopVideosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetUserTopVideosResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetUserTopVideosResponse) GetVideos() []*UserVideo {
+	if x != nil {
+		return x.Videos
+	}
+	return nil
+}
+
+func (x *GetUserTopVideosResponse) GetSnapshotTakenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SnapshotTakenAt
+	}
+	return nil
+}
+
+type UserVideo struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	VideoId  string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	UserId   string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Title    string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Category string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Score    float64                `protobuf:"fixed64,5,opt,name=score,proto3" json:"score,omitempty"`
+	// pin or boost when placed by an editor.
+	Editorial string                 `protobuf:"bytes,6,opt,name=editorial,proto3" json:"editorial,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Token of this impression, set when a viewer ID is given.
+	InteractionToken string `protobuf:"bytes,8,opt,name=interaction_token,json=interactionToken,proto3" json:"interaction_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UserVideo) Reset() {
+	*x = UserVideo{}
+	mi := &file_proto_ranking_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserVideo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserVideo) ProtoMessage() {}
+
+func (x *UserVideo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserVideo.ProtoReflect.Descriptor instead.
+func (*UserVideo) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UserVideo) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *UserVideo) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserVideo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UserVideo) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *UserVideo) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *UserVideo) GetEditorial() string {
+	if x != nil {
+		return x.Editorial
+	}
+	return ""
+}
+
+func (x *UserVideo) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserVideo) GetInteractionToken() string {
+	if x != nil {
+		return x.InteractionToken
+	}
+	return ""
+}
+
+type GetVideoRankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVideoRankRequest) Reset() {
+	*x = GetVideoRankRequest{}
+	mi := &file_proto_ranking_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVideoRankRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVideoRankRequest) ProtoMessage() {}
+
+func (x *GetVideoRankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVideoRankRequest.ProtoReflect.Descriptor instead.
+func (*GetVideoRankRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetVideoRankRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+type VideoRank struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	VideoId string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	// 1-based.
+	Rank  int64   `protobuf:"varint,2,opt,name=rank,proto3" json:"rank,omitempty"`
+	Score float64 `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	// The video at the next-higher rank, and the score it takes to overtake it.
+	Above         *RankedVideo `protobuf:"bytes,4,opt,name=above,proto3" json:"above,omitempty"`
+	Gap           float64      `protobuf:"fixed64,5,opt,name=gap,proto3" json:"gap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VideoRank) Reset() {
+	*x = VideoRank{}
+	mi := &file_proto_ranking_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoRank) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoRank) ProtoMessage() {}
+
+func (x *VideoRank) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ranking_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoRank.ProtoReflect.Descriptor instead.
+func (*VideoRank) Descriptor() ([]byte, []int) {
+	return file_proto_ranking_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VideoRank) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *VideoRank) GetRank() int64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *VideoRank) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *VideoRank) GetAbove() *RankedVideo {
+	if x != nil {
+		return x.Above
+	}
+	return nil
+}
+
+func (x *VideoRank) GetGap() float64 {
+	if x != nil {
+		return x.Gap
+	}
+	return 0
+}
+
+var File_proto_ranking_proto protoreflect.FileDescriptor
+
+const file_proto_ranking_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/ranking.proto\x12\n" +
+	"ranking.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa0\x01\n" +
+	"\vInteraction\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x01R\x06weight\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tviewer_id\x18\x05 \x01(\tR\bviewerId\x12\x14\n" +
+	"\x05token\x18\x06 \x01(\tR\x05token\"~\n" +
+	"\x11InteractionResult\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x01R\x05delta\x12\x16\n" +
+	"\x06capped\x18\x03 \x01(\x01R\x06capped\x12 \n" +
+	"\vquarantined\x18\x04 \x01(\x01R\vquarantined\"t\n" +
+	"\x19StreamInteractionsSummary\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x03R\baccepted\x12;\n" +
+	"\brejected\x18\x02 \x03(\v2\x1f.ranking.v1.RejectedInteractionR\brejected\"\\\n" +
+	"\x13RejectedInteraction\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x19\n" +
+	"\bvideo_id\x18\x02 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xf3\x01\n" +
+	"\x13GetTopVideosRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12'\n" +
+	"\rmax_per_owner\x18\x02 \x01(\x05H\x00R\vmaxPerOwner\x88\x01\x01\x12-\n" +
+	"\x10max_per_category\x18\x03 \x01(\x05H\x01R\x0emaxPerCategory\x88\x01\x01\x12*\n" +
+	"\x02at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x1b\n" +
+	"\tviewer_id\x18\x05 \x01(\tR\bviewerIdB\x10\n" +
+	"\x0e_max_per_ownerB\x13\n" +
+	"\x11_max_per_category\"\x8f\x01\n" +
+	"\x14GetTopVideosResponse\x12/\n" +
+	"\x06videos\x18\x01 \x03(\v2\x17.ranking.v1.RankedVideoR\x06videos\x12F\n" +
+	"\x11snapshot_taken_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x0fsnapshotTakenAt\"\x8d\x02\n" +
+	"\vRankedVideo\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x1c\n" +
+	"\teditorial\x18\x03 \x01(\tR\teditorial\x12 \n" +
+	"\vexploration\x18\x04 \x01(\bR\vexploration\x12#\n" +
+	"\rprevious_rank\x18\x05 \x01(\x05R\fpreviousRank\x12\x1a\n" +
+	"\bmovement\x18\x06 \x01(\tR\bmovement\x12\x1f\n" +
+	"\vrank_change\x18\a \x01(\x05R\n" +
+	"rankChange\x12+\n" +
+	"\x11interaction_token\x18\b \x01(\tR\x10interactionToken\"\x91\x01\n" +
+	"\x17GetUserTopVideosRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x1b\n" +
+	"\tviewer_id\x18\x04 \x01(\tR\bviewerId\"\xaa\x01\n" +
+	"\x18GetUserTopVideosResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12-\n" +
+	"\x06videos\x18\x02 \x03(\v2\x15.ranking.v1.UserVideoR\x06videos\x12F\n" +
+	"\x11snapshot_taken_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0fsnapshotTakenAt\"\x8d\x02\n" +
+	"\tUserVideo\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05score\x18\x05 \x01(\x01R\x05score\x12\x1c\n" +
+	"\teditorial\x18\x06 \x01(\tR\teditorial\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12+\n" +
+	"\x11interaction_token\x18\b \x01(\tR\x10interactionToken\"0\n" +
+	"\x13GetVideoRankRequest\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\"\x91\x01\n" +
+	"\tVideoRank\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x12\n" +
+	"\x04rank\x18\x02 \x01(\x03R\x04rank\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x01R\x05score\x12-\n" +
+	"\x05above\x18\x04 \x01(\v2\x17.ranking.v1.RankedVideoR\x05above\x12\x10\n" +
+	"\x03gap\x18\x05 \x01(\x01R\x03gap2\xaf\x03\n" +
+	"\x0eRankingService\x12K\n" +
+	"\x11RecordInteraction\x12\x17.ranking.v1.Interaction\x1a\x1d.ranking.v1.InteractionResult\x12V\n" +
+	"\x12StreamInteractions\x12\x17.ranking.v1.Interaction\x1a%.ranking.v1.StreamInteractionsSummary(\x01\x12Q\n" +
+	"\fGetTopVideos\x12\x1f.ranking.v1.GetTopVideosRequest\x1a .ranking.v1.GetTopVideosResponse\x12]\n" +
+	"\x10GetUserTopVideos\x12#.ranking.v1.GetUserTopVideosRequest\x1a$.ranking.v1.GetUserTopVideosResponse\x12F\n" +
+	"\fGetVideoRank\x12\x1f.ranking.v1.GetVideoRankRequest\x1a\x15.ranking.v1.VideoRankB!Z\x1franking-service/proto/rankingpbb\x06proto3"
+
+var (
+	file_proto_ranking_proto_rawDescOnce sync.Once
+	file_proto_ranking_proto_rawDescData []byte
+)
+
+func file_proto_ranking_proto_rawDescGZIP() []byte {
+	file_proto_ranking_proto_rawDescOnce.Do(func() {
+		file_proto_ranking_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_ranking_proto_rawDesc), len(file_proto_ranking_proto_rawDesc)))
+	})
+	return file_proto_ranking_proto_rawDescData
+}
+
+var file_proto_ranking_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_proto_ranking_proto_goTypes = []any{
+	(*Interaction)(nil),               // 0: ranking.v1.Interaction
+	(*InteractionResult)(nil),         // 1: ranking.v1.InteractionResult
+	(*StreamInteractionsSummary)(nil), // 2: ranking.v1.StreamInteractionsSummary
+	(*RejectedInteraction)(nil),       // 3: ranking.v1.RejectedInteraction
+	(*GetTopVideosRequest)(nil),       // 4: ranking.v1.GetTopVideosRequest
+	(*GetTopVideosResponse)(nil),      // 5: ranking.v1.GetTopVideosResponse
+	(*RankedVideo)(nil),               // 6: ranking.v1.RankedVideo
+	(*GetUserTopVideosRequest)(nil),   // 7: ranking.v1.GetUserTopVideosRequest
+	(*GetUserTopVideosResponse)(nil),  // 8: ranking.v1.GetUserTopVideosResponse
+	(*UserVideo)(nil),                 // 9: ranking.v1.UserVideo
+	(*GetVideoRankRequest)(nil),       // 10: ranking.v1.GetVideoRankRequest
+	(*VideoRank)(nil),                 // 11: ranking.v1.VideoRank
+	(*timestamppb.Timestamp)(nil),     // 12: google.protobuf.Timestamp
+}
+var file_proto_ranking_proto_depIdxs = []int32{
+	3,  // 0: ranking.v1.StreamInteractionsSummary.rejected:type_name -> ranking.v1.RejectedInteraction
+	12, // 1: ranking.v1.GetTopVideosRequest.at:type_name -> google.protobuf.Timestamp
+	6,  // 2: ranking.v1.GetTopVideosResponse.videos:type_name -> ranking.v1.RankedVideo
+	12, // 3: ranking.v1.GetTopVideosResponse.snapshot_taken_at:type_name -> google.protobuf.Timestamp
+	12, // 4: ranking.v1.GetUserTopVideosRequest.at:type_name -> google.protobuf.Timestamp
+	9,  // 5: ranking.v1.GetUserTopVideosResponse.videos:type_name -> ranking.v1.UserVideo
+	12, // 6: ranking.v1.GetUserTopVideosResponse.snapshot_taken_at:type_name -> google.protobuf.Timestamp
+	12, // 7: ranking.v1.UserVideo.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 8: ranking.v1.VideoRank.above:type_name -> ranking.v1.RankedVideo
+	0,  // 9: ranking.v1.RankingService.RecordInteraction:input_type -> ranking.v1.Interaction
+	0,  // 10: ranking.v1.RankingService.StreamInteractions:input_type -> ranking.v1.Interaction
+	4,  // 11: ranking.v1.RankingService.GetTopVideos:input_type -> ranking.v1.GetTopVideosRequest
+	7,  // 12: ranking.v1.RankingService.GetUserTopVideos:input_type -> ranking.v1.GetUserTopVideosRequest
+	10, // 13: ranking.v1.RankingService.GetVideoRank:input_type -> ranking.v1.GetVideoRankRequest
+	1,  // 14: ranking.v1.RankingService.RecordInteraction:output_type -> ranking.v1.InteractionResult
+	2,  // 15: ranking.v1.RankingService.StreamInteractions:output_type -> ranking.v1.StreamInteractionsSummary
+	5,  // 16: ranking.v1.RankingService.GetTopVideos:output_type -> ranking.v1.GetTopVideosResponse
+	8,  // 17: ranking.v1.RankingService.GetUserTopVideos:output_type -> ranking.v1.GetUserTopVideosResponse
+	11, // 18: ranking.v1.RankingService.GetVideoRank:output_type -> ranking.v1.VideoRank
+	14, // [14:19] is the sub-list for method output_type
+	9,  // [9:14] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_proto_ranking_proto_init() }
+func file_proto_ranking_proto_init() {
+	if File_proto_ranking_proto != nil {
+		return
+	}
+	file_proto_ranking_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_ranking_proto_rawDesc), len(file_proto_ranking_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_ranking_proto_goTypes,
+		DependencyIndexes: file_proto_ranking_proto_depIdxs,
+		MessageInfos:      file_proto_ranking_proto_msgTypes,
+	}.Build()
+	File_proto_ranking_proto = out.File
+	file_proto_ranking_proto_goTypes = nil
+	file_proto_ranking_proto_depIdxs = nil
+}
@@ -0,0 +1,283 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: proto/ranking.proto
+
+package rankingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RankingService_RecordInteraction_FullMethodName  = "/ranking.v1.RankingService/RecordInteraction"
+	RankingService_StreamInteractions_FullMethodName = "/ranking.v1.RankingService/StreamInteractions"
+	RankingService_GetTopVideos_FullMethodName       = "/ranking.v1.RankingService/GetTopVideos"
+	RankingService_GetUserTopVideos_FullMethodName   = "/ranking.v1.RankingService/GetUserTopVideos"
+	RankingService_GetVideoRank_FullMethodName       = "/ranking.v1.RankingService/GetVideoRank"
+)
+
+// RankingServiceClient is the client API for RankingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RankingService ingests interactions and serves the rankings, like the REST API.
+type RankingServiceClient interface {
+	// RecordInteraction updates a video's score based on an interaction.
+	RecordInteraction(ctx context.Context, in *Interaction, opts ...grpc.CallOption) (*InteractionResult, error)
+	// StreamInteractions ingests a stream of interactions. Invalid interactions are
+	// rejected and reported in the summary without ending the stream.
+	StreamInteractions(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Interaction, StreamInteractionsSummary], error)
+	// GetTopVideos retrieves the top-ranked videos globally.
+	GetTopVideos(ctx context.Context, in *GetTopVideosRequest, opts ...grpc.CallOption) (*GetTopVideosResponse, error)
+	// GetUserTopVideos retrieves the top videos of a user.
+	GetUserTopVideos(ctx context.Context, in *GetUserTopVideosRequest, opts ...grpc.CallOption) (*GetUserTopVideosResponse, error)
+	// GetVideoRank retrieves the rank of a video in the global ranking.
+	GetVideoRank(ctx context.Context, in *GetVideoRankRequest, opts ...grpc.CallOption) (*VideoRank, error)
+}
+
+type rankingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRankingServiceClient(cc grpc.ClientConnInterface) RankingServiceClient {
+	return &rankingServiceClient{cc}
+}
+
+func (c *rankingServiceClient) RecordInteraction(ctx context.Context, in *Interaction, opts ...grpc.CallOption) (*InteractionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InteractionResult)
+	err := c.cc.Invoke(ctx, RankingService_RecordInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rankingServiceClient) StreamInteractions(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Interaction, StreamInteractionsSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RankingService_ServiceDesc.Streams[0], RankingService_StreamInteractions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Interaction, StreamInteractionsSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RankingService_StreamInteractionsClient = grpc.ClientStreamingClient[Interaction, StreamInteractionsSummary]
+
+func (c *rankingServiceClient) GetTopVideos(ctx context.Context, in *GetTopVideosRequest, opts ...grpc.CallOption) (*GetTopVideosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTopVideosResponse)
+	err := c.cc.Invoke(ctx, RankingService_GetTopVideos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rankingServiceClient) GetUserTopVideos(ctx context.Context, in *GetUserTopVideosRequest, opts ...grpc.CallOption) (*GetUserTopVideosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserTopVideosResponse)
+	err := c.cc.Invoke(ctx, RankingService_GetUserTopVideos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rankingServiceClient) GetVideoRank(ctx context.Context, in *GetVideoRankRequest, opts ...grpc.CallOption) (*VideoRank, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VideoRank)
+	err := c.cc.Invoke(ctx, RankingService_GetVideoRank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RankingServiceServer is the server API for RankingService service.
+// All implementations must embed UnimplementedRankingServiceServer
+// for forward compatibility.
+//
+// RankingService ingests interactions and serves the rankings, like the REST API.
+type RankingServiceServer interface {
+	// RecordInteraction updates a video's score based on an interaction.
+	RecordInteraction(context.Context, *Interaction) (*InteractionResult, error)
+	// StreamInteractions ingests a stream of interactions. Invalid interactions are
+	// rejected and reported in the summary without ending the stream.
+	StreamInteractions(grpc.ClientStreamingServer[Interaction, StreamInteractionsSummary]) error
+	// GetTopVideos retrieves the top-ranked videos globally.
+	GetTopVideos(context.Context, *GetTopVideosRequest) (*GetTopVideosResponse, error)
+	// GetUserTopVideos retrieves the top videos of a user.
+	GetUserTopVideos(context.Context, *GetUserTopVideosRequest) (*GetUserTopVideosResponse, error)
+	// GetVideoRank retrieves the rank of a video in the global ranking.
+	GetVideoRank(context.Context, *GetVideoRankRequest) (*VideoRank, error)
+	mustEmbedUnimplementedRankingServiceServer()
+}
+
+// UnimplementedRankingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRankingServiceServer struct{}
+
+func (UnimplementedRankingServiceServer) RecordInteraction(context.Context, *Interaction) (*InteractionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordInteraction not implemented")
+}
+func (UnimplementedRankingServiceServer) StreamInteractions(grpc.ClientStreamingServer[Interaction, StreamInteractionsSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamInteractions not implemented")
+}
+func (UnimplementedRankingServiceServer) GetTopVideos(context.Context, *GetTopVideosRequest) (*GetTopVideosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopVideos not implemented")
+}
+func (UnimplementedRankingServiceServer) GetUserTopVideos(context.Context, *GetUserTopVideosRequest) (*GetUserTopVideosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserTopVideos not implemented")
+}
+func (UnimplementedRankingServiceServer) GetVideoRank(context.Context, *GetVideoRankRequest) (*VideoRank, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVideoRank not implemented")
+}
+func (UnimplementedRankingServiceServer) mustEmbedUnimplementedRankingServiceServer() {}
+func (UnimplementedRankingServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRankingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RankingServiceServer will
+// result in compilation errors.
+type UnsafeRankingServiceServer interface {
+	mustEmbedUnimplementedRankingServiceServer()
+}
+
+func RegisterRankingServiceServer(s grpc.ServiceRegistrar, srv RankingServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRankingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RankingService_ServiceDesc, srv)
+}
+
+func _RankingService_RecordInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Interaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RankingServiceServer).RecordInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RankingService_RecordInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RankingServiceServer).RecordInteraction(ctx, req.(*Interaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RankingService_StreamInteractions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RankingServiceServer).StreamInteractions(&grpc.GenericServerStream[Interaction, StreamInteractionsSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RankingService_StreamInteractionsServer = grpc.ClientStreamingServer[Interaction, StreamInteractionsSummary]
+
+func _RankingService_GetTopVideos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopVideosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RankingServiceServer).GetTopVideos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RankingService_GetTopVideos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RankingServiceServer).GetTopVideos(ctx, req.(*GetTopVideosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RankingService_GetUserTopVideos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserTopVideosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RankingServiceServer).GetUserTopVideos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RankingService_GetUserTopVideos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RankingServiceServer).GetUserTopVideos(ctx, req.(*GetUserTopVideosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RankingService_GetVideoRank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVideoRankRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RankingServiceServer).GetVideoRank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RankingService_GetVideoRank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RankingServiceServer).GetVideoRank(ctx, req.(*GetVideoRankRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RankingService_ServiceDesc is the grpc.ServiceDesc for RankingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RankingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ranking.v1.RankingService",
+	HandlerType: (*RankingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecordInteraction",
+			Handler:    _RankingService_RecordInteraction_Handler,
+		},
+		{
+			MethodName: "GetTopVideos",
+			Handler:    _RankingService_GetTopVideos_Handler,
+		},
+		{
+			MethodName: "GetUserTopVideos",
+			Handler:    _RankingService_GetUserTopVideos_Handler,
+		},
+		{
+			MethodName: "GetVideoRank",
+			Handler:    _RankingService_GetVideoRank_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamInteractions",
+			Handler:       _RankingService_StreamInteractions_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/ranking.proto",
+}
@@ -0,0 +1,6 @@
+#!/bin/bash
+
+protoc \
+  --go_out=. --go_opt=module=ranking-service \
+  --go-grpc_out=. --go-grpc_opt=module=ranking-service \
+  proto/ranking.proto
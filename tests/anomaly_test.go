@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/anomaly"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+var testAnomalyConfig = config.AnomalyConfig{
+	Enabled:         true,
+	Window:          24 * time.Hour,
+	BaselineWeight:  0.5,
+	MinInteractions: 5,
+	RateFactor:      3,
+	MinDiversity:    0.5,
+}
+
+func TestAnomalyDetector_Detect(t *testing.T) {
+	detector, err := anomaly.New(&FakeRedis{}, testAnomalyConfig)
+	assert.NoError(t, err)
+
+	// The rate is compared to the baseline once there is one.
+	assert.Equal(t, "", detector.Detect(models.AnomalyObservation{Interactions: 100}))
+	assert.Equal(t, "", detector.Detect(models.AnomalyObservation{Interactions: 30, Baseline: 10, HasBaseline: true}))
+	assert.Equal(t, models.AnomalyReasonRate, detector.Detect(models.AnomalyObservation{Interactions: 31, Baseline: 10, HasBaseline: true}))
+	assert.Equal(t, "", detector.Detect(models.AnomalyObservation{Interactions: 4, HasBaseline: true}))
+
+	// The diversity is checked once enough interactions have a viewer ID.
+	assert.Equal(t, models.AnomalyReasonDiversity, detector.Detect(models.AnomalyObservation{Interactions: 10, ViewerInteractions: 10, DistinctViewers: 4}))
+	assert.Equal(t, "", detector.Detect(models.AnomalyObservation{Interactions: 10, ViewerInteractions: 10, DistinctViewers: 5}))
+	assert.Equal(t, "", detector.Detect(models.AnomalyObservation{Interactions: 10, ViewerInteractions: 4, DistinctViewers: 1}))
+
+	for _, cfg := range []config.AnomalyConfig{
+		{Window: time.Millisecond, BaselineWeight: 0.2, MinInteractions: 1, RateFactor: 2},
+		{Window: time.Minute, BaselineWeight: 0, MinInteractions: 1, RateFactor: 2},
+		{Window: time.Minute, BaselineWeight: 0.2, MinInteractions: 0, RateFactor: 2},
+		{Window: time.Minute, BaselineWeight: 0.2, MinInteractions: 1, RateFactor: 1},
+		{Window: time.Minute, BaselineWeight: 0.2, MinInteractions: 1, RateFactor: 2, MinDiversity: 2},
+	} {
+		_, err := anomaly.New(&FakeRedis{}, cfg)
+		assert.Error(t, err)
+	}
+}
+
+// newAnomalyRouter serves the interaction and anomaly review routes with anomaly detection.
+func newAnomalyRouter(t *testing.T, fakePostgres *FakePostgres, fakeRedis *FakeRedis) *gin.Engine {
+	detector, err := anomaly.New(fakeRedis, testAnomalyConfig)
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithAnomalyDetector(detector))
+	router := gin.New()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.GET("/admin/anomalies", handler.ListAnomaliesHandler())
+	router.GET("/admin/anomalies/:id", handler.GetAnomalyHandler())
+	router.POST("/admin/anomalies/:id/approve", handler.ApproveAnomalyHandler())
+	router.POST("/admin/anomalies/:id/discard", handler.DiscardAnomalyHandler())
+	return router
+}
+
+func likeAs(t *testing.T, router *gin.Engine, viewerID string) map[string]interface{} {
+	w := httptest.NewRecorder()
+	body := `{"user_id":"user1","type":"like","viewer_id":"` + viewerID + `"}`
+	req, _ := http.NewRequest(http.MethodPost, "/videos/video1/interaction", strings.NewReader(body))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func reviewAnomaly(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnomaly_QuarantineAndApprove(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{"video1": {VideoID: "video1", UserID: "user1"}}}
+	fakeRedis := &FakeRedis{}
+	router := newAnomalyRouter(t, fakePostgres, fakeRedis)
+
+	// A single viewer liking repeatedly lacks diversity from the fifth interaction.
+	for range 4 {
+		resp := likeAs(t, router, "bot")
+		assert.Equal(t, "updated", resp["status"])
+	}
+	for range 2 {
+		resp := likeAs(t, router, "bot")
+		assert.Equal(t, "quarantined", resp["status"])
+		assert.Equal(t, 0.0, resp["delta"])
+		assert.Equal(t, 1.0, resp["quarantined"])
+	}
+	assert.Equal(t, 4.0, fakeRedis.Deltas["video1"])
+	assert.Equal(t, 4.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+
+	w := reviewAnomaly(router, http.MethodGet, "/admin/anomalies")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var queue []models.Anomaly
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &queue))
+	assert.Len(t, queue, 1)
+	assert.Equal(t, models.AnomalyReasonDiversity, queue[0].Reason)
+	assert.Equal(t, "user1", queue[0].UserID)
+	assert.Equal(t, int64(2), queue[0].Interactions)
+	assert.Equal(t, int64(2), queue[0].Likes)
+	assert.Equal(t, 2.0, queue[0].Delta)
+	assert.Equal(t, int64(6), queue[0].WindowInteractions)
+
+	// Approving applies the quarantined interactions, once.
+	w = reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"approved"`)
+	assert.Equal(t, 6.0, fakeRedis.Deltas["video1"])
+	assert.Equal(t, 6.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+	assert.Equal(t, int64(6), fakePostgres.Stats["video1"].Likes)
+	assert.Equal(t, http.StatusConflict, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, http.StatusConflict, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/discard").Code)
+
+	// Later anomalous interactions go to a new anomaly, and discarding drops them.
+	assert.Equal(t, "quarantined", likeAs(t, router, "bot")["status"])
+	w = reviewAnomaly(router, http.MethodGet, "/admin/anomalies/2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	assert.Equal(t, http.StatusOK, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/2/discard").Code)
+	assert.Equal(t, 6.0, fakeRedis.Deltas["video1"])
+	assert.Equal(t, 6.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+
+	w = reviewAnomaly(router, http.MethodGet, "/admin/anomalies")
+	assert.Equal(t, "[]", w.Body.String())
+	w = reviewAnomaly(router, http.MethodGet, "/admin/anomalies?status=all")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &queue))
+	assert.Len(t, queue, 2)
+	assert.Equal(t, http.StatusBadRequest, reviewAnomaly(router, http.MethodGet, "/admin/anomalies?status=open").Code)
+	assert.Equal(t, http.StatusNotFound, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/9/approve").Code)
+	assert.Equal(t, http.StatusBadRequest, reviewAnomaly(router, http.MethodGet, "/admin/anomalies/x").Code)
+}
+
+func TestAnomaly_DiverseViewersAreScored(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	router := newAnomalyRouter(t, &FakePostgres{}, fakeRedis)
+
+	for _, viewer := range []string{"a", "b", "c", "d", "e", "f", "", "", "", ""} {
+		assert.Equal(t, "updated", likeAs(t, router, viewer)["status"])
+	}
+	assert.Equal(t, 10.0, fakeRedis.Deltas["video1"])
+}
+
+func TestAnomaly_ApproveDeletedVideo(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{"video1": {VideoID: "video1", Status: models.VideoStatusDeleted}},
+		Anomalies: []models.Anomaly{
+			{ID: 1, VideoID: "video1", Status: models.AnomalyPending, Interactions: 3, Likes: 3, Delta: 3},
+		},
+	}
+	fakeRedis := &FakeRedis{}
+	router := newAnomalyRouter(t, fakePostgres, fakeRedis)
+
+	assert.Equal(t, http.StatusGone, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, models.AnomalyPending, fakePostgres.Anomalies[0].Status)
+	assert.Equal(t, http.StatusOK, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/discard").Code)
+	assert.Empty(t, fakeRedis.Deltas)
+}
+
+func TestAnomaly_ApproveFailureStaysPending(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{"video1": {VideoID: "video1"}},
+		Anomalies: []models.Anomaly{
+			{ID: 1, VideoID: "video1", Status: models.AnomalyPending, Interactions: 3, Likes: 3, Delta: 3},
+		},
+	}
+	fakeRedis := &FakeRedis{UpdateError: assert.AnError}
+	router := newAnomalyRouter(t, fakePostgres, fakeRedis)
+
+	// A failed apply leaves the anomaly pending and withdraws its counters.
+	assert.Equal(t, http.StatusInternalServerError, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, models.AnomalyPending, fakePostgres.Anomalies[0].Status)
+	assert.Equal(t, 0.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+
+	// Retrying counts the interactions once.
+	fakeRedis.UpdateError = nil
+	assert.Equal(t, http.StatusOK, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, models.AnomalyApproved, fakePostgres.Anomalies[0].Status)
+	assert.Equal(t, 3.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+	assert.Equal(t, 3.0, fakeRedis.Deltas["video1"])
+}
+
+func TestAnomaly_ApproveAppliesOnce(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{"video1": {VideoID: "video1"}},
+		Anomalies: []models.Anomaly{
+			{ID: 1, VideoID: "video1", Status: models.AnomalyPending, Interactions: 3, Likes: 3, Delta: 3},
+		},
+		ReviewError: assert.AnError,
+	}
+	fakeRedis := &FakeRedis{}
+	router := newAnomalyRouter(t, fakePostgres, fakeRedis)
+
+	// The interactions are applied, but the approval fails to be saved.
+	assert.Equal(t, http.StatusInternalServerError, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, models.AnomalyPending, fakePostgres.Anomalies[0].Status)
+	assert.Equal(t, 3.0, fakeRedis.Deltas["video1"])
+
+	// Retrying saves the approval without applying the interactions again.
+	fakePostgres.ReviewError = nil
+	assert.Equal(t, http.StatusOK, reviewAnomaly(router, http.MethodPost, "/admin/anomalies/1/approve").Code)
+	assert.Equal(t, models.AnomalyApproved, fakePostgres.Anomalies[0].Status)
+	assert.Equal(t, 3.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+	assert.Equal(t, 3.0, fakeRedis.Deltas["video1"])
+}
+
+func TestAnomaly_QuarantineNotCapped(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	detector, err := anomaly.New(fakeRedis, testAnomalyConfig)
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis,
+		handlers.WithConfig(config.RankingConfig{ViewerCaps: map[string]float64{"like": 10}, ViewerCapPeriod: time.Hour}),
+		handlers.WithAnomalyDetector(detector))
+
+	like := models.InteractionRequest{VideoID: "video1", Type: "like", UserID: "user1", ViewerID: "bot"}
+	for range 4 {
+		outcome, err := handler.RecordInteraction(like)
+		assert.NoError(t, err)
+		assert.Equal(t, handlers.InteractionUpdated, outcome.Status)
+	}
+	for range 2 {
+		outcome, err := handler.RecordInteraction(like)
+		assert.NoError(t, err)
+		assert.Equal(t, handlers.InteractionQuarantined, outcome.Status)
+	}
+
+	// Only the applied interactions are charged against the cap of the viewer.
+	charged := 0.0
+	for _, total := range fakeRedis.ViewerCapTotals {
+		charged += total
+	}
+	assert.Equal(t, 4.0, charged)
+}
+
+func TestAnomaly_ApproveRescores(t *testing.T) {
+	// Formulas are evaluated over the counters, which include the quarantined ones.
+	scorer, err := scoring.NewFormulaScorer("likes * 2")
+	assert.NoError(t, err)
+	state := scoring.VideoState{Exists: true, Score: 4, Counters: map[string]float64{scoring.CounterLikes: 5}}
+	update, err := scorer.ScoreBulk(map[string]float64{scoring.CounterLikes: 3}, 3, time.Now(), state)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, *update.Score)
+	assert.Equal(t, 6.0, update.Delta)
+	assert.Equal(t, 6.0, update.Weight)
+
+	// The quarantined weight is added to decaying scores.
+	decay := scoring.NewTimeDecayScorer(scoring.NewLinearScorer(scoring.DefaultWeights), time.Hour)
+	update, err = decay.ScoreBulk(map[string]float64{scoring.CounterLikes: 3}, 3, time.Now(), state)
+	assert.NoError(t, err)
+	assert.Nil(t, update.Score)
+	assert.Equal(t, 3.0, update.Delta)
+}
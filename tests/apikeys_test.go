@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/config"
+	"ranking-service/internal/auth"
+	"ranking-service/internal/grpcserver"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+	"ranking-service/pkg/client"
+	"ranking-service/proto/rankingpb"
+)
+
+// newAPIKeyRouter serves the API key management and a route of each scope,
+// authenticated by API keys and HS256 bearer tokens.
+func newAPIKeyRouter(t *testing.T, pg *FakePostgres, redis *FakeRedis) *gin.Engine {
+	verifier, err := auth.NewVerifier(config.AuthConfig{HS256Secret: testSecret})
+	assert.NoError(t, err)
+	authenticator := auth.NewAuthenticator(verifier, auth.NewAPIKeys(pg, redis, 0))
+
+	handler := handlers.NewRankingHandler(pg, redis)
+	router := gin.New()
+	router.Group("/", authenticator.Require(auth.ScopeInteractionsWrite)).POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.Group("/", authenticator.Require(auth.ScopeRankingsRead)).GET("/videos/top", handler.GetGlobalTopVideosHandler())
+	admin := router.Group("/admin", authenticator.Require(auth.ScopeAdmin))
+	admin.POST("/api-keys", handler.CreateAPIKeyHandler())
+	admin.GET("/api-keys", handler.ListAPIKeysHandler())
+	admin.DELETE("/api-keys/:id", handler.RevokeAPIKeyHandler())
+	admin.POST("/api-keys/:id/rotate", handler.RotateAPIKeyHandler())
+	admin.GET("/api-keys/:id/usage", handler.GetAPIKeyUsageHandler())
+	return router
+}
+
+// serveAdmin sends a request authenticated by an admin bearer token.
+func serveAdmin(t *testing.T, router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+signHS256(t, testSecret, "admin"))
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func serveWithAPIKey(router *gin.Engine, method, path, key string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(`{"user_id":"user1","type":"like"}`))
+	req.Header.Set("X-API-Key", key)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createAPIKey creates an API key through the admin API.
+func createAPIKey(t *testing.T, router *gin.Engine, body string) models.CreatedAPIKey {
+	w := serveAdmin(t, router, http.MethodPost, "/admin/api-keys", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.CreatedAPIKey
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
+func TestAPIKeys_Management(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	router := newAPIKeyRouter(t, fakePostgres, &FakeRedis{})
+
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(t, router, http.MethodPost, "/admin/api-keys", `{"name":"ingest","scopes":"interactions:write videos:delete"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(t, router, http.MethodPost, "/admin/api-keys", `{"name":"ingest","scopes":""}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(t, router, http.MethodPost, "/admin/api-keys", `{"name":"ingest","scopes":"admin","daily_quota":-1}`).Code)
+
+	created := createAPIKey(t, router, `{"name":"ingest","scopes":"interactions:write"}`)
+	assert.True(t, strings.HasPrefix(created.Key, "rk_"))
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	// Only the hash of the key is stored, and never returned.
+	assert.Equal(t, auth.HashAPIKey(created.Key), fakePostgres.APIKeys[0].Hash)
+	w := serveAdmin(t, router, http.MethodGet, "/admin/api-keys", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.NotContains(t, w.Body.String(), fakePostgres.APIKeys[0].Hash)
+
+	// The key grants its scopes only.
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", created.Key).Code)
+	assert.Equal(t, http.StatusForbidden, serveWithAPIKey(router, http.MethodGet, "/videos/top", created.Key).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithAPIKey(router, http.MethodGet, "/videos/top", "rk_unknown").Code)
+
+	// The previous secret stays valid during the grace period of a rotation.
+	w = serveAdmin(t, router, http.MethodPost, "/admin/api-keys/1/rotate", `{"grace_period":"1h"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rotated models.CreatedAPIKey
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, created.ID, rotated.ID)
+	assert.NotEqual(t, created.Key, rotated.Key)
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", created.Key).Code)
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", rotated.Key).Code)
+
+	// Without a grace period, only the new secret is valid.
+	w = serveAdmin(t, router, http.MethodPost, "/admin/api-keys/1/rotate", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var next models.CreatedAPIKey
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &next))
+	assert.Equal(t, http.StatusUnauthorized, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", created.Key).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", rotated.Key).Code)
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", next.Key).Code)
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(t, router, http.MethodPost, "/admin/api-keys/1/rotate", `{"grace_period":"1y"}`).Code)
+
+	// Revoked keys are rejected and cannot be rotated.
+	assert.Equal(t, http.StatusOK, serveAdmin(t, router, http.MethodDelete, "/admin/api-keys/1", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithAPIKey(router, http.MethodPost, "/videos/video1/interaction", next.Key).Code)
+	assert.Equal(t, http.StatusConflict, serveAdmin(t, router, http.MethodPost, "/admin/api-keys/1/rotate", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(t, router, http.MethodDelete, "/admin/api-keys/2", "").Code)
+
+	// Admin API keys can manage keys too.
+	admin := createAPIKey(t, router, `{"name":"ops","scopes":"admin"}`)
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodGet, "/admin/api-keys", admin.Key).Code)
+}
+
+func TestAPIKeys_Quotas(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	router := newAPIKeyRouter(t, &FakePostgres{}, fakeRedis)
+	created := createAPIKey(t, router, `{"name":"reader","scopes":"rankings:read","per_minute_quota":2,"daily_quota":100}`)
+
+	w := serveWithAPIKey(router, http.MethodGet, "/videos/top", created.Key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Minute-Remaining"))
+	assert.Equal(t, "99", w.Header().Get("X-Quota-Day-Remaining"))
+	assert.Equal(t, http.StatusOK, serveWithAPIKey(router, http.MethodGet, "/videos/top", created.Key).Code)
+
+	w = serveWithAPIKey(router, http.MethodGet, "/videos/top", created.Key)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, retryAfter, 60)
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Minute-Remaining"))
+
+	// Rejected requests are not counted.
+	w = serveAdmin(t, router, http.MethodGet, "/admin/api-keys/1/usage?days=3", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var usage models.APIKeyUsage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, int64(2), usage.Minute)
+	assert.Equal(t, int64(2), usage.Today)
+	assert.Equal(t, 2, usage.PerMinuteQuota)
+	assert.Equal(t, 100, usage.DailyQuota)
+	assert.Len(t, usage.Daily, 3)
+	assert.Equal(t, time.Now().UTC().Format(time.DateOnly), usage.Daily[0].Date)
+	assert.Equal(t, int64(2), usage.Daily[0].Requests)
+
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(t, router, http.MethodGet, "/admin/api-keys/1/usage?days=32", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(t, router, http.MethodGet, "/admin/api-keys/9/usage", "").Code)
+}
+
+func TestAPIKeys_QuotaRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 59, 30, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, auth.QuotaRetryAfter(models.APIKeyUsage{Minute: 5, PerMinuteQuota: 5, Today: 5, DailyQuota: 10}, now))
+	// An exhausted daily quota resets at the next UTC day.
+	now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 12*time.Hour, auth.QuotaRetryAfter(models.APIKeyUsage{Minute: 1, PerMinuteQuota: 5, Today: 10, DailyQuota: 10}, now))
+}
+
+func TestAPIKeys_GRPC(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	router := newAPIKeyRouter(t, fakePostgres, &FakeRedis{})
+	created := createAPIKey(t, router, `{"name":"reader","scopes":"rankings:read","per_minute_quota":1}`)
+
+	authenticator := auth.NewAuthenticator(nil, auth.NewAPIKeys(fakePostgres, &FakeRedis{}, time.Minute))
+	c := newGRPCClient(t, fakePostgres, &FakeRedis{},
+		grpc.UnaryInterceptor(grpcserver.UnaryAuthInterceptor(authenticator)),
+		grpc.StreamInterceptor(grpcserver.StreamAuthInterceptor(authenticator)))
+
+	reader := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", created.Key)
+	_, err := c.GetTopVideos(reader, &rankingpb.GetTopVideosRequest{})
+	assert.NoError(t, err)
+	_, err = c.GetTopVideos(reader, &rankingpb.GetTopVideosRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = c.RecordInteraction(reader, &rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Bearer tokens are rejected without a verifier.
+	token := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+signHS256(t, testSecret, "admin"))
+	_, err = c.GetTopVideos(token, &rankingpb.GetTopVideosRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestClient_WithAPIKey(t *testing.T) {
+	router := newAPIKeyRouter(t, &FakePostgres{}, &FakeRedis{})
+	created := createAPIKey(t, router, `{"name":"reader","scopes":"rankings:read"}`)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithAPIKey(created.Key))
+	assert.NoError(t, err)
+	_, err = c.TopVideos(context.Background(), client.TopOptions{})
+	assert.NoError(t, err)
+
+	c, err = client.New(server.URL, client.WithAPIKey("rk_unknown"))
+	assert.NoError(t, err)
+	_, err = c.TopVideos(context.Background(), client.TopOptions{})
+	assert.Equal(t, http.StatusUnauthorized, client.StatusCode(err))
+}
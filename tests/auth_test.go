@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/config"
+	"ranking-service/internal/auth"
+	"ranking-service/internal/grpcserver"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+	"ranking-service/pkg/client"
+	"ranking-service/proto/rankingpb"
+)
+
+const testSecret = "test-secret"
+
+// signHS256 issues a token valid for an hour with the given scopes.
+func signHS256(t *testing.T, secret, scope string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Scope:            scope,
+	}).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+// newAuthRouter serves a route of each scope, authenticated by the verifier.
+func newAuthRouter(verifier *auth.Verifier) *gin.Engine {
+	handler := handlers.NewRankingHandler(&FakePostgres{}, &FakeRedis{})
+	router := gin.New()
+	router.Group("/", verifier.Require(auth.ScopeInteractionsWrite)).POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.Group("/", verifier.Require(auth.ScopeRankingsRead)).GET("/videos/top", handler.GetGlobalTopVideosHandler())
+	router.Group("/", verifier.RequireWithQueryToken(auth.ScopeRankingsRead)).GET("/videos/top/stream", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Group("/admin", verifier.Require(auth.ScopeAdmin)).GET("/editorial", handler.ListEditorialRulesHandler())
+	return router
+}
+
+func serveWithToken(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_HS256Scopes(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.AuthConfig{HS256Secret: testSecret})
+	assert.NoError(t, err)
+	router := newAuthRouter(verifier)
+
+	w := serveWithToken(router, http.MethodGet, "/videos/top", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+
+	reader := signHS256(t, testSecret, "rankings:read")
+	assert.Equal(t, http.StatusOK, serveWithToken(router, http.MethodGet, "/videos/top", reader).Code)
+	w = serveWithToken(router, http.MethodGet, "/admin/editorial", reader)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "insufficient_scope")
+	assert.Equal(t, http.StatusForbidden, serveWithToken(router, http.MethodPost, "/videos/video1/interaction", reader).Code)
+
+	// Admin grants every scope.
+	admin := signHS256(t, testSecret, "admin")
+	assert.Equal(t, http.StatusOK, serveWithToken(router, http.MethodGet, "/admin/editorial", admin).Code)
+	assert.Equal(t, http.StatusOK, serveWithToken(router, http.MethodGet, "/videos/top", admin).Code)
+
+	// Only stream routes accept the token as a query parameter.
+	assert.Equal(t, http.StatusOK, serveWithToken(router, http.MethodGet, "/videos/top/stream?access_token="+reader, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(router, http.MethodGet, "/videos/top?access_token="+reader, "").Code)
+
+	// Tokens signed with another secret, expired or without expiry are rejected.
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(router, http.MethodGet, "/videos/top", signHS256(t, "other-secret", "rankings:read")).Code)
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour))},
+		Scope:            "rankings:read",
+	}).SignedString([]byte(testSecret))
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(router, http.MethodGet, "/videos/top", expired).Code)
+	unbounded, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{Scope: "rankings:read"}).SignedString([]byte(testSecret))
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(router, http.MethodGet, "/videos/top", unbounded).Code)
+}
+
+func TestAuth_RedactAccessToken(t *testing.T) {
+	assert.Equal(t, "/videos/top/stream?access_token=REDACTED", auth.RedactAccessToken("/videos/top/stream?access_token=eyJ.payload.sig"))
+	assert.Equal(t, "/videos/top/ws?limit=5&access_token=REDACTED&x=1", auth.RedactAccessToken("/videos/top/ws?limit=5&access_token=eyJ.payload.sig&x=1"))
+	assert.Equal(t, "/videos/top?limit=5", auth.RedactAccessToken("/videos/top?limit=5"))
+
+	var logs bytes.Buffer
+	gin.DefaultWriter = &logs
+	defer func() { gin.DefaultWriter = os.Stdout }()
+	router := gin.New()
+	router.Use(auth.Logger())
+	router.GET("/videos/top/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req, _ := http.NewRequest(http.MethodGet, "/videos/top/stream?access_token=eyJ.payload.sig", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, logs.String(), "access_token=REDACTED")
+	assert.NotContains(t, logs.String(), "eyJ.payload.sig")
+}
+
+func TestAuth_IssuerAndAudience(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.AuthConfig{HS256Secret: testSecret, Issuer: "https://issuer.example.com", Audience: "ranking-service"})
+	assert.NoError(t, err)
+
+	sign := func(issuer, audience string) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Audience:  jwt.ClaimStrings{audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			Scp: []string{"rankings:read"},
+		}).SignedString([]byte(testSecret))
+		return token
+	}
+
+	_, err = verifier.Authorize(sign("https://issuer.example.com", "ranking-service"), auth.ScopeRankingsRead)
+	assert.NoError(t, err)
+	_, err = verifier.Authorize(sign("https://other.example.com", "ranking-service"), auth.ScopeRankingsRead)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = verifier.Authorize(sign("https://issuer.example.com", "other-service"), auth.ScopeRankingsRead)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+// writeJWKS writes a key set holding the public key under kid.
+func writeJWKS(t *testing.T, key *rsa.PublicKey, kid string) string {
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestAuth_RS256JWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	verifier, err := auth.NewVerifier(config.AuthConfig{JWKSFile: writeJWKS(t, &key.PublicKey, "key-1")})
+	assert.NoError(t, err)
+
+	sign := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			Scope:            "interactions:write rankings:read",
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	claims, err := verifier.Authorize(sign("key-1"), auth.ScopeInteractionsWrite)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"interactions:write", "rankings:read"}, claims.Scopes())
+	_, err = verifier.Authorize(sign("key-2"), auth.ScopeInteractionsWrite)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// HS256 tokens are refused when no secret is configured, even signed with the public key.
+	_, err = verifier.Authorize(signHS256(t, string(key.PublicKey.N.Bytes()), "admin"), auth.ScopeAdmin)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	_, err = auth.NewVerifier(config.AuthConfig{})
+	assert.Error(t, err)
+	_, err = auth.NewVerifier(config.AuthConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
+
+func TestAuth_GRPC(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.AuthConfig{HS256Secret: testSecret})
+	assert.NoError(t, err)
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{{VideoID: "video1", Score: 1}}}
+	c := newGRPCClient(t, &FakePostgres{}, fakeRedis,
+		grpc.UnaryInterceptor(grpcserver.UnaryAuthInterceptor(auth.NewAuthenticator(verifier, nil))),
+		grpc.StreamInterceptor(grpcserver.StreamAuthInterceptor(auth.NewAuthenticator(verifier, nil))))
+
+	_, err = c.GetTopVideos(context.Background(), &rankingpb.GetTopVideosRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	reader := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+signHS256(t, testSecret, "rankings:read"))
+	_, err = c.GetTopVideos(reader, &rankingpb.GetTopVideosRequest{})
+	assert.NoError(t, err)
+	_, err = c.RecordInteraction(reader, &rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := c.StreamInteractions(reader)
+	assert.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestClient_WithToken(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.AuthConfig{HS256Secret: testSecret})
+	assert.NoError(t, err)
+	server := httptest.NewServer(newAuthRouter(verifier))
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithToken(signHS256(t, testSecret, "rankings:read")))
+	assert.NoError(t, err)
+	_, err = c.TopVideos(context.Background(), client.TopOptions{})
+	assert.NoError(t, err)
+
+	c, err = client.New(server.URL)
+	assert.NoError(t, err)
+	_, err = c.TopVideos(context.Background(), client.TopOptions{})
+	assert.Equal(t, http.StatusUnauthorized, client.StatusCode(err))
+}
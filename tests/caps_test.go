@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func TestViewerCaps(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{"video1": {VideoID: "video1", UserID: "user1"}}}
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithConfig(config.RankingConfig{
+		ViewerCaps:      map[string]float64{"share": 4},
+		ViewerCapPeriod: 24 * time.Hour,
+	}))
+	router := gin.New()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.GET("/videos/:video_id/stats", handler.GetVideoStatsHandler())
+
+	share := func(viewerID string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		body := `{"user_id":"user1","type":"share","viewer_id":"` + viewerID + `"}`
+		req, _ := http.NewRequest(http.MethodPost, "/videos/video1/interaction", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	// Two shares of 2 reach the cap of viewer1, the third is counted but not scored.
+	for range 2 {
+		resp := share("viewer1")
+		assert.Equal(t, 2.0, resp["delta"])
+		assert.Nil(t, resp["capped"])
+	}
+	resp := share("viewer1")
+	assert.Equal(t, "capped", resp["status"])
+	assert.Equal(t, 0.0, resp["delta"])
+	assert.Equal(t, 2.0, resp["capped"])
+
+	counters := fakeRedis.Counters["video1"]
+	assert.Equal(t, 2.0, counters[scoring.CounterShares])
+	assert.Equal(t, 1.0, counters[scoring.CounterCappedInteractions])
+	assert.Equal(t, 2.0, counters[scoring.CounterCappedScore])
+
+	// Other viewers are not capped by viewer1.
+	for range 2 {
+		assert.Equal(t, 2.0, share("viewer2")["delta"])
+	}
+	assert.Equal(t, "capped", share("viewer2")["status"])
+	assert.Equal(t, 2.0, share("viewer3")["delta"])
+
+	// Capped interactions require a viewer, uncapped ones do not.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/videos/video1/interaction", strings.NewReader(`{"user_id":"user1","type":"share"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Missing viewer_id")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/videos/video1/interaction", strings.NewReader(`{"user_id":"user1","type":"like"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/videos/video1/stats", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats models.VideoStatsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, int64(5), stats.Counts["shares"])
+	assert.Equal(t, int64(2), stats.CappedInteractions)
+	assert.Equal(t, 4.0, stats.CappedScore)
+}
+
+func TestValidateViewerCaps(t *testing.T) {
+	assert.NoError(t, scoring.ValidateViewerCaps(map[string]float64{"share": 10, "like": 1}))
+	assert.Error(t, scoring.ValidateViewerCaps(map[string]float64{"repost": 1}))
+	assert.Error(t, scoring.ValidateViewerCaps(map[string]float64{"like": -1}))
+}
+
+func TestViewerCaps_ChargeInteractionWeight(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", Score: 40},
+	}}
+	fakeRedis := &FakeRedis{}
+	scorer, err := scoring.NewFormulaScorer("likes * 2")
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis,
+		handlers.WithConfig(config.RankingConfig{ViewerCaps: map[string]float64{"like": 2}, ViewerCapPeriod: time.Hour}),
+		handlers.WithScorer(scorer))
+
+	// Re-scoring makes the delta negative, yet the like is charged and the next one capped.
+	like := models.InteractionRequest{VideoID: "video1", Type: "like", UserID: "user1", ViewerID: "viewer1"}
+	outcome, err := handler.RecordInteraction(like)
+	assert.NoError(t, err)
+	assert.Equal(t, handlers.InteractionUpdated, outcome.Status)
+	assert.Less(t, outcome.Delta, 0.0)
+	outcome, err = handler.RecordInteraction(like)
+	assert.NoError(t, err)
+	assert.Equal(t, handlers.InteractionCapped, outcome.Status)
+	assert.Equal(t, 2.0, outcome.Withheld)
+	assert.Equal(t, 2.0, fakeRedis.Counters["video1"][scoring.CounterCappedScore])
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+	"ranking-service/pkg/client"
+)
+
+// newClientServer serves the REST API of a RankingHandler. Each request first goes
+// through intercept, which may answer it instead.
+func newClientServer(t *testing.T, fakePostgres *FakePostgres, fakeRedis *FakeRedis, intercept func(w http.ResponseWriter, r *http.Request) bool) *client.Client {
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+	router := gin.New()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.POST("/interactions/batch", handler.RecordInteractionsHandler())
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+	router.GET("/users/:userID/videos/top", handler.GetUserTopVideosHandler())
+	router.GET("/videos/:video_id/rank", handler.GetVideoRankHandler())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if intercept != nil && intercept(w, r) {
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL, client.WithRetries(3, time.Millisecond, 10*time.Millisecond))
+	assert.NoError(t, err)
+	return c
+}
+
+func TestClient_New(t *testing.T) {
+	_, err := client.New("localhost:8080")
+	assert.Error(t, err)
+	_, err = client.New("http://localhost:8080/")
+	assert.NoError(t, err)
+}
+
+func TestClient_RecordInteraction(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"deleted": {VideoID: "deleted", Status: models.VideoStatusDeleted},
+	}}
+	c := newClientServer(t, fakePostgres, &FakeRedis{}, nil)
+	ctx := context.Background()
+
+	result, err := c.RecordInteraction(ctx, "video1", models.InteractionRequest{Type: "share", UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "video1", result.VideoID)
+	assert.Equal(t, 2.0, result.Delta)
+	assert.Equal(t, int64(1), fakePostgres.Stats["video1"].Shares)
+
+	_, err = c.RecordInteraction(ctx, "deleted", models.InteractionRequest{Type: "like", UserID: "user1"})
+	var apiErr *client.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusGone, apiErr.StatusCode)
+		assert.Equal(t, "Video has been deleted", apiErr.Message)
+	}
+}
+
+func TestClient_RecordInteractions(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	c := newClientServer(t, fakePostgres, &FakeRedis{}, nil)
+
+	resp, err := c.RecordInteractions(context.Background(), []models.InteractionRequest{
+		{VideoID: "video1", Type: "view", UserID: "user1"},
+		{VideoID: "video1", Type: "dislike", UserID: "user1"},
+		{VideoID: "video2", Type: "like"},
+		{VideoID: "video2", Type: "like", UserID: "user2"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, []models.RejectedInteraction{
+		{Index: 1, VideoID: "video1", Error: "Unknown interaction type"},
+		{Index: 2, VideoID: "video2", Error: "Missing userID in payload"},
+	}, resp.Rejected)
+	assert.Equal(t, int64(1), fakePostgres.Stats["video2"].Likes)
+}
+
+func TestClient_TopVideos(t *testing.T) {
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video2", Score: 20},
+	}}
+	fakePostgres := &FakePostgres{Videos: []models.Video{{VideoID: "video1", UserID: "user1", Score: 30}}}
+	c := newClientServer(t, fakePostgres, fakeRedis, nil)
+	ctx := context.Background()
+
+	videos, err := c.TopVideos(ctx, client.TopOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RankedVideo{{VideoID: "video1", Score: 30}}, videos)
+
+	userVideos, err := c.UserTopVideos(ctx, "user1", client.UserTopOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, userVideos, 1) {
+		assert.Equal(t, "video1", userVideos[0].VideoID)
+	}
+
+	// No snapshot near the requested time.
+	_, err = c.TopVideos(ctx, client.TopOptions{At: time.Now().Add(-time.Hour)})
+	assert.True(t, client.IsNotFound(err))
+}
+
+func TestClient_VideoRank(t *testing.T) {
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video2", Score: 20},
+	}}
+	c := newClientServer(t, &FakePostgres{}, fakeRedis, nil)
+	ctx := context.Background()
+
+	rank, err := c.VideoRank(ctx, "video2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rank.Rank)
+	assert.Equal(t, "video1", rank.Above.VideoID)
+	assert.Equal(t, 10.0, rank.Gap)
+
+	_, err = c.VideoRank(ctx, "unknown")
+	assert.True(t, client.IsNotFound(err))
+}
+
+func TestClient_RetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	c := newClientServer(t, &FakePostgres{}, &FakeRedis{TopVideosList: []models.RankedVideo{{VideoID: "video1", Score: 1}}},
+		func(w http.ResponseWriter, r *http.Request) bool {
+			if calls.Add(1) <= 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			}
+			return false
+		})
+
+	videos, err := c.TopVideos(context.Background(), client.TopOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, videos, 1)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newClientServer(t, &FakePostgres{}, &FakeRedis{}, func(w http.ResponseWriter, r *http.Request) bool {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+
+	_, err := c.TopVideos(context.Background(), client.TopOptions{})
+
+	assert.Equal(t, http.StatusServiceUnavailable, client.StatusCode(err))
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestClient_DoesNotRetryFailedInteractions(t *testing.T) {
+	var calls atomic.Int32
+	c := newClientServer(t, &FakePostgres{}, &FakeRedis{UpdateError: errors.New("redis down")}, func(w http.ResponseWriter, r *http.Request) bool {
+		calls.Add(1)
+		return false
+	})
+
+	_, err := c.RecordInteraction(context.Background(), "video1", models.InteractionRequest{Type: "like", UserID: "user1"})
+
+	var apiErr *client.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Details, "redis down")
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_HonoursRetryAfterAndContext(t *testing.T) {
+	c := newClientServer(t, &FakePostgres{}, &FakeRedis{}, func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return true
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.RecordInteraction(ctx, "video1", models.InteractionRequest{Type: "like", UserID: "user1"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
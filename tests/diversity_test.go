@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+)
+
+func TestGetGlobalTopVideosHandler_DiversityCapsOwners(t *testing.T) {
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{
+			{VideoID: "a1", Score: 100},
+			{VideoID: "a2", Score: 90},
+			{VideoID: "a3", Score: 80},
+			{VideoID: "a4", Score: 70},
+			{VideoID: "b1", Score: 60},
+			{VideoID: "c1", Score: 50},
+		},
+	}
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"a1": {VideoID: "a1", UserID: "prolific"},
+		"a2": {VideoID: "a2", UserID: "prolific"},
+		"a3": {VideoID: "a3", UserID: "prolific"},
+		"a4": {VideoID: "a4", UserID: "prolific"},
+		"b1": {VideoID: "b1", UserID: "creator-b"},
+		"c1": {VideoID: "c1", UserID: "creator-c"},
+	}}
+
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis,
+		handlers.WithConfig(config.RankingConfig{DiversityMaxPerOwner: 2, DiversityMaxCandidates: 100}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true&limit=4", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp []models.RankedVideo
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	ids := make([]string, len(resp))
+	for i, v := range resp {
+		ids[i] = v.VideoID
+	}
+	assert.Equal(t, []string{"a1", "a2", "b1", "c1"}, ids)
+
+	// The query parameter overrides the configured cap.
+	req, _ = http.NewRequest("GET", "/videos/top?detail=true&limit=4&max_per_owner=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "a4", resp[3].VideoID)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+func TestApplyEditorial_PinAndBoost(t *testing.T) {
+	videos := []models.RankedVideo{
+		{VideoID: "a", Score: 100},
+		{VideoID: "b", Score: 80},
+		{VideoID: "c", Score: 60},
+		{VideoID: "d", Score: 40},
+	}
+	rules := []models.EditorialRule{
+		{VideoID: "promo", Kind: models.EditorialPin, Position: 3},
+		{VideoID: "d", Kind: models.EditorialBoost, Multiplier: 3},
+	}
+	scores := map[string]float64{"promo": 1}
+
+	ranked := rerank.ApplyEditorial(videos, rules, scores, 4)
+
+	assert.Equal(t, 4, len(ranked))
+	assert.Equal(t, "d", ranked[0].VideoID) // 40 * 3 = 120
+	assert.Equal(t, models.EditorialBoost, ranked[0].Editorial)
+	assert.Equal(t, "a", ranked[1].VideoID)
+	assert.Equal(t, "promo", ranked[2].VideoID)
+	assert.Equal(t, models.EditorialPin, ranked[2].Editorial)
+	assert.Equal(t, "b", ranked[3].VideoID)
+	assert.Equal(t, "", ranked[3].Editorial)
+}
+
+func TestApplyEditorial_SkipsUnrankedVideos(t *testing.T) {
+	videos := []models.RankedVideo{{VideoID: "a", Score: 10}}
+	rules := []models.EditorialRule{{VideoID: "deleted", Kind: models.EditorialPin, Position: 1}}
+
+	ranked := rerank.ApplyEditorial(videos, rules, map[string]float64{}, 10)
+
+	assert.Equal(t, []models.RankedVideo{{VideoID: "a", Score: 10}}, ranked)
+}
+
+func TestGetGlobalTopVideosHandler_Editorial(t *testing.T) {
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{
+			{VideoID: "video1", Score: 10},
+			{VideoID: "video2", Score: 5},
+		},
+		Scores: map[string]float64{"promo": 0.5},
+	}
+	fakePostgres := &FakePostgres{Editorial: []models.EditorialRule{{
+		ID:       1,
+		VideoID:  "promo",
+		Kind:     models.EditorialPin,
+		Position: 1,
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(time.Hour),
+	}}}
+
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp []models.RankedVideo
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp))
+	assert.Equal(t, "promo", resp[0].VideoID)
+	assert.Equal(t, models.EditorialPin, resp[0].Editorial)
+	assert.Equal(t, "video1", resp[1].VideoID)
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func explainVideo(t *testing.T, handler *handlers.RankingHandler, videoID string) (*httptest.ResponseRecorder, models.ScoreExplanation) {
+	router := gin.Default()
+	router.GET("/videos/:video_id/explain", handler.ExplainVideoScoreHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/"+videoID+"/explain", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp models.ScoreExplanation
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestExplainVideoScoreHandler_Linear(t *testing.T) {
+	now := time.Now()
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{
+			"video2": {VideoID: "video2", Score: 7, ModerationStatus: models.ModerationVisible, CreatedAt: now},
+		},
+		Editorial: []models.EditorialRule{
+			{VideoID: "video2", Kind: models.EditorialBoost, Multiplier: 2, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		},
+	}
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{{VideoID: "video1", Score: 10}, {VideoID: "video2", Score: 7}},
+		Counters: map[string]map[string]float64{
+			"video2": {"views": 10, "likes": 2, "comments": 1},
+		},
+	}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+
+	w, resp := explainVideo(t, handler, "video2")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "linear", resp.Strategy)
+	assert.Equal(t, []models.ScoreContribution{
+		{Component: "view", Value: 1, Detail: "10 × 0.1"},
+		{Component: "like", Value: 2, Detail: "2 × 1"},
+		{Component: "comment", Value: 1.5, Detail: "1 × 1.5"},
+	}, resp.Contributions)
+	assert.Equal(t, []models.ScoreContribution{
+		{Component: "editorial_boost", Value: 7, Detail: "× 2"},
+	}, resp.Adjustments)
+	assert.Equal(t, 14.0, resp.EffectiveScore)
+	assert.Equal(t, int64(2), resp.Rank.Rank)
+	assert.Equal(t, "video1", resp.Rank.Above.VideoID)
+	assert.Equal(t, 3.0, resp.Rank.Gap)
+}
+
+func TestExplainVideoScoreHandler_Formula(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", Score: 5, ModerationStatus: models.ModerationVisible},
+	}}
+	fakeRedis := &FakeRedis{Counters: map[string]map[string]float64{
+		"video1": {"likes": 10, "reports": 1},
+	}}
+	scorer, err := scoring.NewFormulaScorer("likes - reports*5")
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithScorer(scorer))
+
+	w, resp := explainVideo(t, handler, "video1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "formula", resp.Strategy)
+	assert.Equal(t, "likes - reports*5", resp.Formula)
+	assert.Equal(t, []models.ScoreContribution{
+		{Component: "term", Value: 10, Detail: "likes"},
+		{Component: "term", Value: -5, Detail: "-(reports * 5)"},
+	}, resp.Contributions)
+	assert.Equal(t, int64(0), resp.Rank.Rank)
+}
+
+func TestExplainVideoScoreHandler_Decay(t *testing.T) {
+	lastInteraction := time.Now().Add(-24 * time.Hour)
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", Score: 2, ModerationStatus: models.ModerationVisible, LastInteractionAt: &lastInteraction},
+	}}
+	fakeRedis := &FakeRedis{Counters: map[string]map[string]float64{
+		"video1": {"likes": 4},
+	}}
+	scorer, err := scoring.New(config.RankingConfig{Strategy: "time_decay", DecayHalfLife: 24 * time.Hour})
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithScorer(scorer))
+
+	w, resp := explainVideo(t, handler, "video1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, resp.Contributions, 2)
+	assert.Equal(t, "decay", resp.Contributions[1].Component)
+	assert.InDelta(t, -2, resp.Contributions[1].Value, 0.01)
+	assert.InDelta(t, 2, resp.EffectiveScore, 0.01)
+}
+
+func TestExplainVideoScoreHandler_Moderated(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", Score: 9, ModerationStatus: models.ModerationHidden, ModerationReason: "spam"},
+	}}
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{}, handlers.WithConfig(config.RankingConfig{FreshnessWeight: 1, FreshnessHalfLife: time.Hour}))
+
+	w, resp := explainVideo(t, handler, "video1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []models.ScoreContribution{
+		{Component: "moderation", Value: -9, Detail: "hidden: spam"},
+	}, resp.Adjustments)
+	assert.Equal(t, 0.0, resp.EffectiveScore)
+}
+
+func TestExplainVideoScoreHandler_NotFound(t *testing.T) {
+	handler := handlers.NewRankingHandler(&FakePostgres{}, &FakeRedis{})
+
+	w, _ := explainVideo(t, handler, "missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
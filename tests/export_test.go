@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+	"ranking-service/pkg/client"
+)
+
+func newExportRouter(fakePostgres *FakePostgres, fakeRedis *FakeRedis) *gin.Engine {
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis,
+		handlers.WithConfig(config.RankingConfig{LeaderboardWindows: []time.Duration{24 * time.Hour}}))
+	router := gin.New()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.GET("/videos/top/export", handler.ExportLeaderboardHandler())
+	return router
+}
+
+func exportFixtures() (*FakePostgres, *FakeRedis) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", Category: "music"},
+		"video2": {VideoID: "video2", UserID: "user2"},
+	}}
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video1", Score: 12.5},
+		{VideoID: "video2", Score: 3},
+	}}
+	return fakePostgres, fakeRedis
+}
+
+func TestExportLeaderboardHandler_CSV(t *testing.T) {
+	router := newExportRouter(exportFixtures())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/videos/top/export", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "leaderboard-global.csv")
+	assert.Equal(t, "rank,video_id,score,user_id,category\n1,video1,12.5,user1,music\n2,video2,3,user2,\n", w.Body.String())
+}
+
+func TestExportLeaderboardHandler_NDJSON(t *testing.T) {
+	router := newExportRouter(exportFixtures())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/videos/top/export", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var rows []models.ExportedVideo
+	dec := json.NewDecoder(w.Body)
+	for dec.More() {
+		var row models.ExportedVideo
+		assert.NoError(t, dec.Decode(&row))
+		rows = append(rows, row)
+	}
+	assert.Equal(t, []models.ExportedVideo{
+		{Rank: 1, VideoID: "video1", Score: 12.5, UserID: "user1", Category: "music"},
+		{Rank: 2, VideoID: "video2", Score: 3, UserID: "user2"},
+	}, rows)
+}
+
+func TestExportLeaderboardHandler_InvalidRequests(t *testing.T) {
+	router := newExportRouter(exportFixtures())
+
+	cases := []struct {
+		name   string
+		query  string
+		accept string
+		status int
+	}{
+		{"unsupported format", "", "application/json", http.StatusNotAcceptable},
+		{"owner and window", "?owner=user1&window=24h", "", http.StatusBadRequest},
+		{"unknown window", "?window=1h", "", http.StatusBadRequest},
+		{"invalid window", "?window=soon", "", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/videos/top/export"+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestExportLeaderboardHandler_OwnerAndWindow(t *testing.T) {
+	fakePostgres, fakeRedis := exportFixtures()
+	router := newExportRouter(fakePostgres, fakeRedis)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		body, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "viewer1"})
+		req, _ := http.NewRequest(http.MethodPost, "/videos/video1/interaction", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Interactions are ranked on the board of the video's owner, not of the viewer.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/videos/top/export?owner=user1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "leaderboard-owner-user1.csv")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[1], "1,video1,"), lines[1])
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/videos/top/export?owner=viewer1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "rank,video_id,score,user_id,category\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/videos/top/export?window=24h", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "leaderboard-window-24h.csv")
+	assert.Contains(t, w.Body.String(), "\n1,video1,")
+}
+
+func TestClient_ExportLeaderboard(t *testing.T) {
+	server := httptest.NewServer(newExportRouter(exportFixtures()))
+	defer server.Close()
+	c, err := client.New(server.URL)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.ExportLeaderboard(context.Background(), client.ExportOptions{}, "text/csv", &buf))
+	assert.Equal(t, "rank,video_id,score,user_id,category\n1,video1,12.5,user1,music\n2,video2,3,user2,\n", buf.String())
+
+	buf.Reset()
+	err = c.ExportLeaderboard(context.Background(), client.ExportOptions{Window: time.Hour}, "text/csv", &buf)
+	assert.Equal(t, http.StatusBadRequest, client.StatusCode(err))
+	assert.Empty(t, buf.String())
+}
+
+func TestWindowedLeaderboards_AccumulateWeights(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", Score: 40},
+	}}
+	fakeRedis := &FakeRedis{}
+	scorer, err := scoring.NewFormulaScorer("likes * 2")
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis,
+		handlers.WithConfig(config.RankingConfig{LeaderboardWindows: []time.Duration{24 * time.Hour}}),
+		handlers.WithScorer(scorer))
+
+	// The score is re-scored from 40 to 2, but the video gained the like during the window.
+	outcome, err := handler.RecordInteraction(models.InteractionRequest{VideoID: "video1", Type: "like", UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, -38.0, outcome.Delta)
+	assert.Equal(t, 2.0, fakeRedis.Leaderboards[models.Leaderboard{Window: 24 * time.Hour}.Name()]["video1"])
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/formula"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func TestFormula_Eval(t *testing.T) {
+	f, err := scoring.CompileFormula("likes*1 + shares*2 + log1p(views)*0.5 - reports*5")
+	assert.NoError(t, err)
+
+	score, err := f.Eval(map[string]float64{"likes": 10, "shares": 2, "reports": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 9.0, score) // 10 + 4 + 0 - 5
+
+	f, err = formula.Compile("-2^2 + max(1, x, 3) / (1 + 1)", []string{"x"})
+	assert.NoError(t, err)
+	score, err = f.Eval(map[string]float64{"x": 7})
+	assert.NoError(t, err)
+	assert.Equal(t, -0.5, score)
+
+	f, err = formula.Compile("1 / x", []string{"x"})
+	assert.NoError(t, err)
+	_, err = f.Eval(nil)
+	assert.ErrorIs(t, err, formula.ErrNotFinite)
+}
+
+func TestFormula_CompileErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"likes +",
+		"likes * (shares",
+		"unknown_counter * 2",
+		"system(1)",
+		"min(likes)",
+		"likes; shares",
+		"likes shares",
+	} {
+		_, err := scoring.CompileFormula(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestSetScoringFormulaHandler_SwapsFormula(t *testing.T) {
+	scorer, err := scoring.NewFormulaScorer("likes")
+	assert.NoError(t, err)
+	fakePostgres := &FakePostgres{}
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithScorer(scorer))
+
+	router := gin.Default()
+	router.PUT("/admin/scoring/formula", handler.SetScoringFormulaHandler())
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+
+	// Invalid formulas are rejected at load time.
+	bodyBytes, _ := json.Marshal(models.ScoringFormulaRequest{Expression: "likes * bogus"})
+	req, _ := http.NewRequest("PUT", "/admin/scoring/formula", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "likes", scorer.Formula().String())
+
+	bodyBytes, _ = json.Marshal(models.ScoringFormulaRequest{Expression: "likes*10 - reports*5"})
+	req, _ = http.NewRequest("PUT", "/admin/scoring/formula", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "likes*10 - reports*5", scorer.Formula().String())
+	assert.Equal(t, 1, len(fakePostgres.Formulas))
+
+	// The next interaction is scored with the new formula.
+	bodyBytes, _ = json.Marshal(models.InteractionRequest{Type: "like", UserID: "user123"})
+	req, _ = http.NewRequest("POST", "/videos/video1/interaction", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 10.0, resp["delta"])
+}
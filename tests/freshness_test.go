@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/rerank"
+	"ranking-service/models"
+)
+
+func TestFreshnessBoost(t *testing.T) {
+	assert.Equal(t, 1.5, rerank.FreshnessBoost(0, 0.5, time.Hour))
+	assert.Equal(t, 1.25, rerank.FreshnessBoost(time.Hour, 0.5, time.Hour))
+	assert.Equal(t, 1.0, rerank.FreshnessBoost(time.Hour, 0, time.Hour))
+}
+
+func TestApplyFreshness(t *testing.T) {
+	now := time.Now()
+	videos := []models.RankedVideo{
+		{VideoID: "old", Score: 10},
+		{VideoID: "new", Score: 6},
+		{VideoID: "unknown", Score: 5},
+	}
+	createdAt := map[string]time.Time{
+		"old": now.Add(-30 * 24 * time.Hour),
+		"new": now,
+	}
+
+	ranked := rerank.ApplyFreshness(videos, createdAt, now, 1, 24*time.Hour, 2)
+
+	assert.Equal(t, 2, len(ranked))
+	assert.Equal(t, "new", ranked[0].VideoID) // 6 * 2 = 12
+	assert.Equal(t, 12.0, ranked[0].Score)
+	assert.Equal(t, "old", ranked[1].VideoID)
+}
+
+func TestExplore(t *testing.T) {
+	videos := []models.RankedVideo{{VideoID: "a"}, {VideoID: "b"}, {VideoID: "c"}}
+	pool := []models.RankedVideo{{VideoID: "a"}, {VideoID: "fresh"}}
+
+	// With epsilon 1 every slot explores until the pool runs out.
+	page, slots := rerank.Explore(videos, pool, 1, rand.New(rand.NewPCG(1, 2)))
+
+	assert.Equal(t, []int{0}, slots)
+	assert.Equal(t, 3, len(page))
+	assert.Equal(t, "fresh", page[0].VideoID)
+	assert.True(t, page[0].Exploration)
+	assert.Equal(t, "a", page[1].VideoID)
+	assert.Equal(t, "b", page[2].VideoID)
+
+	// With epsilon 0 the page is untouched.
+	page, slots = rerank.Explore(videos, pool, 0, rand.New(rand.NewPCG(1, 2)))
+
+	assert.Empty(t, slots)
+	assert.Equal(t, videos, page)
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"ranking-service/internal/grpcserver"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+	"ranking-service/proto/rankingpb"
+)
+
+// newGRPCClient serves the gRPC API over an in-memory listener.
+func newGRPCClient(t *testing.T, fakePostgres *FakePostgres, fakeRedis *FakeRedis, opts ...grpc.ServerOption) rankingpb.RankingServiceClient {
+	return newGRPCHandlerClient(t, handlers.NewRankingHandler(fakePostgres, fakeRedis), opts...)
+}
+
+// newGRPCHandlerClient serves the gRPC API of a handler.
+func newGRPCHandlerClient(t *testing.T, handler *handlers.RankingHandler, opts ...grpc.ServerOption) rankingpb.RankingServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	rankingpb.RegisterRankingServiceServer(server, grpcserver.NewServer(handler))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return rankingpb.NewRankingServiceClient(conn)
+}
+
+func TestGRPC_RecordInteraction(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"deleted": {VideoID: "deleted", Status: models.VideoStatusDeleted},
+	}}
+	client := newGRPCClient(t, fakePostgres, &FakeRedis{})
+	ctx := context.Background()
+
+	result, err := client.RecordInteraction(ctx, &rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "video1", result.GetVideoId())
+	assert.Equal(t, 1.0, result.GetDelta())
+	assert.Equal(t, int64(1), fakePostgres.Stats["video1"].Likes)
+
+	_, err = client.RecordInteraction(ctx, &rankingpb.Interaction{VideoId: "video1", Type: "like"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.RecordInteraction(ctx, &rankingpb.Interaction{VideoId: "video1", Type: "dislike", UserId: "user1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.RecordInteraction(ctx, &rankingpb.Interaction{VideoId: "deleted", Type: "like", UserId: "user1"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestGRPC_StreamInteractions(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	client := newGRPCClient(t, fakePostgres, &FakeRedis{})
+
+	stream, err := client.StreamInteractions(context.Background())
+	assert.NoError(t, err)
+	for _, interaction := range []*rankingpb.Interaction{
+		{VideoId: "video1", Type: "view", UserId: "user1"},
+		{VideoId: "video1", Type: "dislike", UserId: "user1"},
+		{VideoId: "video2", Type: "share", UserId: "user2"},
+		{Type: "view", UserId: "user1"},
+	} {
+		assert.NoError(t, stream.Send(interaction))
+	}
+	summary, err := stream.CloseAndRecv()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), summary.GetAccepted())
+	if assert.Len(t, summary.GetRejected(), 2) {
+		assert.Equal(t, int64(1), summary.GetRejected()[0].GetIndex())
+		assert.Equal(t, "video1", summary.GetRejected()[0].GetVideoId())
+		assert.Equal(t, "unknown interaction type", summary.GetRejected()[0].GetError())
+		assert.Equal(t, int64(3), summary.GetRejected()[1].GetIndex())
+	}
+	assert.Equal(t, int64(1), fakePostgres.Stats["video2"].Shares)
+}
+
+func TestGRPC_GetTopVideos(t *testing.T) {
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video2", Score: 20},
+		{VideoID: "video3", Score: 10},
+	}}
+	client := newGRPCClient(t, &FakePostgres{}, fakeRedis)
+	ctx := context.Background()
+
+	resp, err := client.GetTopVideos(ctx, &rankingpb.GetTopVideosRequest{Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.GetVideos(), 2) {
+		assert.Equal(t, "video1", resp.GetVideos()[0].GetVideoId())
+		assert.Equal(t, 30.0, resp.GetVideos()[0].GetScore())
+	}
+	assert.Nil(t, resp.GetSnapshotTakenAt())
+
+	// Historical queries need a snapshot.
+	_, err = client.GetTopVideos(ctx, &rankingpb.GetTopVideosRequest{At: timestamppb.New(time.Now().Add(-time.Hour))})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPC_GetTopVideosAt(t *testing.T) {
+	takenAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	fakePostgres := &FakePostgres{Snapshots: []models.LeaderboardSnapshot{{
+		ID:      1,
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: takenAt,
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video2", UserID: "user1", Score: 20},
+			{Rank: 2, VideoID: "video1", UserID: "user1", Score: 10},
+		},
+	}}}
+	client := newGRPCClient(t, fakePostgres, &FakeRedis{})
+
+	resp, err := client.GetTopVideos(context.Background(), &rankingpb.GetTopVideosRequest{At: timestamppb.New(takenAt)})
+
+	assert.NoError(t, err)
+	if assert.Len(t, resp.GetVideos(), 2) {
+		assert.Equal(t, "video2", resp.GetVideos()[0].GetVideoId())
+	}
+	assert.True(t, takenAt.Equal(resp.GetSnapshotTakenAt().AsTime()))
+}
+
+func TestGRPC_GetUserTopVideos(t *testing.T) {
+	fakePostgres := &FakePostgres{Videos: []models.Video{
+		{VideoID: "video1", UserID: "user1", Title: "First", Score: 12},
+	}}
+	client := newGRPCClient(t, fakePostgres, &FakeRedis{})
+	ctx := context.Background()
+
+	resp, err := client.GetUserTopVideos(ctx, &rankingpb.GetUserTopVideosRequest{UserId: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", resp.GetUserId())
+	if assert.Len(t, resp.GetVideos(), 1) {
+		assert.Equal(t, "First", resp.GetVideos()[0].GetTitle())
+		assert.Equal(t, 12.0, resp.GetVideos()[0].GetScore())
+	}
+
+	_, err = client.GetUserTopVideos(ctx, &rankingpb.GetUserTopVideosRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_GetVideoRank(t *testing.T) {
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video2", Score: 20},
+	}}
+	client := newGRPCClient(t, &FakePostgres{}, fakeRedis)
+	ctx := context.Background()
+
+	rank, err := client.GetVideoRank(ctx, &rankingpb.GetVideoRankRequest{VideoId: "video2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rank.GetRank())
+	assert.Equal(t, "video1", rank.GetAbove().GetVideoId())
+	assert.Equal(t, 10.0, rank.GetGap())
+
+	_, err = client.GetVideoRank(ctx, &rankingpb.GetVideoRankRequest{VideoId: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
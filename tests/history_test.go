@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/history"
+	"ranking-service/models"
+)
+
+func TestBucket(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []models.ScoreSample{
+		{VideoID: "video1", SampledAt: from.Add(-time.Hour), Score: 1},
+		{VideoID: "video1", SampledAt: from.Add(10 * time.Minute), Score: 4},
+		{VideoID: "video1", SampledAt: from.Add(20 * time.Minute), Score: 2},
+		{VideoID: "video1", SampledAt: from.Add(150 * time.Minute), Score: 6},
+	}
+
+	buckets := history.Bucket(samples, from, from.Add(3*time.Hour), time.Hour)
+
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, 2.0, *buckets[0].Score)
+	assert.Equal(t, 2.0, *buckets[0].Min) // The score carried into the bucket is not a sample.
+	assert.Equal(t, 4.0, *buckets[0].Max)
+	assert.Equal(t, 2, buckets[0].Samples)
+	// No samples: the last score is carried forward.
+	assert.Equal(t, 2.0, *buckets[1].Score)
+	assert.Equal(t, 0, buckets[1].Samples)
+	assert.Equal(t, 6.0, *buckets[2].Score)
+	assert.Equal(t, from.Add(2*time.Hour), buckets[2].Start)
+}
+
+func TestBucket_BeforeFirstSample(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []models.ScoreSample{{VideoID: "video1", SampledAt: from.Add(90 * time.Minute), Score: 3}}
+
+	buckets := history.Bucket(samples, from, from.Add(2*time.Hour), time.Hour)
+
+	assert.Len(t, buckets, 2)
+	assert.Nil(t, buckets[0].Score)
+	assert.Equal(t, 3.0, *buckets[1].Score)
+}
+
+func TestGetScoreHistoryHandler(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	lastInteraction := now.Add(-time.Minute)
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", Score: 5, LastInteractionAt: &lastInteraction},
+	}}
+	_, err := fakePostgres.RecordScoreSamples(now.Add(-5*time.Minute), now.Add(-30*time.Minute))
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{})
+	router := gin.Default()
+	router.GET("/videos/:video_id/history", handler.GetScoreHistoryHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/video1/history?from="+now.Add(-2*time.Hour).Format(time.RFC3339)+"&to="+now.Format(time.RFC3339)+"&resolution=1h", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.ScoreHistoryResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "1h0m0s", resp.Resolution)
+	assert.Len(t, resp.Buckets, 2)
+	assert.Nil(t, resp.Buckets[0].Score)
+	assert.Equal(t, 5.0, *resp.Buckets[1].Score)
+	assert.Equal(t, 1, resp.Buckets[1].Samples)
+}
+
+func TestGetScoreHistoryHandler_InvalidRange(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{"video1": {VideoID: "video1"}}}
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{})
+	router := gin.Default()
+	router.GET("/videos/:video_id/history", handler.GetScoreHistoryHandler())
+
+	for _, query := range []string{
+		"resolution=-1h",
+		"from=yesterday",
+		"from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
+		"from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&resolution=1m",
+	} {
+		req, _ := http.NewRequest("GET", "/videos/video1/history?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	req, _ := http.NewRequest("GET", "/videos/missing/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
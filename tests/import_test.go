@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/importer"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func newImporter(fakePostgres *FakePostgres, fakeRedis *FakeRedis) *importer.Importer {
+	return importer.New(fakePostgres, fakeRedis, scoring.NewLinearScorer(scoring.DefaultWeights), config.RankingConfig{})
+}
+
+func TestImporter_VideosCSV(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"moved":   {VideoID: "moved", UserID: "old-owner", Status: models.VideoStatusActive},
+		"deleted": {VideoID: "deleted", UserID: "user1", Status: models.VideoStatusDeleted},
+	}}
+	fakeRedis := &FakeRedis{}
+	input := `video_id,user_id,title,category,score,created_at
+video1,user1,First,music,12.5,2024-01-02T03:04:05Z
+video2,user2,,,3,
+video1,user1,First again,music,14,
+moved,new-owner,,,7,
+deleted,user1,,,1,
+no-owner,,,,1,
+video3,user1,,,not-a-number,
+`
+
+	progress, err := newImporter(fakePostgres, fakeRedis).Run(context.Background(), strings.NewReader(input), importer.Options{
+		Kind: importer.KindVideos, Format: importer.FormatCSV, Checkpoint: "videos",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, importer.Progress{Position: 7, Imported: 4, Rejected: 3}, progress)
+
+	// The last record of a video wins.
+	assert.Equal(t, "First again", fakePostgres.Catalog["video1"].Title)
+	assert.Equal(t, 14.0, fakePostgres.Catalog["video1"].Score)
+	assert.Equal(t, 14.0, fakeRedis.Scores["video1"])
+	assert.Equal(t, 3.0, fakeRedis.Scores["video2"])
+	assert.NotContains(t, fakePostgres.Catalog, "no-owner")
+	assert.NotContains(t, fakeRedis.Scores, "deleted")
+
+	// A video moving to another owner leaves the previous owner's leaderboard.
+	assert.Equal(t, "new-owner", fakePostgres.Catalog["moved"].UserID)
+	assert.Equal(t, models.VideoStatusActive, fakePostgres.Catalog["moved"].Status)
+	assert.NotContains(t, fakeRedis.Leaderboards["owner-old-owner"], "moved")
+	assert.Equal(t, 7.0, fakeRedis.Leaderboards["owner-new-owner"]["moved"])
+
+	checkpoint := fakePostgres.Checkpoints["videos"]
+	assert.True(t, checkpoint.Done)
+	assert.Equal(t, int64(7), checkpoint.Position)
+	assert.Equal(t, int64(4), checkpoint.Imported)
+	assert.Equal(t, int64(3), checkpoint.Rejected)
+}
+
+func TestImporter_VideosWithoutScore(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", Title: "First", Score: 42, Status: models.VideoStatusActive},
+	}}
+	fakeRedis := &FakeRedis{}
+	input := `{"video_id": "video1", "user_id": "user1", "title": "Renamed"}
+{"video_id": "video2", "user_id": "user1"}
+`
+
+	_, err := newImporter(fakePostgres, fakeRedis).Run(context.Background(), strings.NewReader(input), importer.Options{
+		Kind: importer.KindVideos, Format: importer.FormatNDJSON, Checkpoint: "videos",
+	})
+	assert.NoError(t, err)
+
+	// Re-imported videos keep their score, new ones start at 0.
+	assert.Equal(t, "Renamed", fakePostgres.Catalog["video1"].Title)
+	assert.Equal(t, 42.0, fakePostgres.Catalog["video1"].Score)
+	assert.Equal(t, 42.0, fakeRedis.Scores["video1"])
+	assert.Equal(t, 0.0, fakePostgres.Catalog["video2"].Score)
+	assert.Contains(t, fakeRedis.Scores, "video2")
+}
+
+func TestImporter_InvalidCSVHeader(t *testing.T) {
+	_, err := newImporter(&FakePostgres{}, &FakeRedis{}).Run(context.Background(), strings.NewReader("video_id,owner\nvideo1,user1\n"), importer.Options{
+		Kind: importer.KindVideos, Format: importer.FormatCSV, Checkpoint: "videos",
+	})
+	assert.ErrorContains(t, err, `unknown column "owner"`)
+}
+
+func TestImporter_InteractionsNDJSON(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{"video1": {VideoID: "video1", UserID: "owner1", Score: 10}},
+		Stats:   map[string]*models.VideoStats{"video1": {VideoID: "video1", Likes: 4}},
+	}
+	fakeRedis := &FakeRedis{}
+	input := `{"video_id": "video1", "type": "like", "user_id": "owner1", "at": "2024-01-01T00:00:00Z"}
+{"video_id": "video1", "type": "share", "user_id": "owner1", "at": "2024-01-02T00:00:00Z"}
+
+{"video_id": "video2", "type": "watch_time", "weight": 30, "user_id": "owner2"}
+{"video_id": "video2", "type": "unknown", "user_id": "owner2"}
+{"video_id": "video2", "type":
+`
+
+	progress, err := newImporter(fakePostgres, fakeRedis).Run(context.Background(), strings.NewReader(input), importer.Options{
+		Kind: importer.KindInteractions, Format: importer.FormatNDJSON, Checkpoint: "interactions",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, importer.Progress{Position: 5, Imported: 3, Rejected: 2}, progress)
+
+	video1 := fakePostgres.Catalog["video1"]
+	assert.Equal(t, 13.0, video1.Score)
+	assert.Equal(t, "2024-01-02T00:00:00Z", video1.LastInteractionAt.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, int64(5), fakePostgres.Stats["video1"].Likes)
+	assert.Equal(t, int64(1), fakePostgres.Stats["video1"].Shares)
+	assert.Equal(t, 13.0, fakeRedis.Scores["video1"])
+	assert.Equal(t, 5.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+	assert.Equal(t, 13.0, fakeRedis.Leaderboards["owner-owner1"]["video1"])
+
+	// Videos are created on their first interaction, owned by its user.
+	assert.Equal(t, "owner2", fakePostgres.Catalog["video2"].UserID)
+	assert.Equal(t, 30.0, fakePostgres.Catalog["video2"].Score)
+	assert.Equal(t, 30.0, fakePostgres.Stats["video2"].WatchTime)
+}
+
+func TestImporter_InteractionsDecay(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	scorer := scoring.NewTimeDecayScorer(scoring.NewLinearScorer(scoring.DefaultWeights), time.Hour)
+	imp := importer.New(fakePostgres, &FakeRedis{}, scorer, config.RankingConfig{})
+	at := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	input := `{"video_id": "video1", "type": "share", "user_id": "owner1", "at": "` + at + `"}` + "\n"
+
+	_, err := imp.Run(context.Background(), strings.NewReader(input), importer.Options{
+		Kind: importer.KindInteractions, Format: importer.FormatNDJSON, Checkpoint: "decay",
+	})
+	assert.NoError(t, err)
+
+	// The share, worth 2 points two half-lives ago, has decayed since.
+	assert.InDelta(t, 0.5, fakePostgres.Catalog["video1"].Score, 1e-3)
+}
+
+func TestImporter_RequireRegisteredVideos(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	imp := importer.New(fakePostgres, &FakeRedis{}, scoring.NewLinearScorer(scoring.DefaultWeights), config.RankingConfig{RequireRegisteredVideos: true})
+
+	progress, err := imp.Run(context.Background(), strings.NewReader("video_id,type,user_id\nvideo1,like,owner1\n"), importer.Options{
+		Kind: importer.KindInteractions, Format: importer.FormatCSV, Checkpoint: "interactions",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), progress.Rejected)
+	assert.Empty(t, fakePostgres.Catalog)
+}
+
+func TestImporter_Resume(t *testing.T) {
+	var lines []string
+	for i := 0; i < 5; i++ {
+		lines = append(lines, `{"video_id": "video1", "type": "like", "user_id": "owner1"}`)
+	}
+	input := strings.Join(lines, "\n")
+
+	fakePostgres := &FakePostgres{}
+	fakeRedis := &FakeRedis{}
+	imp := newImporter(fakePostgres, fakeRedis)
+	opts := importer.Options{Kind: importer.KindInteractions, Format: importer.FormatNDJSON, Checkpoint: "likes", BatchSize: 2}
+
+	// Fail the batch after the first one.
+	failing := opts
+	failing.Progress = func(importer.Progress) { fakePostgres.ImportError = errors.New("connection lost") }
+	progress, err := imp.Run(context.Background(), strings.NewReader(input), failing)
+	assert.ErrorContains(t, err, "connection lost")
+	assert.Equal(t, importer.Progress{Position: 2, Imported: 2}, progress)
+	assert.Equal(t, 2.0, fakePostgres.Catalog["video1"].Score)
+
+	// Resuming imports the remaining records once. Redis, already written by the failed
+	// batch, ends up with the same score.
+	fakePostgres.ImportError = nil
+	progress, err = imp.Run(context.Background(), strings.NewReader(input), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, importer.Progress{Position: 5, Skipped: 2, Imported: 5}, progress)
+	assert.Equal(t, 5.0, fakePostgres.Catalog["video1"].Score)
+	assert.Equal(t, 5.0, fakeRedis.Scores["video1"])
+	assert.Equal(t, int64(5), fakePostgres.Stats["video1"].Likes)
+
+	// A completed import is not imported again unless restarted.
+	_, err = imp.Run(context.Background(), strings.NewReader(input), opts)
+	assert.ErrorIs(t, err, importer.ErrAlreadyImported)
+
+	opts.Restart = true
+	progress, err = imp.Run(context.Background(), strings.NewReader(input), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), progress.Imported)
+	assert.Equal(t, 10.0, fakePostgres.Catalog["video1"].Score)
+
+	// The checkpoint belongs to an import of interactions.
+	_, err = imp.Run(context.Background(), strings.NewReader(input), importer.Options{Kind: importer.KindVideos, Format: importer.FormatNDJSON, Checkpoint: "likes"})
+	assert.ErrorContains(t, err, "belongs to an import of interactions")
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/config"
+	"ranking-service/internal/auth"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/impression"
+	"ranking-service/models"
+	"ranking-service/proto/rankingpb"
+)
+
+var testTokenConfig = config.InteractionTokenConfig{
+	Enabled: true,
+	Secret:  "interaction-token-secret-of-32-bytes",
+	TTL:     time.Minute,
+}
+
+func newTokenHandler(t *testing.T, fakeRedis *FakeRedis) *handlers.RankingHandler {
+	tokens, err := impression.New(fakeRedis, testTokenConfig)
+	assert.NoError(t, err)
+	fakeRedis.TopVideosList = []models.RankedVideo{{VideoID: "video1", Score: 2}, {VideoID: "video2", Score: 1}}
+	return handlers.NewRankingHandler(&FakePostgres{}, fakeRedis, handlers.WithInteractionTokens(tokens))
+}
+
+// authenticatedAs is a middleware that authenticates the requests as the principal.
+func authenticatedAs(principal auth.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// servedTokens returns the interaction tokens of the global top served to a viewer, by video.
+func servedTokens(t *testing.T, router *gin.Engine, viewerID string) map[string]string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/videos/top?detail=true&viewer_id="+viewerID, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var videos []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &videos))
+	tokens := map[string]string{}
+	for _, v := range videos {
+		tokens[v.VideoID] = v.InteractionToken
+	}
+	return tokens
+}
+
+func postTokenInteraction(router *gin.Engine, videoID, interactionType, viewerID, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(models.InteractionRequest{Type: interactionType, UserID: "user1", ViewerID: viewerID, Token: token})
+	req, _ := http.NewRequest(http.MethodPost, "/videos/"+videoID+"/interaction", strings.NewReader(string(body)))
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestInteractionTokens_New(t *testing.T) {
+	_, err := impression.New(&FakeRedis{}, config.InteractionTokenConfig{Secret: "short", TTL: time.Minute})
+	assert.Error(t, err)
+	_, err = impression.New(&FakeRedis{}, config.InteractionTokenConfig{Secret: testTokenConfig.Secret})
+	assert.Error(t, err)
+}
+
+func TestInteractionTokens(t *testing.T) {
+	handler := newTokenHandler(t, &FakeRedis{})
+	router := gin.New()
+	router.GET("/videos/top", authenticatedAs(auth.Principal{APIKey: &models.APIKey{ID: 1}}), handler.GetGlobalTopVideosHandler())
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.POST("/interactions/batch", handler.RecordInteractionsHandler())
+
+	// Tokens are only issued to identified viewers.
+	assert.Empty(t, servedTokens(t, router, "")["video1"])
+	tokens := servedTokens(t, router, "viewer1")
+	assert.NotEmpty(t, tokens["video1"])
+	assert.NotEqual(t, tokens["video1"], tokens["video2"])
+
+	// A token can be used once, whatever the interaction type.
+	assert.Equal(t, http.StatusOK, postTokenInteraction(router, "video1", "view", "viewer1", tokens["video1"]).Code)
+	w := postTokenInteraction(router, "video1", "like", "viewer1", tokens["video1"])
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Interaction token already used")
+
+	// Tokens are bound to their viewer and video.
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "viewer1", tokens["video1"]).Code)
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "viewer2", tokens["video2"]).Code)
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "", tokens["video2"]).Code)
+	w = postTokenInteraction(router, "video2", "like", "viewer1", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Missing interaction token")
+
+	// Forged and expired tokens are rejected.
+	sign := func(secret string, claims impression.Claims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return token
+	}
+	claims := impression.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "forged",
+			Subject:   "viewer1",
+			Audience:  jwt.ClaimStrings{"interactions"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		VideoID: "video2",
+	}
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "viewer1", sign("another-secret-of-at-least-32-bytes", claims)).Code)
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "viewer1", sign(testTokenConfig.Secret, claims)).Code)
+	claims.ExpiresAt, claims.Audience = jwt.NewNumericDate(time.Now().Add(time.Minute)), nil
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video2", "like", "viewer1", sign(testTokenConfig.Secret, claims)).Code)
+
+	// Rejected interactions did not use the token.
+	assert.Equal(t, http.StatusOK, postTokenInteraction(router, "video2", "like", "viewer1", tokens["video2"]).Code)
+
+	// Batches report the interactions with invalid tokens.
+	tokens = servedTokens(t, router, "viewer1")
+	batch, _ := json.Marshal(models.BatchInteractionRequest{Interactions: []models.InteractionRequest{
+		{VideoID: "video2", Type: "share", UserID: "user1", ViewerID: "viewer1", Token: tokens["video2"]},
+		{VideoID: "video2", Type: "share", UserID: "user1", ViewerID: "viewer1", Token: tokens["video2"]},
+		{VideoID: "video2", Type: "share", UserID: "user1", ViewerID: "viewer1"},
+	}})
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/interactions/batch", strings.NewReader(string(batch)))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.BatchInteractionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Accepted)
+	assert.Equal(t, []models.RejectedInteraction{
+		{Index: 1, VideoID: "video2", Error: "Interaction token already used"},
+		{Index: 2, VideoID: "video2", Error: "Missing interaction token"},
+	}, resp.Rejected)
+}
+
+func TestInteractionTokens_ReleasedOnFailure(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	handler := newTokenHandler(t, fakeRedis)
+	router := gin.New()
+	router.GET("/videos/top", authenticatedAs(auth.Principal{APIKey: &models.APIKey{ID: 1}}), handler.GetGlobalTopVideosHandler())
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	tokens := servedTokens(t, router, "viewer1")
+
+	// A failed interaction does not use the token, so the viewer can retry it.
+	fakeRedis.UpdateError = assert.AnError
+	assert.Equal(t, http.StatusInternalServerError, postTokenInteraction(router, "video1", "like", "viewer1", tokens["video1"]).Code)
+	fakeRedis.UpdateError = nil
+	assert.Equal(t, http.StatusOK, postTokenInteraction(router, "video1", "like", "viewer1", tokens["video1"]).Code)
+	assert.Equal(t, http.StatusConflict, postTokenInteraction(router, "video1", "like", "viewer1", tokens["video1"]).Code)
+}
+
+func TestInteractionTokens_Principal(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	handler := newTokenHandler(t, fakeRedis)
+	fakeRedis.TopVideosList = nil
+	for i := range handlers.MaxInteractionTokens + 20 {
+		fakeRedis.TopVideosList = append(fakeRedis.TopVideosList, models.RankedVideo{VideoID: "video" + strconv.Itoa(i+1)})
+	}
+	claims := &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "viewer1"}}
+	router := gin.New()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+	router.GET("/bearer/videos/top", authenticatedAs(auth.Principal{Claims: claims}), handler.GetGlobalTopVideosHandler())
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+
+	// Unauthenticated callers get no tokens, whatever viewer they declare.
+	assert.Empty(t, servedTokens(t, router, "viewer1")["video1"])
+
+	// Bearer tokens get tokens for their subject, for the first videos only.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/bearer/videos/top?detail=true&limit=200&viewer_id=viewer2", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var videos []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &videos))
+	assert.Len(t, videos, handlers.MaxInteractionTokens+20)
+	assert.NotEmpty(t, videos[handlers.MaxInteractionTokens-1].InteractionToken)
+	assert.Empty(t, videos[handlers.MaxInteractionTokens].InteractionToken)
+	assert.Equal(t, http.StatusForbidden, postTokenInteraction(router, "video1", "like", "viewer2", videos[0].InteractionToken).Code)
+	assert.Equal(t, http.StatusOK, postTokenInteraction(router, "video1", "like", "viewer1", videos[0].InteractionToken).Code)
+}
+
+func TestInteractionTokens_GRPC(t *testing.T) {
+	// The interceptor authenticates the calls with the bearer token of viewer1.
+	claims := &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "viewer1"}}
+	authenticate := grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(auth.NewContext(ctx, auth.Principal{Claims: claims}), req)
+	})
+	c := newGRPCHandlerClient(t, newTokenHandler(t, &FakeRedis{}), authenticate)
+
+	top, err := c.GetTopVideos(context.Background(), &rankingpb.GetTopVideosRequest{ViewerId: "viewer2"})
+	assert.NoError(t, err)
+	token := top.GetVideos()[0].GetInteractionToken()
+	assert.NotEmpty(t, token)
+
+	interaction := &rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1", ViewerId: "viewer1", Token: token}
+	_, err = c.RecordInteraction(context.Background(), interaction)
+	assert.NoError(t, err)
+	_, err = c.RecordInteraction(context.Background(), interaction)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	interaction.VideoId = "video2"
+	_, err = c.RecordInteraction(context.Background(), interaction)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -3,48 +3,625 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
 	"ranking-service/internal/handlers"
+	"ranking-service/internal/repository"
 	"ranking-service/models"
 )
 
 // FakeRedis simulates the Redis repository.
 type FakeRedis struct {
 	UpdateError   error
-	TopVideosList []string
+	TopVideosList []models.RankedVideo
 	GetError      error
+	Scores        map[string]float64
+	// Deltas sums the score deltas applied by UpdateVideoScore by video.
+	Deltas    map[string]float64
+	Removed   []string
+	Hidden    map[string]bool
+	Counters  map[string]map[string]float64
+	Published []models.RankingUpdate
+	Updates   chan models.RankingUpdate
+	// Leaderboards holds the scores of the owner and windowed leaderboards by name.
+	Leaderboards map[string]map[string]float64
+	// QuotaCounters counts the requests of API keys by key ID and minute or UTC day.
+	QuotaCounters map[string]int64
+	// RateLimitLogs holds the times of the requests counted by each rate limited key.
+	RateLimitLogs  map[string][]time.Time
+	RateLimitError error
+	// ViewerCapTotals holds the score contributed by each viewer, video and type.
+	ViewerCapTotals map[string]float64
+	// AnomalyWindows holds the anomaly detection state of each video.
+	AnomalyWindows map[string]*fakeAnomalyWindow
+	// TokenUses records the uses of interaction tokens by token ID.
+	TokenUses map[string]bool
+	// DecayedAt is the time the scores were last decayed to.
+	DecayedAt time.Time
+	// AppliedAnomalies holds the IDs of the claimed anomalies.
+	AppliedAnomalies map[uint]bool
+}
+
+// fakeAnomalyWindow is the current anomaly detection window of a video.
+type fakeAnomalyWindow struct {
+	start       time.Time
+	count       int64
+	viewers     []string
+	baseline    float64
+	hasBaseline bool
 }
 
 func (f *FakeRedis) UpdateVideoScore(videoID string, delta float64) error {
+	if f.UpdateError != nil {
+		return f.UpdateError
+	}
+	if f.Deltas == nil {
+		f.Deltas = map[string]float64{}
+	}
+	f.Deltas[videoID] += delta
+	return nil
+}
+
+func (f *FakeRedis) SetVideoScore(videoID string, score float64) error {
+	return f.UpdateError
+}
+
+func (f *FakeRedis) AdvanceDecayClock(now time.Time) (time.Duration, error) {
+	if f.UpdateError != nil {
+		return 0, f.UpdateError
+	}
+	if !f.DecayedAt.IsZero() && !now.After(f.DecayedAt) {
+		return 0, nil
+	}
+	previous := f.DecayedAt
+	f.DecayedAt = now
+	if previous.IsZero() {
+		return 0, nil
+	}
+	return now.Sub(previous), nil
+}
+
+func (f *FakeRedis) ScaleScores(factor float64) error {
+	for videoID := range f.Scores {
+		f.Scores[videoID] *= factor
+	}
+	for videoID := range f.Deltas {
+		f.Deltas[videoID] *= factor
+	}
+	for name, board := range f.Leaderboards {
+		if strings.HasPrefix(name, "owner-") {
+			for videoID := range board {
+				board[videoID] *= factor
+			}
+		}
+	}
 	return f.UpdateError
 }
 
-func (f *FakeRedis) GetTopVideos(limit int) ([]string, error) {
+func (f *FakeRedis) IncrementCounters(videoID string, increments map[string]float64) (map[string]float64, error) {
+	if f.Counters == nil {
+		f.Counters = map[string]map[string]float64{}
+	}
+	if f.Counters[videoID] == nil {
+		f.Counters[videoID] = map[string]float64{}
+	}
+	for name, value := range increments {
+		f.Counters[videoID][name] += value
+	}
+	return f.Counters[videoID], nil
+}
+
+func (f *FakeRedis) GetCounters(videoID string) (map[string]float64, error) {
+	return f.Counters[videoID], nil
+}
+
+func (f *FakeRedis) GetTopVideos(limit int) ([]models.RankedVideo, error) {
 	return f.TopVideosList, f.GetError
 }
 
+func (f *FakeRedis) ScanTopVideos(offset, limit int) ([]models.RankedVideo, int, error) {
+	if offset >= len(f.TopVideosList) {
+		return nil, 0, f.GetError
+	}
+	end := offset + limit
+	if end >= len(f.TopVideosList) {
+		return f.TopVideosList[offset:], 0, f.GetError
+	}
+	return f.TopVideosList[offset:end], end, f.GetError
+}
+
+func (f *FakeRedis) GetVideoScores(videoIDs []string) (map[string]float64, error) {
+	scores := map[string]float64{}
+	for _, videoID := range videoIDs {
+		if score, ok := f.Scores[videoID]; ok {
+			scores[videoID] = score
+		}
+	}
+	return scores, nil
+}
+
+func (f *FakeRedis) GetVideoRank(videoID string) (*models.RankInfo, error) {
+	info := &models.RankInfo{VideoID: videoID}
+	for i, v := range f.TopVideosList {
+		if v.VideoID != videoID {
+			continue
+		}
+		info.Rank = int64(i + 1)
+		info.Score = v.Score
+		if i > 0 {
+			above := f.TopVideosList[i-1]
+			info.Above = &above
+			info.Gap = above.Score - v.Score
+		}
+	}
+	return info, f.GetError
+}
+
+func (f *FakeRedis) GetVideoRanks(videoIDs []string) (map[string]int64, error) {
+	ranks := make(map[string]int64, len(videoIDs))
+	for _, videoID := range videoIDs {
+		for i, v := range f.TopVideosList {
+			if v.VideoID == videoID {
+				ranks[videoID] = int64(i + 1)
+			}
+		}
+	}
+	return ranks, f.GetError
+}
+
+// PublishRankingUpdate records the update and delivers it to the subscriber, if any.
+func (f *FakeRedis) PublishRankingUpdate(update models.RankingUpdate) error {
+	f.Published = append(f.Published, update)
+	if f.Updates != nil {
+		f.Updates <- update
+	}
+	return nil
+}
+
+func (f *FakeRedis) SubscribeRankingUpdates(ctx context.Context) (<-chan models.RankingUpdate, error) {
+	if f.Updates == nil {
+		f.Updates = make(chan models.RankingUpdate, 16)
+	}
+	return f.Updates, nil
+}
+
+func (f *FakeRedis) AcquireLock(name, owner string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func quotaCounterKeys(keyID uint, at time.Time) (minute, day string) {
+	return fmt.Sprintf("%d:minute:%d", keyID, at.Unix()/60), fmt.Sprintf("%d:day:%s", keyID, at.UTC().Format(time.DateOnly))
+}
+
+func (f *FakeRedis) ConsumeAPIKeyQuota(keyID uint, perMinute, daily int, at time.Time) (models.APIKeyUsage, bool, error) {
+	if f.QuotaCounters == nil {
+		f.QuotaCounters = make(map[string]int64)
+	}
+	minuteKey, dayKey := quotaCounterKeys(keyID, at)
+	usage := models.APIKeyUsage{Minute: f.QuotaCounters[minuteKey], Today: f.QuotaCounters[dayKey], PerMinuteQuota: perMinute, DailyQuota: daily}
+	if (perMinute > 0 && usage.Minute >= int64(perMinute)) || (daily > 0 && usage.Today >= int64(daily)) {
+		return usage, false, nil
+	}
+	f.QuotaCounters[minuteKey]++
+	f.QuotaCounters[dayKey]++
+	usage.Minute++
+	usage.Today++
+	return usage, true, nil
+}
+
+func (f *FakeRedis) CheckRateLimits(limits []models.RateLimit, at time.Time, requestID string) (models.RateLimitDecision, error) {
+	if f.RateLimitError != nil {
+		return models.RateLimitDecision{}, f.RateLimitError
+	}
+	if f.RateLimitLogs == nil {
+		f.RateLimitLogs = make(map[string][]time.Time)
+	}
+	decision := models.RateLimitDecision{Allowed: true, Index: -1}
+	for i, limit := range limits {
+		var log []time.Time
+		for _, t := range f.RateLimitLogs[limit.Key] {
+			if t.After(at.Add(-limit.Window)) {
+				log = append(log, t)
+			}
+		}
+		f.RateLimitLogs[limit.Key] = log
+		if len(log) >= limit.Limit {
+			retry := log[len(log)-limit.Limit].Add(limit.Window).Sub(at)
+			if decision.Allowed || retry > decision.RetryAfter {
+				decision = models.RateLimitDecision{Index: i, RetryAfter: retry}
+			}
+		}
+	}
+	if decision.Allowed {
+		for i, limit := range limits {
+			f.RateLimitLogs[limit.Key] = append(f.RateLimitLogs[limit.Key], at)
+			if remaining := limit.Limit - len(f.RateLimitLogs[limit.Key]); decision.Index < 0 || remaining < decision.Remaining {
+				decision.Index, decision.Remaining = i, remaining
+			}
+		}
+	}
+	limit := limits[decision.Index]
+	decision.Limit = limit.Limit
+	decision.Reset = f.RateLimitLogs[limit.Key][0].Add(limit.Window).Sub(at)
+	return decision, nil
+}
+
+func (f *FakeRedis) ConsumeViewerCap(videoID, viewerID, interactionType string, contribution, limit float64, period time.Duration, at time.Time) (bool, error) {
+	if f.ViewerCapTotals == nil {
+		f.ViewerCapTotals = make(map[string]float64)
+	}
+	key := fmt.Sprintf("%d:%s:%s:%s", at.Truncate(period).Unix(), interactionType, videoID, viewerID)
+	if f.ViewerCapTotals[key]+contribution > limit+1e-9 {
+		return false, nil
+	}
+	f.ViewerCapTotals[key] += contribution
+	return true, nil
+}
+
+func (f *FakeRedis) ConsumeInteractionToken(tokenID string, expiresAt time.Time) (bool, error) {
+	if f.TokenUses == nil {
+		f.TokenUses = map[string]bool{}
+	}
+	if f.TokenUses[tokenID] {
+		return false, nil
+	}
+	f.TokenUses[tokenID] = true
+	return true, nil
+}
+
+func (f *FakeRedis) ReleaseInteractionToken(tokenID string) error {
+	delete(f.TokenUses, tokenID)
+	return nil
+}
+
+func (f *FakeRedis) ClaimAnomaly(id uint) (bool, error) {
+	if f.AppliedAnomalies == nil {
+		f.AppliedAnomalies = map[uint]bool{}
+	}
+	if f.AppliedAnomalies[id] {
+		return false, nil
+	}
+	f.AppliedAnomalies[id] = true
+	return true, nil
+}
+
+func (f *FakeRedis) ReleaseAnomaly(id uint) error {
+	delete(f.AppliedAnomalies, id)
+	return nil
+}
+
+func (f *FakeRedis) ObserveInteraction(videoID, viewerID string, window time.Duration, baselineWeight float64, at time.Time) (models.AnomalyObservation, error) {
+	if f.AnomalyWindows == nil {
+		f.AnomalyWindows = map[string]*fakeAnomalyWindow{}
+	}
+	start := at.Truncate(window)
+	w := f.AnomalyWindows[videoID]
+	if w == nil {
+		w = &fakeAnomalyWindow{start: start}
+		f.AnomalyWindows[videoID] = w
+	}
+	if start.After(w.start) {
+		if w.hasBaseline {
+			w.baseline += baselineWeight * (float64(w.count) - w.baseline)
+		} else {
+			w.baseline, w.hasBaseline = float64(w.count), true
+		}
+		w.baseline *= math.Pow(1-baselineWeight, float64(start.Sub(w.start)/window-1))
+		w.start, w.count, w.viewers = start, 0, nil
+	}
+	w.count++
+	if viewerID != "" {
+		w.viewers = append(w.viewers, viewerID)
+	}
+	distinct := map[string]bool{}
+	for _, viewer := range w.viewers {
+		distinct[viewer] = true
+	}
+	return models.AnomalyObservation{
+		Interactions:       w.count,
+		ViewerInteractions: int64(len(w.viewers)),
+		DistinctViewers:    int64(len(distinct)),
+		Baseline:           w.baseline,
+		HasBaseline:        w.hasBaseline,
+	}, nil
+}
+
+func (f *FakeRedis) GetAPIKeyUsage(keyID uint, days int, at time.Time) (models.APIKeyUsage, error) {
+	minuteKey, _ := quotaCounterKeys(keyID, at)
+	usage := models.APIKeyUsage{Minute: f.QuotaCounters[minuteKey]}
+	for i := 0; i < days; i++ {
+		_, dayKey := quotaCounterKeys(keyID, at.AddDate(0, 0, -i))
+		usage.Daily = append(usage.Daily, models.APIKeyDailyUsage{Date: at.AddDate(0, 0, -i).UTC().Format(time.DateOnly), Requests: f.QuotaCounters[dayKey]})
+	}
+	if days > 0 {
+		usage.Today = usage.Daily[0].Requests
+	}
+	return usage, nil
+}
+
+func (f *FakeRedis) ScanLeaderboard(board models.Leaderboard, at time.Time, offset, limit int) ([]models.RankedVideo, int, error) {
+	if board.Name() == "global" {
+		return f.ScanTopVideos(offset, limit)
+	}
+	var videos []models.RankedVideo
+	for videoID, score := range f.Leaderboards[board.Name()] {
+		if !f.Hidden[videoID] {
+			videos = append(videos, models.RankedVideo{VideoID: videoID, Score: score})
+		}
+	}
+	sort.Slice(videos, func(i, j int) bool { return videos[i].Score > videos[j].Score })
+	if offset >= len(videos) {
+		return nil, 0, nil
+	}
+	if offset+limit >= len(videos) {
+		return videos[offset:], 0, nil
+	}
+	return videos[offset : offset+limit], offset + limit, nil
+}
+
+func (f *FakeRedis) UpdateLeaderboards(videoID, ownerID string, weight float64, windows []time.Duration, at time.Time) error {
+	f.addToLeaderboard(models.Leaderboard{Owner: ownerID}, videoID, weight)
+	for _, window := range windows {
+		f.addToLeaderboard(models.Leaderboard{Window: window}, videoID, weight)
+	}
+	return nil
+}
+
+func (f *FakeRedis) addToLeaderboard(board models.Leaderboard, videoID string, delta float64) {
+	if f.Leaderboards == nil {
+		f.Leaderboards = map[string]map[string]float64{}
+	}
+	if f.Leaderboards[board.Name()] == nil {
+		f.Leaderboards[board.Name()] = map[string]float64{}
+	}
+	f.Leaderboards[board.Name()][videoID] += delta
+}
+
+func (f *FakeRedis) SetVideoOwner(videoID, previousOwnerID, ownerID string) error {
+	previous := models.Leaderboard{Owner: previousOwnerID}.Name()
+	if score, ok := f.Leaderboards[previous][videoID]; ok {
+		delete(f.Leaderboards[previous], videoID)
+		f.addToLeaderboard(models.Leaderboard{Owner: ownerID}, videoID, score)
+	}
+	return nil
+}
+
+func (f *FakeRedis) RemoveVideo(videoID string) error {
+	f.Removed = append(f.Removed, videoID)
+	for _, board := range f.Leaderboards {
+		delete(board, videoID)
+	}
+	return nil
+}
+
+func (f *FakeRedis) ImportScores(scores []models.ImportedScore) error {
+	if f.Scores == nil {
+		f.Scores = map[string]float64{}
+	}
+	for _, s := range scores {
+		f.Scores[s.VideoID] = s.Score
+		if s.PreviousOwnerID != "" && s.PreviousOwnerID != s.OwnerID {
+			delete(f.Leaderboards[models.Leaderboard{Owner: s.PreviousOwnerID}.Name()], s.VideoID)
+		}
+		board := models.Leaderboard{Owner: s.OwnerID}
+		f.addToLeaderboard(board, s.VideoID, s.Score-f.Leaderboards[board.Name()][s.VideoID])
+		if s.Counters != nil {
+			if f.Counters == nil {
+				f.Counters = map[string]map[string]float64{}
+			}
+			f.Counters[s.VideoID] = maps.Clone(s.Counters)
+		}
+	}
+	return nil
+}
+
+func (f *FakeRedis) SetVideoHidden(videoID string, hidden bool) error {
+	if f.Hidden == nil {
+		f.Hidden = map[string]bool{}
+	}
+	f.Hidden[videoID] = hidden
+	return nil
+}
+
 // FakePostgres simulates the PostgreSQL repository.
 type FakePostgres struct {
 	UpdateError error
 	Videos      []models.Video
 	GetError    error
+	Catalog     map[string]*models.Video
+	Moderation  []models.ModerationEvent
+	Editorial   []models.EditorialRule
+	Formulas    []models.ScoringFormula
+	Stats       map[string]*models.VideoStats
+	Samples     []models.ScoreSample
+	Snapshots   []models.LeaderboardSnapshot
+	Webhooks    []models.WebhookSubscription
+	Deliveries  []models.WebhookDelivery
+	Checkpoints map[string]models.ImportCheckpoint
+	ImportError error
+	APIKeys     []models.APIKey
+	Anomalies   []models.Anomaly
+	// ReviewError fails the reviews of anomalies after their apply.
+	ReviewError error
 }
 
 func (f *FakePostgres) UpdateVideoScoreInPostgres(videoID, userID string, delta float64) error {
 	return f.UpdateError
 }
 
+func (f *FakePostgres) SetVideoScoreInPostgres(videoID, userID string, score float64) error {
+	return f.UpdateError
+}
+
+func (f *FakePostgres) ScaleVideoScores(factor float64) error {
+	for _, video := range f.Catalog {
+		video.Score *= factor
+	}
+	return f.UpdateError
+}
+
 func (f *FakePostgres) GetUserTopVideosFromDB(userID string, limit int) ([]models.Video, error) {
 	return f.Videos, f.GetError
 }
 
+func (f *FakePostgres) CreateVideo(video *models.Video) error {
+	if _, ok := f.Catalog[video.VideoID]; ok {
+		return repository.ErrVideoExists
+	}
+	if f.Catalog == nil {
+		f.Catalog = map[string]*models.Video{}
+	}
+	f.Catalog[video.VideoID] = video
+	return nil
+}
+
+func (f *FakePostgres) GetVideo(videoID string) (*models.Video, error) {
+	video, ok := f.Catalog[videoID]
+	if !ok {
+		return nil, repository.ErrVideoNotFound
+	}
+	return video, nil
+}
+
+func (f *FakePostgres) GetVideos(videoIDs []string) ([]models.Video, error) {
+	var videos []models.Video
+	for _, videoID := range videoIDs {
+		if video, ok := f.Catalog[videoID]; ok {
+			videos = append(videos, *video)
+		}
+	}
+	return videos, nil
+}
+
+func (f *FakePostgres) GetVideosWithDeleted(videoIDs []string) ([]models.Video, error) {
+	return f.GetVideos(videoIDs)
+}
+
+func (f *FakePostgres) GetExplorationCandidates(createdAfter time.Time, maxScore float64, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	for _, video := range f.Catalog {
+		if video.CreatedAt.After(createdAfter) && video.Score <= maxScore && len(videos) < limit {
+			videos = append(videos, *video)
+		}
+	}
+	return videos, nil
+}
+
+func (f *FakePostgres) UpdateVideo(video *models.Video) error {
+	f.Catalog[video.VideoID] = video
+	return nil
+}
+
+func (f *FakePostgres) SetModerationStatus(videoID, status, reason string) error {
+	video, ok := f.Catalog[videoID]
+	if !ok {
+		return repository.ErrVideoNotFound
+	}
+	video.ModerationStatus = status
+	video.ModerationReason = reason
+	f.Moderation = append(f.Moderation, models.ModerationEvent{VideoID: videoID, Status: status, Reason: reason})
+	return nil
+}
+
+func (f *FakePostgres) GetModerationHistory(videoID string) ([]models.ModerationEvent, error) {
+	return f.Moderation, nil
+}
+
+func (f *FakePostgres) CreateEditorialRule(rule *models.EditorialRule) error {
+	rule.ID = uint(len(f.Editorial) + 1)
+	f.Editorial = append(f.Editorial, *rule)
+	return nil
+}
+
+func (f *FakePostgres) ListEditorialRules() ([]models.EditorialRule, error) {
+	return f.Editorial, nil
+}
+
+func (f *FakePostgres) GetActiveEditorialRules(at time.Time) ([]models.EditorialRule, error) {
+	var rules []models.EditorialRule
+	for _, rule := range f.Editorial {
+		if !rule.StartsAt.After(at) && rule.EndsAt.After(at) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (f *FakePostgres) DeleteEditorialRule(id uint) error {
+	for i, rule := range f.Editorial {
+		if rule.ID == id {
+			f.Editorial = append(f.Editorial[:i], f.Editorial[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrEditorialRuleNotFound
+}
+
+func (f *FakePostgres) IncrementVideoStats(videoID string, increments map[string]float64) error {
+	if f.Stats == nil {
+		f.Stats = map[string]*models.VideoStats{}
+	}
+	stats, ok := f.Stats[videoID]
+	if !ok {
+		stats = &models.VideoStats{VideoID: videoID}
+		f.Stats[videoID] = stats
+	}
+	stats.Views += int64(increments["views"])
+	stats.Likes += int64(increments["likes"])
+	stats.Comments += int64(increments["comments"])
+	stats.Shares += int64(increments["shares"])
+	stats.Reports += int64(increments["reports"])
+	stats.WatchTime += increments["watch_time"]
+	stats.CappedInteractions += int64(increments["capped_interactions"])
+	stats.CappedScore += increments["capped_score"]
+	return nil
+}
+
+func (f *FakePostgres) GetVideoStats(videoID string) (*models.VideoStats, error) {
+	if stats, ok := f.Stats[videoID]; ok {
+		return stats, nil
+	}
+	return &models.VideoStats{VideoID: videoID}, nil
+}
+
+func (f *FakePostgres) SaveScoringFormula(formula *models.ScoringFormula) error {
+	f.Formulas = append(f.Formulas, *formula)
+	return nil
+}
+
+func (f *FakePostgres) GetActiveScoringFormula() (*models.ScoringFormula, error) {
+	if len(f.Formulas) == 0 {
+		return nil, repository.ErrScoringFormulaNotFound
+	}
+	return &f.Formulas[len(f.Formulas)-1], nil
+}
+
+func (f *FakePostgres) DeleteVideo(videoID string, hard bool) error {
+	video, ok := f.Catalog[videoID]
+	if !ok || video.Status == models.VideoStatusDeleted {
+		return repository.ErrVideoNotFound
+	}
+	if hard {
+		delete(f.Catalog, videoID)
+	} else {
+		video.Status = models.VideoStatusDeleted
+	}
+	return nil
+}
+
 // --- Unit Test Cases ---
 
 func TestUpdateVideoScoreHandler_Success(t *testing.T) {
@@ -115,7 +692,10 @@ func TestUpdateVideoScoreHandler_MissingUserID(t *testing.T) {
 func TestGetGlobalTopVideosHandler(t *testing.T) {
 	// Set up fake Redis to return a list of videos.
 	fakeRedis := &FakeRedis{
-		TopVideosList: []string{"video1", "video2"},
+		TopVideosList: []models.RankedVideo{
+			{VideoID: "video1", Score: 10},
+			{VideoID: "video2", Score: 5},
+		},
 	}
 	// Postgres not used in this endpoint.
 	fakePostgres := &FakePostgres{}
@@ -138,6 +718,29 @@ func TestGetGlobalTopVideosHandler(t *testing.T) {
 	assert.Equal(t, "video2", resp[1])
 }
 
+func TestGetGlobalTopVideosHandler_LimitBounds(t *testing.T) {
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{
+			{VideoID: "video1", Score: 10},
+			{VideoID: "video2", Score: 5},
+		},
+	}
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis)
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	for limit, expected := range map[string]int{"-1": 1, "0": 1, "100000": 2} {
+		req, _ := http.NewRequest("GET", "/videos/top?limit="+limit, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, expected, "limit=%s", limit)
+	}
+}
+
 func TestGetUserTopVideosHandler(t *testing.T) {
 	// Set up fake Postgres to return a slice of Video models.
 	fakePostgres := &FakePostgres{
@@ -169,3 +772,356 @@ func TestGetUserTopVideosHandler(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, 2, len(videos))
 }
+
+func (f *FakePostgres) RecordScoreSamples(interactedSince, at time.Time) (int64, error) {
+	var recorded int64
+	for _, video := range f.Catalog {
+		if video.LastInteractionAt != nil && !video.LastInteractionAt.Before(interactedSince) {
+			f.Samples = append(f.Samples, models.ScoreSample{VideoID: video.VideoID, SampledAt: at, Score: video.Score})
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+func (f *FakePostgres) GetScoreSamples(videoID string, from, to time.Time) ([]models.ScoreSample, error) {
+	var before *models.ScoreSample
+	var samples []models.ScoreSample
+	for i, sample := range f.Samples {
+		if sample.VideoID != videoID {
+			continue
+		}
+		if sample.SampledAt.Before(from) {
+			if before == nil || sample.SampledAt.After(before.SampledAt) {
+				before = &f.Samples[i]
+			}
+		} else if sample.SampledAt.Before(to) {
+			samples = append(samples, sample)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].SampledAt.Before(samples[j].SampledAt)
+	})
+	if before != nil {
+		samples = append([]models.ScoreSample{*before}, samples...)
+	}
+	return samples, nil
+}
+
+func (f *FakePostgres) DeleteScoreSamplesBefore(before time.Time) (int64, error) {
+	kept := f.Samples[:0]
+	for _, sample := range f.Samples {
+		if !sample.SampledAt.Before(before) {
+			kept = append(kept, sample)
+		}
+	}
+	deleted := int64(len(f.Samples) - len(kept))
+	f.Samples = kept
+	return deleted, nil
+}
+
+func (f *FakePostgres) SaveSnapshot(snapshot *models.LeaderboardSnapshot) error {
+	for _, existing := range f.Snapshots {
+		if existing.Ranking == snapshot.Ranking && existing.TakenAt.Equal(snapshot.TakenAt) {
+			return repository.ErrSnapshotExists
+		}
+	}
+	snapshot.ID = uint(len(f.Snapshots) + 1)
+	f.Snapshots = append(f.Snapshots, *snapshot)
+	return nil
+}
+
+func (f *FakePostgres) GetNearestSnapshot(ranking string, at time.Time, within time.Duration) (*models.LeaderboardSnapshot, error) {
+	var nearest *models.LeaderboardSnapshot
+	for i, snapshot := range f.Snapshots {
+		if snapshot.Ranking != ranking {
+			continue
+		}
+		if nearest == nil || snapshot.TakenAt.Sub(at).Abs() < nearest.TakenAt.Sub(at).Abs() {
+			nearest = &f.Snapshots[i]
+		}
+	}
+	if nearest == nil || (within > 0 && nearest.TakenAt.Sub(at).Abs() > within) {
+		return nil, repository.ErrSnapshotNotFound
+	}
+	return nearest, nil
+}
+
+func (f *FakePostgres) DeleteSnapshotsBefore(before time.Time) (int64, error) {
+	kept := f.Snapshots[:0]
+	for _, snapshot := range f.Snapshots {
+		if !snapshot.TakenAt.Before(before) {
+			kept = append(kept, snapshot)
+		}
+	}
+	deleted := int64(len(f.Snapshots) - len(kept))
+	f.Snapshots = kept
+	return deleted, nil
+}
+
+func (f *FakePostgres) CreateWebhookSubscription(subscription *models.WebhookSubscription) error {
+	subscription.ID = uint(len(f.Webhooks) + 1)
+	subscription.CreatedAt = time.Now()
+	f.Webhooks = append(f.Webhooks, *subscription)
+	return nil
+}
+
+func (f *FakePostgres) ListWebhookSubscriptions() ([]models.WebhookSubscription, error) {
+	return f.Webhooks, nil
+}
+
+func (f *FakePostgres) GetWebhookSubscription(id uint) (*models.WebhookSubscription, error) {
+	for i := range f.Webhooks {
+		if f.Webhooks[i].ID == id {
+			subscription := f.Webhooks[i]
+			return &subscription, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (f *FakePostgres) DeleteWebhookSubscription(id uint) error {
+	for i := range f.Webhooks {
+		if f.Webhooks[i].ID == id {
+			f.Webhooks = append(f.Webhooks[:i], f.Webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrWebhookNotFound
+}
+
+func (f *FakePostgres) CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		delivery.ID = uint(len(f.Deliveries) + 1)
+		delivery.CreatedAt = time.Now()
+		f.Deliveries = append(f.Deliveries, delivery)
+	}
+	return nil
+}
+
+func (f *FakePostgres) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	for i := range f.Deliveries {
+		d := &f.Deliveries[i]
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == models.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			claimed = append(claimed, *d)
+			d.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *FakePostgres) GetWebhookDelivery(id uint) (*models.WebhookDelivery, error) {
+	for i := range f.Deliveries {
+		if f.Deliveries[i].ID == id {
+			delivery := f.Deliveries[i]
+			return &delivery, nil
+		}
+	}
+	return nil, repository.ErrWebhookDeliveryNotFound
+}
+
+func (f *FakePostgres) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	for i := range f.Deliveries {
+		if f.Deliveries[i].ID == delivery.ID {
+			f.Deliveries[i] = *delivery
+			return nil
+		}
+	}
+	return repository.ErrWebhookDeliveryNotFound
+}
+
+func (f *FakePostgres) ListWebhookDeliveries(subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for i := len(f.Deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		d := f.Deliveries[i]
+		if (subscriptionID == 0 || d.SubscriptionID == subscriptionID) && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (f *FakePostgres) GetVideosStats(videoIDs []string) ([]models.VideoStats, error) {
+	var stats []models.VideoStats
+	for _, videoID := range videoIDs {
+		if s, ok := f.Stats[videoID]; ok {
+			stats = append(stats, *s)
+		}
+	}
+	return stats, nil
+}
+
+func (f *FakePostgres) GetImportCheckpoint(name string) (*models.ImportCheckpoint, error) {
+	checkpoint, ok := f.Checkpoints[name]
+	if !ok {
+		return nil, repository.ErrImportCheckpointNotFound
+	}
+	return &checkpoint, nil
+}
+
+func (f *FakePostgres) ImportVideos(videos []models.Video, checkpoint *models.ImportCheckpoint) error {
+	if f.ImportError != nil {
+		return f.ImportError
+	}
+	if f.Catalog == nil {
+		f.Catalog = map[string]*models.Video{}
+	}
+	for _, v := range videos {
+		video := v
+		if current, ok := f.Catalog[v.VideoID]; ok {
+			video.Status, video.ModerationStatus = current.Status, current.ModerationStatus
+		}
+		f.Catalog[v.VideoID] = &video
+	}
+	f.saveCheckpoint(checkpoint)
+	return nil
+}
+
+func (f *FakePostgres) ImportScores(videos []models.Video, stats []models.VideoStats, checkpoint *models.ImportCheckpoint) error {
+	if f.ImportError != nil {
+		return f.ImportError
+	}
+	if f.Catalog == nil {
+		f.Catalog = map[string]*models.Video{}
+	}
+	if f.Stats == nil {
+		f.Stats = map[string]*models.VideoStats{}
+	}
+	for _, v := range videos {
+		if current, ok := f.Catalog[v.VideoID]; ok {
+			current.Score, current.LastInteractionAt = v.Score, v.LastInteractionAt
+			continue
+		}
+		video := v
+		f.Catalog[v.VideoID] = &video
+	}
+	for _, s := range stats {
+		row := s
+		f.Stats[s.VideoID] = &row
+	}
+	f.saveCheckpoint(checkpoint)
+	return nil
+}
+
+func (f *FakePostgres) saveCheckpoint(checkpoint *models.ImportCheckpoint) {
+	if f.Checkpoints == nil {
+		f.Checkpoints = map[string]models.ImportCheckpoint{}
+	}
+	f.Checkpoints[checkpoint.Name] = *checkpoint
+}
+
+func (f *FakePostgres) CreateAPIKey(key *models.APIKey) error {
+	key.ID = uint(len(f.APIKeys) + 1)
+	key.CreatedAt = time.Now()
+	f.APIKeys = append(f.APIKeys, *key)
+	return nil
+}
+
+func (f *FakePostgres) ListAPIKeys() ([]models.APIKey, error) {
+	return f.APIKeys, nil
+}
+
+func (f *FakePostgres) GetAPIKey(id uint) (*models.APIKey, error) {
+	for i := range f.APIKeys {
+		if f.APIKeys[i].ID == id {
+			key := f.APIKeys[i]
+			return &key, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (f *FakePostgres) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	for i := range f.APIKeys {
+		if f.APIKeys[i].Hash == hash || f.APIKeys[i].PreviousHash == hash {
+			key := f.APIKeys[i]
+			return &key, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (f *FakePostgres) UpdateAPIKey(key *models.APIKey) error {
+	for i := range f.APIKeys {
+		if f.APIKeys[i].ID == key.ID {
+			f.APIKeys[i] = *key
+			return nil
+		}
+	}
+	return repository.ErrAPIKeyNotFound
+}
+
+func (f *FakePostgres) QuarantineInteraction(anomaly models.Anomaly, increments map[string]float64, delta float64) error {
+	index := -1
+	for i := range f.Anomalies {
+		if f.Anomalies[i].VideoID == anomaly.VideoID && f.Anomalies[i].Status == models.AnomalyPending {
+			index = i
+		}
+	}
+	now := time.Now()
+	if index < 0 {
+		anomaly.ID = uint(len(f.Anomalies) + 1)
+		anomaly.Status = models.AnomalyPending
+		anomaly.DetectedAt = now
+		f.Anomalies = append(f.Anomalies, anomaly)
+		index = len(f.Anomalies) - 1
+	}
+	pending := &f.Anomalies[index]
+	pending.Reason, pending.WindowInteractions, pending.Baseline, pending.Diversity = anomaly.Reason, anomaly.WindowInteractions, anomaly.Baseline, anomaly.Diversity
+	pending.Interactions++
+	pending.Delta += delta
+	pending.Views += int64(increments["views"])
+	pending.Likes += int64(increments["likes"])
+	pending.Comments += int64(increments["comments"])
+	pending.Shares += int64(increments["shares"])
+	pending.Reports += int64(increments["reports"])
+	pending.WatchTime += increments["watch_time"]
+	pending.UpdatedAt = now
+	return nil
+}
+
+func (f *FakePostgres) ListAnomalies(status string, limit int) ([]models.Anomaly, error) {
+	anomalies := []models.Anomaly{}
+	for _, anomaly := range f.Anomalies {
+		if (status == "" || anomaly.Status == status) && len(anomalies) < limit {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies, nil
+}
+
+func (f *FakePostgres) GetAnomaly(id uint) (*models.Anomaly, error) {
+	for _, anomaly := range f.Anomalies {
+		if anomaly.ID == id {
+			return &anomaly, nil
+		}
+	}
+	return nil, repository.ErrAnomalyNotFound
+}
+
+func (f *FakePostgres) ReviewAnomaly(id uint, status string, at time.Time, apply func(*models.Anomaly) error) (*models.Anomaly, error) {
+	for i := range f.Anomalies {
+		if f.Anomalies[i].ID != id {
+			continue
+		}
+		if f.Anomalies[i].Status != models.AnomalyPending {
+			return nil, repository.ErrAnomalyReviewed
+		}
+		if apply != nil {
+			if err := apply(&f.Anomalies[i]); err != nil {
+				return nil, err
+			}
+		}
+		if f.ReviewError != nil {
+			return nil, f.ReviewError
+		}
+		f.Anomalies[i].Status, f.Anomalies[i].ReviewedAt = status, &at
+		anomaly := f.Anomalies[i]
+		return &anomaly, nil
+	}
+	return nil, repository.ErrAnomalyNotFound
+}
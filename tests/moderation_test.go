@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+)
+
+func TestSetModerationStatusHandler(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user123", ModerationStatus: models.ModerationVisible},
+	}}
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+	router := gin.Default()
+	router.PUT("/admin/videos/:video_id/moderation", handler.SetModerationStatusHandler())
+
+	bodyBytes, _ := json.Marshal(models.ModerationRequest{Status: models.ModerationBanned, Reason: "spam"})
+	req, _ := http.NewRequest("PUT", "/admin/videos/video1/moderation", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.ModerationBanned, fakePostgres.Catalog["video1"].ModerationStatus)
+	assert.True(t, fakeRedis.Hidden["video1"])
+	assert.Equal(t, 1, len(fakePostgres.Moderation))
+
+	// Making the video visible again puts it back in the rankings.
+	bodyBytes, _ = json.Marshal(models.ModerationRequest{Status: models.ModerationVisible})
+	req, _ = http.NewRequest("PUT", "/admin/videos/video1/moderation", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, fakeRedis.Hidden["video1"])
+}
+
+func TestSetModerationStatusHandler_UnknownStatus(t *testing.T) {
+	handler := handlers.NewRankingHandler(&FakePostgres{}, &FakeRedis{})
+	router := gin.Default()
+	router.PUT("/admin/videos/:video_id/moderation", handler.SetModerationStatusHandler())
+
+	bodyBytes, _ := json.Marshal(models.ModerationRequest{Status: "deleted"})
+	req, _ := http.NewRequest("PUT", "/admin/videos/video1/moderation", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
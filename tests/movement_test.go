@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+)
+
+func TestGetGlobalTopVideosHandler_Movement(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	_ = fakePostgres.SaveSnapshot(&models.LeaderboardSnapshot{
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: time.Now().Add(-24 * time.Hour),
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video1", Score: 30},
+			{Rank: 2, VideoID: "video2", Score: 20},
+			{Rank: 3, VideoID: "video3", Score: 10},
+			{Rank: 4, VideoID: "video4", Score: 5},
+		},
+	})
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{
+		{VideoID: "video4", Score: 50},
+		{VideoID: "video2", Score: 40},
+		{VideoID: "video5", Score: 35},
+		{VideoID: "video1", Score: 31},
+	}}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithConfig(config.RankingConfig{
+		SnapshotInterval: time.Hour,
+		MovementWindow:   24 * time.Hour,
+	}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []models.RankedVideo{
+		{VideoID: "video4", Score: 50, PreviousRank: 4, Movement: models.MovementUp, RankChange: 3},
+		{VideoID: "video2", Score: 40, PreviousRank: 2, Movement: models.MovementSame},
+		{VideoID: "video5", Score: 35, Movement: models.MovementNew},
+		{VideoID: "video1", Score: 31, PreviousRank: 1, Movement: models.MovementDown, RankChange: -3},
+	}, resp)
+}
+
+func TestGetGlobalTopVideosHandler_MovementPinned(t *testing.T) {
+	fakePostgres := &FakePostgres{Editorial: []models.EditorialRule{{
+		ID:       1,
+		VideoID:  "promo",
+		Kind:     models.EditorialPin,
+		Position: 1,
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(time.Hour),
+	}}}
+	_ = fakePostgres.SaveSnapshot(&models.LeaderboardSnapshot{
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: time.Now().Add(-24 * time.Hour),
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video1", Score: 30},
+			{Rank: 2, VideoID: "video2", Score: 20},
+		},
+	})
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{
+			{VideoID: "video1", Score: 40},
+			{VideoID: "video2", Score: 25},
+		},
+		Scores: map[string]float64{"promo": 0.5},
+	}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithConfig(config.RankingConfig{
+		SnapshotInterval: time.Hour,
+		MovementWindow:   24 * time.Hour,
+	}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The pin moves the page down, not the organic ranks.
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []models.RankedVideo{
+		{VideoID: "promo", Score: 0.5, Editorial: models.EditorialPin},
+		{VideoID: "video1", Score: 40, PreviousRank: 1, Movement: models.MovementSame},
+		{VideoID: "video2", Score: 25, PreviousRank: 2, Movement: models.MovementSame},
+	}, resp)
+}
+
+func TestGetGlobalTopVideosHandler_MovementWithoutSnapshot(t *testing.T) {
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{{VideoID: "video1", Score: 50}}}
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis, handlers.WithConfig(config.RankingConfig{
+		SnapshotInterval: time.Hour,
+		MovementWindow:   24 * time.Hour,
+	}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []models.RankedVideo{{VideoID: "video1", Score: 50}}, resp)
+}
+
+func TestDiffSnapshotsHandler(t *testing.T) {
+	friday := time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC)
+	fakePostgres := &FakePostgres{}
+	_ = fakePostgres.SaveSnapshot(&models.LeaderboardSnapshot{
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: friday,
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video1", Score: 30},
+			{Rank: 2, VideoID: "video2", Score: 20},
+			{Rank: 3, VideoID: "video3", Score: 10},
+		},
+	})
+	_ = fakePostgres.SaveSnapshot(&models.LeaderboardSnapshot{
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: friday.Add(24 * time.Hour),
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video2", Score: 40},
+			{Rank: 2, VideoID: "video4", Score: 35},
+			{Rank: 3, VideoID: "video1", Score: 31},
+		},
+	})
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{}, handlers.WithConfig(config.RankingConfig{SnapshotInterval: time.Hour}))
+	router := gin.Default()
+	router.GET("/videos/top/diff", handler.DiffSnapshotsHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top/diff?from=2025-01-03T20:00:00Z&to=2025-01-04T20:05:00Z&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.SnapshotDiff
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, friday, resp.From)
+	assert.Equal(t, []models.SnapshotDiffEntry{
+		{VideoID: "video2", Rank: 1, PreviousRank: 2, Score: 40, PreviousScore: 20, Movement: models.MovementUp, RankChange: 1},
+		{VideoID: "video4", Rank: 2, Score: 35, Movement: models.MovementNew},
+	}, resp.Entries)
+	assert.Equal(t, []models.SnapshotDiffEntry{
+		{VideoID: "video1", Rank: 3, PreviousRank: 1, Score: 31, PreviousScore: 30, Movement: models.MovementDropped, RankChange: -2},
+	}, resp.Dropped)
+
+	req, _ = http.NewRequest("GET", "/videos/top/diff?from=2024-01-01T00:00:00Z&to=2025-01-04T20:00:00Z", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ranking-service/config"
+	"ranking-service/internal/grpcserver"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/ratelimit"
+	"ranking-service/models"
+	"ranking-service/proto/rankingpb"
+)
+
+func TestRateLimit_ParseRules(t *testing.T) {
+	rules, err := ratelimit.ParseRules("interaction:ip=120/1m, interaction:viewer=10/30s,batch:client=5/1h")
+	assert.NoError(t, err)
+	assert.Len(t, rules[ratelimit.RouteInteraction], 2)
+	assert.Equal(t, ratelimit.Rule{Key: ratelimit.KeyClient, Limit: 5, Window: time.Hour}, rules[ratelimit.RouteBatch][0])
+
+	for _, spec := range []string{
+		"interaction:ip=120",
+		"videos:ip=120/1m",
+		"batch:user=10/1m",
+		"interaction:ip=0/1m",
+		"interaction:ip=10/10ms",
+	} {
+		_, err := ratelimit.ParseRules(spec)
+		assert.Error(t, err, spec)
+	}
+
+	// The default rules are valid.
+	_, err = ratelimit.New(&FakeRedis{}, config.RateLimitConfig{Rules: "interaction:client=6000/1m,interaction:ip=300/1m,interaction:viewer=60/1m,interaction:video=3000/1m,batch:client=6000/1m,batch:viewer=60/1m,batch:video=3000/1m,grpc_interaction:client=6000/1m,grpc_interaction:viewer=60/1m,grpc_interaction:video=3000/1m"})
+	assert.NoError(t, err)
+}
+
+// newRateLimitRouter serves the interaction routes limited by rules.
+func newRateLimitRouter(t *testing.T, fakeRedis *FakeRedis, rules string) *gin.Engine {
+	limiter, err := ratelimit.New(fakeRedis, config.RateLimitConfig{Enabled: true, Rules: rules})
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis, handlers.WithRateLimiter(limiter))
+	router := gin.New()
+	router.POST("/videos/:video_id/interaction", limiter.Middleware(ratelimit.RouteInteraction), handler.UpdateVideoScoreHandler())
+	router.POST("/interactions/batch", handler.RecordInteractionsHandler())
+	return router
+}
+
+func postInteraction(router *gin.Engine, videoID, viewerID, ip string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body := `{"user_id":"user1","type":"like","viewer_id":"` + viewerID + `"}`
+	req, _ := http.NewRequest(http.MethodPost, "/videos/"+videoID+"/interaction", strings.NewReader(body))
+	req.RemoteAddr = ip + ":1234"
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_Viewer(t *testing.T) {
+	router := newRateLimitRouter(t, &FakeRedis{}, "interaction:viewer=2/1m,interaction:ip=100/1m")
+
+	w := postInteraction(router, "video1", "viewer1", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video2", "viewer1", "10.0.0.1").Code)
+
+	w = postInteraction(router, "video3", "viewer1", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"viewer"`)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.True(t, retryAfter > 0 && retryAfter <= 60)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// Other viewers are not limited, and interactions without viewer skip the rule.
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "viewer2", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "", "10.0.0.1").Code)
+}
+
+func TestRateLimit_IPAndVideo(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	router := newRateLimitRouter(t, fakeRedis, "interaction:ip=3/1m,interaction:video=2/1m")
+
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "viewer1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "viewer2", "10.0.0.2").Code)
+	w := postInteraction(router, "video1", "viewer3", "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"video"`)
+
+	// Rejected requests are not counted against the other limits.
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video2", "viewer1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video3", "viewer1", "10.0.0.1").Code)
+	w = postInteraction(router, "video4", "viewer1", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"ip"`)
+
+	// The batch route has its own rules.
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/interactions/batch", strings.NewReader(`{"interactions":[]}`))
+	req.RemoteAddr = "10.0.0.1:1234"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimit_BatchPerInteraction(t *testing.T) {
+	router := newRateLimitRouter(t, &FakeRedis{}, "batch:viewer=2/1m,batch:video=3/1m")
+
+	batch := `{"interactions":[
+		{"video_id":"video1","type":"like","user_id":"user1","viewer_id":"viewer1"},
+		{"video_id":"video2","type":"like","user_id":"user1","viewer_id":"viewer1"},
+		{"video_id":"video3","type":"like","user_id":"user1","viewer_id":"viewer1"},
+		{"video_id":"video1","type":"like","user_id":"user1","viewer_id":"viewer2"},
+		{"video_id":"video1","type":"like","user_id":"user1","viewer_id":"viewer3"},
+		{"video_id":"video1","type":"like","user_id":"user1","viewer_id":"viewer4"}
+	]}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/interactions/batch", strings.NewReader(batch))
+	router.ServeHTTP(w, req)
+
+	// Each interaction is charged against the viewer and video limits.
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.BatchInteractionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 4, resp.Accepted)
+	assert.Equal(t, []models.RejectedInteraction{
+		{Index: 2, VideoID: "video3", Error: "Rate limit of the viewer exceeded"},
+		{Index: 5, VideoID: "video1", Error: "Rate limit of the video exceeded"},
+	}, resp.Rejected)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimit_RedisUnavailable(t *testing.T) {
+	router := newRateLimitRouter(t, &FakeRedis{RateLimitError: errors.New("connection refused")}, "interaction:ip=1/1m")
+
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "viewer1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, postInteraction(router, "video1", "viewer1", "10.0.0.1").Code)
+}
+
+func TestRateLimit_GRPC(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	limiter, err := ratelimit.New(fakeRedis, config.RateLimitConfig{Enabled: true, Rules: "grpc_interaction:viewer=1/1m"})
+	assert.NoError(t, err)
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis, handlers.WithRateLimiter(limiter))
+	c := newGRPCHandlerClient(t, handler, grpc.UnaryInterceptor(grpcserver.UnaryRateLimitInterceptor(limiter)))
+
+	interaction := &rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1", ViewerId: "viewer1"}
+	_, err = c.RecordInteraction(context.Background(), interaction)
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	_, err = c.RecordInteraction(context.Background(), interaction, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+
+	// Each interaction of a stream is charged against the limits.
+	stream, err := c.StreamInteractions(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&rankingpb.Interaction{VideoId: "video1", Type: "like", UserId: "user1", ViewerId: "viewer2"}))
+	assert.NoError(t, stream.Send(&rankingpb.Interaction{VideoId: "video2", Type: "like", UserId: "user1", ViewerId: "viewer2"}))
+	summary, err := stream.CloseAndRecv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), summary.GetAccepted())
+	if assert.Len(t, summary.GetRejected(), 1) {
+		assert.Equal(t, int64(1), summary.GetRejected()[0].GetIndex())
+		assert.Equal(t, "rate limit of the viewer exceeded", summary.GetRejected()[0].GetError())
+	}
+
+	// Reads are not limited.
+	fakeRedis.TopVideosList = []models.RankedVideo{{VideoID: "video1", Score: 1}}
+	for range 3 {
+		_, err = c.GetTopVideos(context.Background(), &rankingpb.GetTopVideosRequest{})
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func TestNewScorer(t *testing.T) {
+	scorer, err := scoring.New(config.RankingConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, scoring.StrategyLinear, scorer.Name())
+
+	scorer, err = scoring.New(config.RankingConfig{Strategy: scoring.StrategyTimeDecay, DecayHalfLife: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, scoring.StrategyTimeDecay, scorer.Name())
+
+	_, err = scoring.New(config.RankingConfig{Strategy: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestLinearScorer(t *testing.T) {
+	scorer := scoring.NewLinearScorer(scoring.DefaultWeights)
+
+	update, err := scorer.Score(scoring.Interaction{Type: "share"}, scoring.VideoState{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, update.Delta)
+	assert.Nil(t, update.Score)
+
+	update, err = scorer.Score(scoring.Interaction{Type: "watch_time", Weight: 3.5}, scoring.VideoState{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.5, update.Delta)
+
+	_, err = scorer.Score(scoring.Interaction{Type: "unknown"}, scoring.VideoState{})
+	assert.ErrorIs(t, err, scoring.ErrUnknownInteraction)
+}
+
+func TestTimeDecayScorer(t *testing.T) {
+	scorer := scoring.NewTimeDecayScorer(scoring.NewLinearScorer(scoring.DefaultWeights), time.Hour)
+	now := time.Now()
+
+	// Interactions add their weight, whatever the time since the last one: the
+	// scores of every video decay together.
+	update, err := scorer.Score(scoring.Interaction{Type: "like", At: now}, scoring.VideoState{
+		Exists:            true,
+		Score:             10,
+		LastInteractionAt: now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, update.Score)
+	assert.Equal(t, 1.0, update.Delta)
+	assert.Equal(t, 1.0, update.Weight)
+
+	// A score is worth half after one half-life.
+	assert.Equal(t, 1.0, scorer.Factor(0))
+	assert.InDelta(t, 0.5, scorer.Factor(time.Hour), 1e-9)
+	assert.InDelta(t, 0.25, scorer.Factor(2*time.Hour), 1e-9)
+}
+
+func TestDecayScores(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{"video1": {VideoID: "video1", Score: 8}}}
+	fakeRedis := &FakeRedis{Scores: map[string]float64{"video1": 8}}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis,
+		handlers.WithScorer(scoring.NewTimeDecayScorer(scoring.NewLinearScorer(scoring.DefaultWeights), time.Hour)))
+	now := time.Now()
+
+	// The first decay starts the clock, the next ones decay by the time elapsed since.
+	assert.NoError(t, handler.DecayScores(now))
+	assert.Equal(t, 8.0, fakeRedis.Scores["video1"])
+	assert.NoError(t, handler.DecayScores(now.Add(time.Hour)))
+	assert.InDelta(t, 4.0, fakeRedis.Scores["video1"], 1e-9)
+	assert.InDelta(t, 4.0, fakePostgres.Catalog["video1"].Score, 1e-9)
+
+	// A replica behind the clock does not decay the scores again.
+	assert.NoError(t, handler.DecayScores(now.Add(30*time.Minute)))
+	assert.InDelta(t, 4.0, fakeRedis.Scores["video1"], 1e-9)
+
+	// Other strategies do not decay.
+	linear := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+	assert.NoError(t, linear.DecayScores(now.Add(5*time.Hour)))
+	assert.InDelta(t, 4.0, fakeRedis.Scores["video1"], 1e-9)
+}
+
+func TestFormulaScorer_Weight(t *testing.T) {
+	scorer, err := scoring.NewFormulaScorer("likes * 2 + views")
+	assert.NoError(t, err)
+
+	// The weight is what the interaction adds to the score of the other counters,
+	// whatever the stored score.
+	update, err := scorer.Score(scoring.Interaction{Type: "like"}, scoring.VideoState{
+		Exists:   true,
+		Score:    100,
+		Counters: map[string]float64{scoring.CounterLikes: 3, scoring.CounterViews: 4},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, *update.Score)
+	assert.Equal(t, -90.0, update.Delta)
+	assert.Equal(t, 2.0, update.Weight)
+}
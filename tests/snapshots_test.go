@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+)
+
+func snapshotFixture(takenAt time.Time) *FakePostgres {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", Title: "First", Score: 100},
+		"video2": {VideoID: "video2", UserID: "user2", Score: 100},
+	}}
+	_ = fakePostgres.SaveSnapshot(&models.LeaderboardSnapshot{
+		Ranking: models.SnapshotRankingGlobal,
+		TakenAt: takenAt,
+		Entries: []models.SnapshotEntry{
+			{Rank: 1, VideoID: "video1", UserID: "user1", Score: 30},
+			{Rank: 2, VideoID: "video2", UserID: "user2", Score: 20},
+			{Rank: 3, VideoID: "video3", UserID: "user1", Score: 10},
+		},
+	})
+	return fakePostgres
+}
+
+func TestGetGlobalTopVideosHandler_At(t *testing.T) {
+	takenAt := time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC)
+	fakePostgres := snapshotFixture(takenAt)
+	fakePostgres.Editorial = []models.EditorialRule{
+		{VideoID: "video3", Kind: models.EditorialPin, Position: 1, StartsAt: takenAt.Add(-time.Hour), EndsAt: takenAt.Add(time.Hour)},
+		// Not active at the time of the query.
+		{VideoID: "video2", Kind: models.EditorialBoost, Multiplier: 10, StartsAt: takenAt.Add(24 * time.Hour), EndsAt: takenAt.Add(48 * time.Hour)},
+	}
+	fakeRedis := &FakeRedis{TopVideosList: []models.RankedVideo{{VideoID: "video2", Score: 500}}}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithConfig(config.RankingConfig{SnapshotInterval: time.Hour}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?detail=true&limit=2&at=2025-01-03T20:10:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2025-01-03T20:00:00Z", w.Header().Get("X-Snapshot-Taken-At"))
+	var resp []models.RankedVideo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []models.RankedVideo{
+		{VideoID: "video3", Score: 10, Editorial: models.EditorialPin},
+		{VideoID: "video1", Score: 30},
+	}, resp)
+}
+
+func TestGetGlobalTopVideosHandler_AtWithoutSnapshot(t *testing.T) {
+	fakePostgres := snapshotFixture(time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC))
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{}, handlers.WithConfig(config.RankingConfig{SnapshotInterval: time.Hour}))
+	router := gin.Default()
+	router.GET("/videos/top", handler.GetGlobalTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/top?at=2024-06-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("GET", "/videos/top?at=last-friday", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetUserTopVideosHandler_At(t *testing.T) {
+	fakePostgres := snapshotFixture(time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC))
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{}, handlers.WithConfig(config.RankingConfig{SnapshotInterval: time.Hour}))
+	router := gin.Default()
+	router.GET("/users/:userID/videos/top", handler.GetUserTopVideosHandler())
+
+	req, _ := http.NewRequest("GET", "/users/user1/videos/top?at=2025-01-03T19:45:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		UserID string `json:"userID"`
+		Videos []struct {
+			VideoID string
+			Title   string
+			Score   float64
+		} `json:"videos"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Videos, 2)
+	// Catalog metadata with the score of the snapshot.
+	assert.Equal(t, "video1", resp.Videos[0].VideoID)
+	assert.Equal(t, "First", resp.Videos[0].Title)
+	assert.Equal(t, 30.0, resp.Videos[0].Score)
+	// Videos missing from the catalog keep what the snapshot recorded.
+	assert.Equal(t, "video3", resp.Videos[1].VideoID)
+	assert.Equal(t, 10.0, resp.Videos[1].Score)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/scoring"
+	"ranking-service/models"
+)
+
+func TestGetVideoStatsHandler(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user123", Score: 12.5},
+	}}
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+	router := gin.Default()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.GET("/videos/:video_id/stats", handler.GetVideoStatsHandler())
+
+	for _, interaction := range []models.InteractionRequest{
+		{Type: "view", UserID: "user123"},
+		{Type: "view", UserID: "user123"},
+		{Type: "like", UserID: "user123"},
+		{Type: "watch_time", Weight: 42.5, UserID: "user123"},
+	} {
+		bodyBytes, _ := json.Marshal(interaction)
+		req, _ := http.NewRequest("POST", "/videos/video1/interaction", bytes.NewBuffer(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, int64(2), fakePostgres.Stats["video1"].Views)
+
+	req, _ := http.NewRequest("GET", "/videos/video1/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.VideoStatsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.Counts["views"])
+	assert.Equal(t, int64(1), resp.Counts["likes"])
+	assert.Equal(t, int64(0), resp.Counts["shares"])
+	assert.Equal(t, 42.5, resp.TotalWatchTime)
+	assert.Equal(t, 12.5, resp.Score)
+}
+
+func TestGetVideoStatsHandler_FallsBackToPostgres(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Catalog: map[string]*models.Video{"video1": {VideoID: "video1"}},
+		Stats:   map[string]*models.VideoStats{"video1": {VideoID: "video1", Shares: 3}},
+	}
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{})
+	router := gin.Default()
+	router.GET("/videos/:video_id/stats", handler.GetVideoStatsHandler())
+
+	req, _ := http.NewRequest("GET", "/videos/video1/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.VideoStatsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(3), resp.Counts["shares"])
+}
+
+// failingScorer rejects every interaction.
+type failingScorer struct{}
+
+func (failingScorer) Name() string { return "failing" }
+
+func (failingScorer) Score(scoring.Interaction, scoring.VideoState) (scoring.ScoreUpdate, error) {
+	return scoring.ScoreUpdate{}, errors.New("evaluation failed")
+}
+
+func TestRecordInteraction_ScoringErrorWithdrawsCounters(t *testing.T) {
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(&FakePostgres{}, fakeRedis, handlers.WithScorer(failingScorer{}))
+
+	_, err := handler.RecordInteraction(models.InteractionRequest{VideoID: "video1", Type: "like", UserID: "user123"})
+	assert.Error(t, err)
+	assert.Equal(t, 0.0, fakeRedis.Counters["video1"][scoring.CounterLikes])
+}
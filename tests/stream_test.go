@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/stream"
+	"ranking-service/models"
+)
+
+func TestStreamDiff(t *testing.T) {
+	previous := []models.RankedVideo{
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video2", Score: 20},
+		{VideoID: "video3", Score: 10},
+	}
+	next := []models.RankedVideo{
+		{VideoID: "video2", Score: 40},
+		{VideoID: "video1", Score: 30},
+		{VideoID: "video4", Score: 15},
+	}
+
+	event, changed := stream.Diff(previous, next)
+
+	assert.True(t, changed)
+	assert.Equal(t, models.LeaderboardEventUpdate, event.Type)
+	assert.Equal(t, next, event.Top)
+	assert.Equal(t, []models.RankedVideo{{VideoID: "video4", Score: 15}}, event.Entered)
+	assert.Equal(t, []string{"video3"}, event.Exited)
+	assert.Equal(t, []models.LeaderboardMove{
+		{VideoID: "video2", From: 2, To: 1},
+		{VideoID: "video1", From: 1, To: 2},
+	}, event.Moved)
+
+	// Score changes alone are updates too.
+	_, changed = stream.Diff(previous, []models.RankedVideo{
+		{VideoID: "video1", Score: 31},
+		{VideoID: "video2", Score: 20},
+		{VideoID: "video3", Score: 10},
+	})
+	assert.True(t, changed)
+	_, changed = stream.Diff(previous, previous)
+	assert.False(t, changed)
+}
+
+func TestHub_CoalescesUpdates(t *testing.T) {
+	top := []models.RankedVideo{{VideoID: "video1", Score: 30}, {VideoID: "video2", Score: 20}}
+	fetches := make(chan int, 10)
+	hub := stream.NewHub(func(limit int) ([]models.RankedVideo, error) {
+		fetches <- limit
+		return top, nil
+	}, 20*time.Millisecond)
+
+	sub, err := hub.Subscribe(2)
+	assert.NoError(t, err)
+	snapshot := <-sub.Events()
+	assert.Equal(t, models.LeaderboardEventSnapshot, snapshot.Type)
+	assert.Equal(t, 2, <-fetches)
+
+	// Below the top: the leaderboard is not refreshed.
+	hub.Notify(models.RankingUpdate{VideoID: "video9", Score: 5})
+	// A burst of updates leads to a single refresh.
+	top = []models.RankedVideo{{VideoID: "video3", Score: 50}, {VideoID: "video1", Score: 30}}
+	for i := 0; i < 5; i++ {
+		hub.Notify(models.RankingUpdate{VideoID: "video3", Score: 50})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go hub.Run(ctx)
+
+	event := <-sub.Events()
+	assert.Equal(t, models.LeaderboardEventUpdate, event.Type)
+	assert.Equal(t, []string{"video2"}, event.Exited)
+	assert.Len(t, fetches, 1)
+
+	cancel()
+	_, open := <-sub.Events()
+	assert.False(t, open)
+}
+
+func newStreamServer(t *testing.T) (*httptest.Server, *FakeRedis, context.CancelFunc) {
+	fakeRedis := &FakeRedis{
+		TopVideosList: []models.RankedVideo{{VideoID: "video1", Score: 30}, {VideoID: "video2", Score: 20}},
+		Scores:        map[string]float64{"video2": 40},
+		Updates:       make(chan models.RankingUpdate, 16),
+	}
+	// Published updates carry the score computed from the catalog.
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{"video2": {VideoID: "video2", UserID: "user1", Score: 39}}}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis, handlers.WithConfig(config.RankingConfig{
+		StreamCoalesceInterval: 10 * time.Millisecond,
+		StreamMaxLimit:         50,
+		StreamKeepAlive:        time.Minute,
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	go handler.RunLiveUpdates(ctx)
+
+	router := gin.Default()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.GET("/videos/top/stream", handler.StreamTopVideosHandler())
+	router.GET("/videos/top/ws", handler.StreamTopVideosWebSocketHandler())
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, fakeRedis, cancel
+}
+
+// likeVideo2 moves video2 to the top and sends the interaction that announces it.
+func likeVideo2(t *testing.T, server *httptest.Server, fakeRedis *FakeRedis) {
+	fakeRedis.TopVideosList = []models.RankedVideo{{VideoID: "video2", Score: 40}, {VideoID: "video1", Score: 30}}
+	body, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "user1"})
+	resp, err := http.Post(server.URL+"/videos/video2/interaction", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestStreamTopVideosHandler(t *testing.T) {
+	server, fakeRedis, cancel := newStreamServer(t)
+	defer cancel()
+
+	resp, err := http.Get(server.URL + "/videos/top/stream?limit=2")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() (string, models.LeaderboardEvent) {
+		var name string
+		var event models.LeaderboardEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if !assert.NoError(t, err) {
+				return "", event
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				name = line[len("event:"):]
+			case strings.HasPrefix(line, "data:"):
+				assert.NoError(t, json.Unmarshal([]byte(line[len("data:"):]), &event))
+			case line == "" && name != "":
+				return name, event
+			}
+		}
+	}
+
+	name, event := readEvent()
+	assert.Equal(t, models.LeaderboardEventSnapshot, name)
+	assert.Len(t, event.Top, 2)
+
+	likeVideo2(t, server, fakeRedis)
+	assert.Equal(t, []models.RankingUpdate{{VideoID: "video2", Score: 40}}, fakeRedis.Published)
+
+	name, event = readEvent()
+	assert.Equal(t, models.LeaderboardEventUpdate, name)
+	assert.Equal(t, []models.LeaderboardMove{
+		{VideoID: "video2", From: 2, To: 1},
+		{VideoID: "video1", From: 1, To: 2},
+	}, event.Moved)
+}
+
+func TestStreamTopVideosWebSocketHandler(t *testing.T) {
+	server, fakeRedis, cancel := newStreamServer(t)
+	defer cancel()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/videos/top/ws?limit=2", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	var event models.LeaderboardEvent
+	assert.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.LeaderboardEventSnapshot, event.Type)
+
+	likeVideo2(t, server, fakeRedis)
+
+	assert.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.LeaderboardEventUpdate, event.Type)
+	assert.Equal(t, "video2", event.Top[0].VideoID)
+}
+
+func TestStreamTopVideosHandler_InvalidLimit(t *testing.T) {
+	server, _, cancel := newStreamServer(t)
+	defer cancel()
+
+	resp, err := http.Get(server.URL + "/videos/top/stream?limit=500")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRecordInteraction_HiddenVideoNotPublished(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", ModerationStatus: models.ModerationHidden},
+	}}
+	fakeRedis := &FakeRedis{}
+	handler := handlers.NewRankingHandler(fakePostgres, fakeRedis)
+
+	_, err := handler.RecordInteraction(models.InteractionRequest{VideoID: "video1", Type: "like", UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Empty(t, fakeRedis.Published)
+}
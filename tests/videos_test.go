@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/models"
+)
+
+func newCatalogRouter(handler *handlers.RankingHandler) *gin.Engine {
+	router := gin.Default()
+	router.POST("/videos", handler.CreateVideoHandler())
+	router.GET("/videos/:video_id", handler.GetVideoHandler())
+	router.PATCH("/videos/:video_id", handler.UpdateVideoHandler())
+	router.DELETE("/videos/:video_id", handler.DeleteVideoHandler())
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	return router
+}
+
+func TestCreateVideoHandler(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	router := newCatalogRouter(handlers.NewRankingHandler(fakePostgres, &FakeRedis{}))
+
+	bodyBytes, _ := json.Marshal(models.CreateVideoRequest{VideoID: "video1", UserID: "user123", Title: "First"})
+	req, _ := http.NewRequest("POST", "/videos", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "First", fakePostgres.Catalog["video1"].Title)
+	assert.Equal(t, models.VideoStatusActive, fakePostgres.Catalog["video1"].Status)
+
+	// Registering the same video twice conflicts.
+	req, _ = http.NewRequest("POST", "/videos", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDeleteVideoHandler_RemovesFromRanking(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user123", Status: models.VideoStatusActive},
+	}}
+	fakeRedis := &FakeRedis{}
+	router := newCatalogRouter(handlers.NewRankingHandler(fakePostgres, fakeRedis))
+
+	req, _ := http.NewRequest("DELETE", "/videos/video1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"video1"}, fakeRedis.Removed)
+	assert.Equal(t, models.VideoStatusDeleted, fakePostgres.Catalog["video1"].Status)
+
+	// Interactions on a deleted video are rejected.
+	bodyBytes, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "user123"})
+	req, _ = http.NewRequest("POST", "/videos/video1/interaction", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
+func TestUpdateVideoScoreHandler_RequireRegisteredVideos(t *testing.T) {
+	handler := handlers.NewRankingHandler(&FakePostgres{}, &FakeRedis{},
+		handlers.WithConfig(config.RankingConfig{RequireRegisteredVideos: true}))
+	router := newCatalogRouter(handler)
+
+	bodyBytes, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "user123"})
+	req, _ := http.NewRequest("POST", "/videos/unknown-video/interaction", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,358 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"ranking-service/config"
+	"ranking-service/internal/handlers"
+	"ranking-service/internal/webhook"
+	"ranking-service/models"
+)
+
+func TestWebhookSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+
+	signature := webhook.Sign("secret", []byte(`{"type":"top_entered"}`), at)
+
+	assert.True(t, strings.HasPrefix(signature, "t=1700000000,v1="))
+	assert.Equal(t, signature, webhook.Sign("secret", []byte(`{"type":"top_entered"}`), at))
+	assert.NotEqual(t, signature, webhook.Sign("other", []byte(`{"type":"top_entered"}`), at))
+	assert.NotEqual(t, signature, webhook.Sign("secret", []byte(`{"type":"top_entered"}`), at.Add(time.Second)))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.Backoff(1, 30*time.Second, time.Hour))
+	assert.Equal(t, 60*time.Second, webhook.Backoff(2, 30*time.Second, time.Hour))
+	assert.Equal(t, 240*time.Second, webhook.Backoff(4, 30*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, webhook.Backoff(20, 30*time.Second, time.Hour))
+}
+
+func webhookConfig() config.RankingConfig {
+	return config.RankingConfig{
+		WebhookInterval:    time.Second,
+		WebhookTimeout:     time.Second,
+		WebhookMaxAttempts: 3,
+		WebhookBackoff:     time.Minute,
+		WebhookMaxBackoff:  time.Hour,
+		WebhookBatchSize:   10,
+	}
+}
+
+func TestWebhookDispatcher_ScoreThreshold(t *testing.T) {
+	fakePostgres := &FakePostgres{Webhooks: []models.WebhookSubscription{
+		{ID: 1, URL: "http://example.com", Event: models.WebhookEventScoreThreshold, Threshold: 10},
+		{ID: 2, URL: "http://example.com", Event: models.WebhookEventScoreThreshold, Threshold: 10, UserID: "user2"},
+		{ID: 3, URL: "http://example.com", Event: models.WebhookEventTopEntered, TopN: 10},
+	}}
+	dispatcher := webhook.NewDispatcher(fakePostgres, &FakeRedis{}, nil, webhookConfig())
+	assert.NoError(t, dispatcher.Reload())
+
+	dispatcher.ScoreChanged("video1", "user1", 8, 9)
+	assert.Empty(t, fakePostgres.Deliveries)
+
+	dispatcher.ScoreChanged("video1", "user1", 9, 10.5)
+	if assert.Len(t, fakePostgres.Deliveries, 1) {
+		delivery := fakePostgres.Deliveries[0]
+		assert.Equal(t, uint(1), delivery.SubscriptionID)
+		assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+		var event models.WebhookEvent
+		assert.NoError(t, json.Unmarshal([]byte(delivery.Payload), &event))
+		assert.Equal(t, models.WebhookEventScoreThreshold, event.Type)
+		assert.Equal(t, "video1", event.VideoID)
+		assert.Equal(t, 10.5, event.Score)
+		assert.Equal(t, 10.0, event.Threshold)
+	}
+
+	// Staying above the threshold does not notify again.
+	dispatcher.ScoreChanged("video1", "user1", 10.5, 12)
+	assert.Len(t, fakePostgres.Deliveries, 1)
+}
+
+func TestWebhookDispatcher_TopEntered(t *testing.T) {
+	top := []models.RankedVideo{{VideoID: "video1", Score: 30}, {VideoID: "video2", Score: 20}, {VideoID: "video3", Score: 10}}
+	fakePostgres := &FakePostgres{
+		Webhooks: []models.WebhookSubscription{
+			{ID: 1, URL: "http://example.com", Event: models.WebhookEventTopEntered, TopN: 2},
+			{ID: 2, URL: "http://example.com", Event: models.WebhookEventTopEntered, TopN: 2, UserID: "user1"},
+		},
+		Catalog: map[string]*models.Video{
+			"video3": {VideoID: "video3", UserID: "user3"},
+		},
+	}
+	dispatcher := webhook.NewDispatcher(fakePostgres, &FakeRedis{}, func(limit int) ([]models.RankedVideo, error) {
+		return top[:min(limit, len(top))], nil
+	}, webhookConfig())
+	assert.NoError(t, dispatcher.Reload())
+
+	// The first tick sets the baseline.
+	assert.NoError(t, dispatcher.DetectTopEntries())
+	assert.Empty(t, fakePostgres.Deliveries)
+
+	top = []models.RankedVideo{{VideoID: "video3", Score: 50}, {VideoID: "video1", Score: 30}, {VideoID: "video2", Score: 20}}
+	assert.NoError(t, dispatcher.DetectTopEntries())
+	if assert.Len(t, fakePostgres.Deliveries, 1) {
+		var event models.WebhookEvent
+		assert.NoError(t, json.Unmarshal([]byte(fakePostgres.Deliveries[0].Payload), &event))
+		assert.Equal(t, models.WebhookEventTopEntered, event.Type)
+		assert.Equal(t, "video3", event.VideoID)
+		assert.Equal(t, "user3", event.UserID)
+		assert.Equal(t, 1, event.Rank)
+		assert.Equal(t, 2, event.TopN)
+	}
+
+	// No change, no event.
+	assert.NoError(t, dispatcher.DetectTopEntries())
+	assert.Len(t, fakePostgres.Deliveries, 1)
+}
+
+func TestWebhookDispatcher_DeliverDue(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	fakePostgres := &FakePostgres{
+		Webhooks: []models.WebhookSubscription{{ID: 1, URL: server.URL, Secret: "secret", Event: models.WebhookEventScoreThreshold, Threshold: 10}},
+	}
+	dispatcher := webhook.NewDispatcher(fakePostgres, &FakeRedis{}, nil, webhookConfig())
+	assert.NoError(t, dispatcher.Reload())
+	dispatcher.ScoreChanged("video1", "user1", 0, 10)
+
+	sent, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	delivery := fakePostgres.Deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	assert.NotNil(t, delivery.DeliveredAt)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, delivery.Payload, string(body))
+		assert.Equal(t, models.WebhookEventScoreThreshold, received.Header.Get(webhook.EventHeader))
+		assert.Equal(t, "1", received.Header.Get(webhook.DeliveryHeader))
+		signature := received.Header.Get(webhook.SignatureHeader)
+		part, _, _ := strings.Cut(signature, ",")
+		timestamp, err := strconv.ParseInt(strings.TrimPrefix(part, "t="), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.Sign("secret", body, time.Unix(timestamp, 0)), signature)
+	}
+
+	// Nothing is left to send.
+	sent, err = dispatcher.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestWebhookDispatcher_RetriesThenDeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	fakePostgres := &FakePostgres{
+		Webhooks: []models.WebhookSubscription{{ID: 1, URL: server.URL, Event: models.WebhookEventScoreThreshold, Threshold: 10}},
+	}
+	dispatcher := webhook.NewDispatcher(fakePostgres, &FakeRedis{}, nil, webhookConfig())
+	assert.NoError(t, dispatcher.Reload())
+	dispatcher.ScoreChanged("video1", "user1", 0, 10)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		before := time.Now()
+		sent, err := dispatcher.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+
+		delivery := &fakePostgres.Deliveries[0]
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+		assert.Equal(t, "unexpected status 500", delivery.LastError)
+		if attempt < 3 {
+			assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+			backoff := webhook.Backoff(attempt, time.Minute, time.Hour)
+			assert.WithinDuration(t, before.Add(backoff), delivery.NextAttemptAt, time.Second)
+			// Not due yet.
+			sent, _ = dispatcher.DeliverDue(context.Background())
+			assert.Equal(t, 0, sent)
+			delivery.NextAttemptAt = time.Now()
+		} else {
+			assert.Equal(t, models.WebhookDeliveryDead, delivery.Status)
+		}
+	}
+}
+
+func newWebhookRouter(fakePostgres *FakePostgres) *gin.Engine {
+	handler := handlers.NewRankingHandler(fakePostgres, &FakeRedis{})
+	router := gin.Default()
+	router.POST("/videos/:video_id/interaction", handler.UpdateVideoScoreHandler())
+	router.POST("/admin/webhooks", handler.CreateWebhookHandler())
+	router.GET("/admin/webhooks", handler.ListWebhooksHandler())
+	router.DELETE("/admin/webhooks/:id", handler.DeleteWebhookHandler())
+	router.GET("/admin/webhooks/deliveries", handler.ListWebhookDeliveriesHandler())
+	router.POST("/admin/webhooks/deliveries/:id/retry", handler.RetryWebhookDeliveryHandler())
+	return router
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	router := newWebhookRouter(fakePostgres)
+
+	body := `{"url":"https://partner.example.com/hooks","event":"top_entered"}`
+	req, _ := http.NewRequest("POST", "/admin/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.CreatedWebhookSubscription
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, 10, created.TopN)
+	assert.Len(t, created.Secret, 64)
+	assert.Equal(t, created.Secret, fakePostgres.Webhooks[0].Secret)
+
+	// The secret is not listed.
+	req, _ = http.NewRequest("GET", "/admin/webhooks", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+	assert.Contains(t, w.Body.String(), "https://partner.example.com/hooks")
+}
+
+func TestCreateWebhookHandler_Invalid(t *testing.T) {
+	router := newWebhookRouter(&FakePostgres{})
+
+	for _, body := range []string{
+		`{"url":"ftp://partner.example.com","event":"top_entered"}`,
+		`{"url":"/hooks","event":"top_entered"}`,
+		`{"url":"https://partner.example.com","event":"unknown"}`,
+		`{"url":"https://partner.example.com","event":"score_threshold"}`,
+		`{"url":"https://partner.example.com","event":"top_entered","top_n":5000}`,
+	} {
+		req, _ := http.NewRequest("POST", "/admin/webhooks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestWebhooks_ThresholdFromInteraction(t *testing.T) {
+	fakePostgres := &FakePostgres{}
+	router := newWebhookRouter(fakePostgres)
+
+	body := `{"url":"https://partner.example.com/hooks","event":"score_threshold","threshold":1,"user_id":"user1"}`
+	req, _ := http.NewRequest("POST", "/admin/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	interaction, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "user1"})
+	req, _ = http.NewRequest("POST", "/videos/video1/interaction", bytes.NewBuffer(interaction))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if assert.Len(t, fakePostgres.Deliveries, 1) {
+		assert.Contains(t, fakePostgres.Deliveries[0].Payload, `"videoID":"video1"`)
+	}
+}
+
+func TestWebhooks_HiddenVideoNoThreshold(t *testing.T) {
+	fakePostgres := &FakePostgres{Catalog: map[string]*models.Video{
+		"video1": {VideoID: "video1", UserID: "user1", ModerationStatus: models.ModerationHidden},
+	}}
+	router := newWebhookRouter(fakePostgres)
+
+	body := `{"url":"https://partner.example.com/hooks","event":"score_threshold","threshold":1}`
+	req, _ := http.NewRequest("POST", "/admin/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	interaction, _ := json.Marshal(models.InteractionRequest{Type: "like", UserID: "user1"})
+	req, _ = http.NewRequest("POST", "/videos/video1/interaction", bytes.NewBuffer(interaction))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The score of the hidden video crossed the threshold, without notification.
+	assert.Empty(t, fakePostgres.Deliveries)
+}
+
+func TestWebhookDeliveriesHandlers(t *testing.T) {
+	fakePostgres := &FakePostgres{
+		Webhooks: []models.WebhookSubscription{{ID: 1, URL: "https://partner.example.com", Event: models.WebhookEventTopEntered, TopN: 10}},
+		Deliveries: []models.WebhookDelivery{
+			{ID: 1, SubscriptionID: 1, Status: models.WebhookDeliveryDelivered},
+			{ID: 2, SubscriptionID: 1, Status: models.WebhookDeliveryDead, Attempts: 8},
+		},
+	}
+	router := newWebhookRouter(fakePostgres)
+
+	req, _ := http.NewRequest("GET", "/admin/webhooks/deliveries?status=dead", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var dead []models.WebhookDelivery
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &dead))
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, uint(2), dead[0].ID)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/webhooks/deliveries?subscription_id=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var all []models.WebhookDelivery
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	assert.Len(t, all, 2)
+
+	// Only dead deliveries can be retried.
+	req, _ = http.NewRequest("POST", "/admin/webhooks/deliveries/1/retry", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req, _ = http.NewRequest("POST", "/admin/webhooks/deliveries/2/retry", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.WebhookDeliveryPending, fakePostgres.Deliveries[1].Status)
+	assert.Equal(t, 0, fakePostgres.Deliveries[1].Attempts)
+
+	req, _ = http.NewRequest("POST", "/admin/webhooks/deliveries/9/retry", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/admin/webhooks/1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, fakePostgres.Webhooks)
+
+	req, _ = http.NewRequest("DELETE", "/admin/webhooks/1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}